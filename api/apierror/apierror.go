@@ -0,0 +1,155 @@
+// Package apierror define las respuestas de error de la API. Se escriben como
+// application/problem+json (RFC 9457) con un código estable que los clientes pueden comparar en
+// lugar del mensaje, que es para mostrar al usuario y se traduce al idioma de la solicitud.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/hugodiazo/arq-soft-2/i18n"
+)
+
+// ContentType es el tipo de contenido de las respuestas de error
+const ContentType = "application/problem+json"
+
+// Códigos de los errores de campo
+const (
+	FieldRequired   = "required"
+	FieldInvalid    = "invalid"
+	FieldOutOfRange = "out_of_range"
+	FieldUnknown    = "unknown"
+)
+
+// Error es un error de la API con su estado HTTP, un código estable y el mensaje para el usuario
+type Error struct {
+	Status int
+	Code   string
+	// MessageID es el mensaje en el catálogo de i18n y Args sus parámetros
+	MessageID string
+	Args      []any
+	// Fields detalla los campos inválidos de un error de validación
+	Fields []FieldError
+}
+
+// New crea un error de la API con el mensaje messageID del catálogo
+func New(status int, code, messageID string, args ...any) *Error {
+	return &Error{Status: status, Code: code, MessageID: messageID, Args: args}
+}
+
+// Error devuelve el mensaje en el idioma por defecto
+func (e *Error) Error() string {
+	return i18n.T(i18n.Default, e.MessageID, e.Args...)
+}
+
+// WithMessage devuelve una copia del error con otro mensaje y el mismo código
+func (e *Error) WithMessage(messageID string, args ...any) *Error {
+	copied := *e
+	copied.MessageID, copied.Args = messageID, args
+	return &copied
+}
+
+// FieldError es un problema de validación de un campo del cuerpo o de un parámetro de la consulta
+type FieldError struct {
+	Field     string
+	Code      string
+	MessageID string
+	Args      []any
+}
+
+// Field crea el error de validación del campo field con el mensaje messageID del catálogo
+func Field(field, code, messageID string, args ...any) FieldError {
+	return FieldError{Field: field, Code: code, MessageID: messageID, Args: args}
+}
+
+// Error devuelve el mensaje en el idioma por defecto
+func (e FieldError) Error() string {
+	return i18n.T(i18n.Default, e.MessageID, e.Args...)
+}
+
+// Errores comunes a todos los handlers
+var (
+	ErrInvalidBody      = New(http.StatusBadRequest, "invalid_body", "errors.invalid_body")
+	ErrInvalidID        = New(http.StatusBadRequest, "invalid_id", "errors.invalid_id")
+	ErrUnauthorized     = New(http.StatusUnauthorized, "unauthorized", "errors.unauthorized")
+	ErrForbidden        = New(http.StatusForbidden, "forbidden", "errors.forbidden")
+	ErrMethodNotAllowed = New(http.StatusMethodNotAllowed, "method_not_allowed", "errors.method_not_allowed")
+	ErrInternal         = New(http.StatusInternalServerError, "internal_error", "errors.internal")
+)
+
+// Internal crea un error 500 con un mensaje que indica qué operación falló
+func Internal(messageID string) *Error {
+	return ErrInternal.WithMessage(messageID)
+}
+
+// Validation crea un error 400 con los errores de campo que contiene err, que puede unir varios
+// con errors.Join. Si err no contiene errores de campo se usa su texto sin traducir.
+func Validation(err error) *Error {
+	e := New(http.StatusBadRequest, "validation_failed", err.Error())
+	e.Fields = fieldErrors(err)
+	switch {
+	case len(e.Fields) == 1:
+		e.MessageID, e.Args = e.Fields[0].MessageID, e.Fields[0].Args
+	case len(e.Fields) > 1:
+		e.MessageID = "errors.validation_failed"
+	}
+	return e
+}
+
+// fieldErrors junta los FieldError de err recorriendo los errores unidos con errors.Join
+func fieldErrors(err error) []FieldError {
+	var field FieldError
+	if errors.As(err, &field) {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			var fields []FieldError
+			for _, inner := range joined.Unwrap() {
+				fields = append(fields, fieldErrors(inner)...)
+			}
+			return fields
+		}
+		return []FieldError{field}
+	}
+	return nil
+}
+
+// Problem es el cuerpo de la respuesta de error
+type Problem struct {
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Code     string         `json:"code"`
+	Detail   string         `json:"detail"`
+	Instance string         `json:"instance,omitempty"`
+	Errors   []FieldProblem `json:"errors,omitempty"`
+}
+
+// FieldProblem es un error de campo dentro de Problem
+type FieldProblem struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Write responde el error como application/problem+json en el idioma de la solicitud
+func Write(w http.ResponseWriter, r *http.Request, err *Error) {
+	lang := i18n.Lang(r.Context())
+	problem := Problem{
+		Title:    http.StatusText(err.Status),
+		Status:   err.Status,
+		Code:     err.Code,
+		Detail:   i18n.T(lang, err.MessageID, err.Args...),
+		Instance: r.URL.Path,
+	}
+	for _, field := range err.Fields {
+		problem.Errors = append(problem.Errors, FieldProblem{
+			Field:   field.Field,
+			Code:    field.Code,
+			Message: i18n.T(lang, field.MessageID, field.Args...),
+		})
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(problem)
+}
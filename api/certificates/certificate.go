@@ -0,0 +1,121 @@
+package certificates
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Algorithm es el algoritmo de firma de los certificados
+const Algorithm = "Ed25519"
+
+// Certificate es el certificado de finalización de un curso. Guarda una copia del nombre del
+// usuario y del título del curso al momento de emitirlo.
+type Certificate struct {
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserID      int                `json:"user_id" bson:"user_id"`
+	UserName    string             `json:"user_name" bson:"user_name"`
+	CourseID    string             `json:"course_id" bson:"course_id"`
+	CourseTitle string             `json:"course_title" bson:"course_title"`
+	CompletedAt time.Time          `json:"completed_at" bson:"completed_at"`
+	IssuedAt    time.Time          `json:"issued_at" bson:"issued_at"`
+	// KeyID identifica la clave con la que se firmó y Signature es la firma en base64 de payload
+	KeyID     string `json:"key_id" bson:"key_id"`
+	Signature string `json:"signature" bson:"signature"`
+}
+
+// payload es el contenido firmado: todos los datos del certificado en un orden fijo
+func (c Certificate) payload() []byte {
+	fields := []string{
+		"certificate/v1",
+		c.ID.Hex(),
+		strconv.Itoa(c.UserID),
+		c.UserName,
+		c.CourseID,
+		c.CourseTitle,
+		c.CompletedAt.UTC().Format(time.RFC3339),
+		c.IssuedAt.UTC().Format(time.RFC3339),
+		c.KeyID,
+	}
+	return []byte(strings.Join(fields, "\n"))
+}
+
+// Signer firma certificados con la clave del servidor y los verifica con ella o con las claves
+// públicas anteriores, para que la rotación no invalide los certificados ya emitidos
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+	// keyring son las claves públicas aceptadas por KeyID, incluida la actual
+	keyring map[string]ed25519.PublicKey
+}
+
+// NewSigner crea un firmante a partir de la semilla Ed25519 de 32 bytes y de las claves públicas
+// de las semillas retiradas
+func NewSigner(seed []byte, previous ...[]byte) (*Signer, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("la clave de certificados debe tener %d bytes", ed25519.SeedSize)
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	public := key.Public().(ed25519.PublicKey)
+	s := &Signer{key: key, keyID: keyID(public), keyring: map[string]ed25519.PublicKey{}}
+	s.keyring[s.keyID] = public
+
+	for _, old := range previous {
+		if len(old) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("las claves públicas de certificados anteriores deben tener %d bytes", ed25519.PublicKeySize)
+		}
+		s.keyring[keyID(old)] = ed25519.PublicKey(old)
+	}
+	return s, nil
+}
+
+// keyID identifica una clave pública con los primeros 8 bytes de su SHA-256
+func keyID(public ed25519.PublicKey) string {
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:8])
+}
+
+// KeyID identifica la clave pública del firmante
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey devuelve la clave pública actual en base64 para que terceros verifiquen las firmas
+func (s *Signer) PublicKey() string {
+	return s.PublicKeyFor(s.keyID)
+}
+
+// PublicKeyFor devuelve en base64 la clave pública identificada por keyID; vacío si no la conoce
+func (s *Signer) PublicKeyFor(keyID string) string {
+	public, ok := s.keyring[keyID]
+	if !ok {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(public)
+}
+
+// Sign completa KeyID y Signature del certificado
+func (s *Signer) Sign(c *Certificate) {
+	c.KeyID = s.keyID
+	c.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, c.payload()))
+}
+
+// Verify indica si el certificado fue firmado con una clave conocida y no se modificó
+func (s *Signer) Verify(c Certificate) bool {
+	public, ok := s.keyring[c.KeyID]
+	if !ok {
+		return false
+	}
+	signature, err := base64.StdEncoding.DecodeString(c.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(public, c.payload(), signature)
+}
@@ -0,0 +1,135 @@
+package certificates
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestSigner crea un firmante con una semilla fija derivada de b
+func newTestSigner(t *testing.T, b byte, previous ...[]byte) *Signer {
+	t.Helper()
+	signer, err := NewSigner(bytes.Repeat([]byte{b}, ed25519.SeedSize), previous...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func testCertificate() Certificate {
+	completed := time.Date(2026, 3, 5, 10, 0, 0, 0, time.UTC)
+	return Certificate{
+		ID:          primitive.NewObjectID(),
+		UserID:      7,
+		UserName:    "Ada Lovelace",
+		CourseID:    primitive.NewObjectID().Hex(),
+		CourseTitle: "Go (avanzado)",
+		CompletedAt: completed,
+		IssuedAt:    completed.Add(time.Hour),
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	signer := newTestSigner(t, 1)
+	certificate := testCertificate()
+	signer.Sign(&certificate)
+
+	if certificate.KeyID != signer.KeyID() || certificate.Signature == "" {
+		t.Fatalf("certificado firmado: %+v", certificate)
+	}
+	if !signer.Verify(certificate) {
+		t.Fatal("la firma de un certificado sin cambios no es válida")
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	signer := newTestSigner(t, 1)
+	certificate := testCertificate()
+	signer.Sign(&certificate)
+
+	tests := map[string]func(*Certificate){
+		"usuario":      func(c *Certificate) { c.UserID++ },
+		"nombre":       func(c *Certificate) { c.UserName = "Grace Hopper" },
+		"curso":        func(c *Certificate) { c.CourseTitle = "Rust" },
+		"finalización": func(c *Certificate) { c.CompletedAt = c.CompletedAt.AddDate(-1, 0, 0) },
+		"clave":        func(c *Certificate) { c.KeyID = "0000000000000000" },
+		"firma":        func(c *Certificate) { c.Signature = "no es base64" },
+	}
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			modified := certificate
+			tamper(&modified)
+			if signer.Verify(modified) {
+				t.Error("se aceptó un certificado modificado")
+			}
+		})
+	}
+}
+
+func TestVerifyWithPreviousKeys(t *testing.T) {
+	old := newTestSigner(t, 1)
+	certificate := testCertificate()
+	old.Sign(&certificate)
+
+	previous, err := base64.StdEncoding.DecodeString(old.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := newTestSigner(t, 2, previous)
+	if rotated.KeyID() == old.KeyID() {
+		t.Fatal("la clave rotada tiene el mismo KeyID")
+	}
+	if !rotated.Verify(certificate) {
+		t.Error("no se aceptó un certificado firmado con la clave anterior")
+	}
+	if rotated.PublicKeyFor(certificate.KeyID) != old.PublicKey() {
+		t.Error("PublicKeyFor no devolvió la clave anterior")
+	}
+
+	// Sin la clave anterior en el llavero el certificado no se puede verificar
+	if newTestSigner(t, 2).Verify(certificate) {
+		t.Error("se aceptó un certificado firmado con una clave desconocida")
+	}
+	if _, err := NewSigner(bytes.Repeat([]byte{2}, ed25519.SeedSize), []byte("corta")); err == nil {
+		t.Error("se aceptó una clave pública anterior inválida")
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Go", "(Go)"},
+		{"Go (avanzado)", `(Go \(avanzado\))`},
+		{`C:\cursos`, `(C:\\cursos)`},
+		{") Tj /F1 99 Tf (", `(\) Tj /F1 99 Tf \()`},
+		{"línea\nnueva", `(l\355nea\012nueva)`},
+	}
+	for _, tt := range tests {
+		if got := pdfString(winAnsi(tt.text)); got != tt.want {
+			t.Errorf("pdfString(%q) = %s, se esperaba %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRenderPDFEscapesText(t *testing.T) {
+	certificate := testCertificate()
+	certificate.UserName = "Ada) Tj (x"
+	newTestSigner(t, 1).Sign(&certificate)
+
+	pdf := RenderPDF(certificate, "http://localhost:8080/certificates/1/verify", "es")
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("el documento no es un PDF completo")
+	}
+	if !bytes.Contains(pdf, []byte(`(Ada\) Tj \(x)`)) {
+		t.Error("el nombre del usuario no se escapó")
+	}
+	if bytes.Contains(pdf, []byte("(Ada) Tj (x)")) {
+		t.Error("el nombre del usuario se escribió sin escapar")
+	}
+}
@@ -0,0 +1,161 @@
+package certificates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/courses"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/i18n"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Errores de la API de certificados
+var (
+	errCertificateNotFound = apierror.New(http.StatusNotFound, "certificate_not_found", "certificates.not_found")
+	errEnrollmentNotFound  = apierror.New(http.StatusNotFound, "enrollment_not_found", "enrollments.not_found")
+	errNotCompleted        = apierror.New(http.StatusConflict, "enrollment_not_completed", "certificates.not_completed")
+)
+
+// Handler agrupa los handlers HTTP de certificados y sus dependencias
+type Handler struct {
+	issuer       *Issuer
+	certificates Repository
+	enrollments  courses.EnrollmentRepository
+	signer       *Signer
+	policy       auth.Policy
+	// publicURL es la URL base del servidor que se imprime en el certificado para verificarlo
+	publicURL string
+}
+
+// NewHandler crea los handlers de certificados; publicURL es la URL base pública del servidor
+func NewHandler(issuer *Issuer, certificates Repository, enrollments courses.EnrollmentRepository, signer *Signer, policy auth.Policy, publicURL string) *Handler {
+	return &Handler{
+		issuer:       issuer,
+		certificates: certificates,
+		enrollments:  enrollments,
+		signer:       signer,
+		policy:       policy,
+		publicURL:    strings.TrimRight(publicURL, "/"),
+	}
+}
+
+// Verification es el resultado público de verificar un certificado. SignedData es el contenido
+// firmado, para que terceros puedan comprobar la firma con PublicKey.
+type Verification struct {
+	Valid       bool        `json:"valid"`
+	Certificate Certificate `json:"certificate"`
+	Algorithm   string      `json:"algorithm"`
+	PublicKey   string      `json:"public_key"`
+	SignedData  string      `json:"signed_data"`
+}
+
+// writePDF responde el certificado como PDF en el idioma de la solicitud
+func (h *Handler) writePDF(w http.ResponseWriter, r *http.Request, certificate Certificate) {
+	lang := i18n.Lang(r.Context())
+	verifyURL := fmt.Sprintf("%s/certificates/%s/verify", h.publicURL, certificate.ID.Hex())
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", i18n.T(lang, "certificates.pdf_filename", certificate.ID.Hex())))
+	w.Write(RenderPDF(certificate, verifyURL, lang))
+}
+
+// GetEnrollmentCertificate maneja GET /enrollments/{course_id}/certificate con el certificado de la
+// inscripción completada del usuario, o del usuario ?user_id= con enrollment:manage; si no se
+// emitió al completar la inscripción se emite ahora
+func (h *Handler) GetEnrollmentCertificate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+	courseID := r.PathValue("course_id")
+	if !primitive.IsValidObjectID(courseID) {
+		apierror.Write(w, r, apierror.ErrInvalidID.WithMessage("courses.invalid_id"))
+		return
+	}
+
+	userID := claims.UserID
+	if v := r.URL.Query().Get("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			apierror.Write(w, r, apierror.Validation(apierror.Field("user_id", apierror.FieldInvalid, "validation.invalid", "user_id")))
+			return
+		}
+		if id != claims.UserID && !h.policy.Allows(claims.Role, auth.PermEnrollmentManage) {
+			apierror.Write(w, r, apierror.ErrForbidden.WithMessage("certificates.forbidden_other"))
+			return
+		}
+		userID = id
+	}
+
+	enrollment, err := h.enrollments.Get(r.Context(), userID, courseID)
+	if errors.Is(err, courses.ErrEnrollmentNotFound) {
+		apierror.Write(w, r, errEnrollmentNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("enrollments.fetch_failed"))
+		return
+	}
+
+	certificate, err := h.issuer.Issue(r.Context(), enrollment)
+	if errors.Is(err, ErrNotCompleted) {
+		apierror.Write(w, r, errNotCompleted)
+		return
+	} else if err != nil {
+		log.Println("Error al emitir el certificado:", err)
+		apierror.Write(w, r, apierror.Internal("certificates.issue_failed"))
+		return
+	}
+	h.writePDF(w, r, certificate)
+}
+
+// GetCertificate maneja GET /certificates/{id} con el PDF del certificado, para su dueño o
+// quien tenga enrollment:manage
+func (h *Handler) GetCertificate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+
+	certificate, err := h.certificates.GetByID(r.Context(), r.PathValue("id"))
+	if errors.Is(err, ErrCertificateNotFound) {
+		apierror.Write(w, r, errCertificateNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("certificates.fetch_failed"))
+		return
+	}
+	if certificate.UserID != claims.UserID && !h.policy.Allows(claims.Role, auth.PermEnrollmentManage) {
+		apierror.Write(w, r, apierror.ErrForbidden.WithMessage("certificates.forbidden"))
+		return
+	}
+	h.writePDF(w, r, certificate)
+}
+
+// VerifyCertificate maneja GET /certificates/{id}/verify; es pública para que terceros comprueben
+// que el certificado fue emitido por el servidor y no se modificó
+func (h *Handler) VerifyCertificate(w http.ResponseWriter, r *http.Request) {
+	certificate, err := h.certificates.GetByID(r.Context(), r.PathValue("id"))
+	if errors.Is(err, ErrCertificateNotFound) {
+		apierror.Write(w, r, errCertificateNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("certificates.fetch_failed"))
+		return
+	}
+
+	json.NewEncoder(w).Encode(Verification{
+		Valid:       h.signer.Verify(certificate),
+		Certificate: certificate,
+		Algorithm:   Algorithm,
+		PublicKey:   h.signer.PublicKeyFor(certificate.KeyID),
+		SignedData:  string(certificate.payload()),
+	})
+}
@@ -0,0 +1,89 @@
+package certificates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/courses"
+	"github.com/hugodiazo/arq-soft-2/api/users"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNotCompleted se devuelve al pedir el certificado de una inscripción que no está completada
+var ErrNotCompleted = errors.New("la inscripción no está completada")
+
+// Issuer emite los certificados de las inscripciones completadas; implementa courses.CompletionHook
+type Issuer struct {
+	certificates Repository
+	courses      courses.CourseRepository
+	users        users.UserRepository
+	signer       *Signer
+}
+
+// NewIssuer crea un emisor de certificados con los repositorios y el firmante dados
+func NewIssuer(certificates Repository, courseRepo courses.CourseRepository, userRepo users.UserRepository, signer *Signer) *Issuer {
+	return &Issuer{
+		certificates: certificates,
+		courses:      courseRepo,
+		users:        userRepo,
+		signer:       signer,
+	}
+}
+
+// EnrollmentCompleted emite el certificado de la inscripción recién completada
+func (i *Issuer) EnrollmentCompleted(ctx context.Context, enrollment courses.Enrollment) error {
+	_, err := i.Issue(ctx, enrollment)
+	return err
+}
+
+// Issue devuelve el certificado de la inscripción completada, emitiéndolo si todavía no existe
+func (i *Issuer) Issue(ctx context.Context, enrollment courses.Enrollment) (Certificate, error) {
+	if enrollment.Status != courses.EnrollmentCompleted {
+		return Certificate{}, ErrNotCompleted
+	}
+
+	existing, err := i.certificates.GetByEnrollment(ctx, enrollment.UserID, enrollment.CourseID)
+	if err == nil || !errors.Is(err, ErrCertificateNotFound) {
+		return existing, err
+	}
+
+	user, err := i.users.GetByID(ctx, enrollment.UserID)
+	if err != nil {
+		return Certificate{}, fmt.Errorf("error al obtener el usuario %d: %w", enrollment.UserID, err)
+	}
+	// Los cursos archivados siguen siendo válidos para certificar lo ya completado
+	course, err := i.courses.GetByID(ctx, enrollment.CourseID)
+	if err != nil {
+		return Certificate{}, fmt.Errorf("error al obtener el curso %s: %w", enrollment.CourseID, err)
+	}
+
+	certificate := Certificate{
+		ID:          primitive.NewObjectID(),
+		UserID:      user.ID,
+		UserName:    user.Name,
+		CourseID:    enrollment.CourseID,
+		CourseTitle: course.Title,
+		CompletedAt: completedAt(enrollment),
+		IssuedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+	i.signer.Sign(&certificate)
+
+	err = i.certificates.Create(ctx, certificate)
+	if errors.Is(err, ErrCertificateExists) {
+		// Otra solicitud lo emitió al mismo tiempo
+		return i.certificates.GetByEnrollment(ctx, enrollment.UserID, enrollment.CourseID)
+	}
+	return certificate, err
+}
+
+// completedAt devuelve la fecha en que la inscripción pasó a completada
+func completedAt(enrollment courses.Enrollment) time.Time {
+	for j := len(enrollment.History) - 1; j >= 0; j-- {
+		if enrollment.History[j].To == courses.EnrollmentCompleted {
+			return enrollment.History[j].At.UTC().Truncate(time.Second)
+		}
+	}
+	return enrollment.UpdatedAt.UTC().Truncate(time.Second)
+}
@@ -0,0 +1,54 @@
+package certificates
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryRepository implementa Repository en memoria para pruebas y desarrollo
+type MemoryRepository struct {
+	mu           sync.RWMutex
+	certificates []Certificate
+}
+
+// NewMemoryRepository crea un repositorio de certificados vacío
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, certificate Certificate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.certificates {
+		if existing.UserID == certificate.UserID && existing.CourseID == certificate.CourseID {
+			return ErrCertificateExists
+		}
+	}
+	r.certificates = append(r.certificates, certificate)
+	return nil
+}
+
+func (r *MemoryRepository) GetByID(ctx context.Context, id string) (Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, certificate := range r.certificates {
+		if certificate.ID.Hex() == id {
+			return certificate, nil
+		}
+	}
+	return Certificate{}, ErrCertificateNotFound
+}
+
+func (r *MemoryRepository) GetByEnrollment(ctx context.Context, userID int, courseID string) (Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, certificate := range r.certificates {
+		if certificate.UserID == userID && certificate.CourseID == courseID {
+			return certificate, nil
+		}
+	}
+	return Certificate{}, ErrCertificateNotFound
+}
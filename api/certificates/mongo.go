@@ -0,0 +1,49 @@
+package certificates
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoRepository implementa Repository sobre la colección certificates
+type MongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRepository crea un repositorio de certificados sobre la base dada
+func NewMongoRepository(db *mongo.Database) *MongoRepository {
+	return &MongoRepository{collection: db.Collection("certificates")}
+}
+
+func (r *MongoRepository) Create(ctx context.Context, certificate Certificate) error {
+	_, err := r.collection.InsertOne(ctx, certificate)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrCertificateExists
+	}
+	return err
+}
+
+func (r *MongoRepository) GetByID(ctx context.Context, id string) (Certificate, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Certificate{}, ErrCertificateNotFound
+	}
+	return r.findOne(ctx, bson.M{"_id": objectID})
+}
+
+func (r *MongoRepository) GetByEnrollment(ctx context.Context, userID int, courseID string) (Certificate, error) {
+	return r.findOne(ctx, bson.M{"user_id": userID, "course_id": courseID})
+}
+
+func (r *MongoRepository) findOne(ctx context.Context, filter bson.M) (Certificate, error) {
+	var certificate Certificate
+	err := r.collection.FindOne(ctx, filter).Decode(&certificate)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return certificate, ErrCertificateNotFound
+	}
+	return certificate, err
+}
@@ -0,0 +1,140 @@
+package certificates
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/i18n"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Medidas de una hoja A4 apaisada en puntos
+const (
+	pageWidth  = 842
+	pageHeight = 595
+	// maxTextWidth es el ancho máximo de una línea antes de achicar la fuente
+	maxTextWidth = 700
+)
+
+// helveticaWidths son los anchos de Helvetica para los caracteres 32 a 126, en milésimas del
+// tamaño de la fuente; se usan también para la negrita, que es apenas más ancha
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// formatDate escribe la fecha en el idioma dado, por ejemplo "el 5 de marzo de 2026"; los nombres
+// de los meses están en el catálogo separados por comas
+func formatDate(t time.Time, lang string) string {
+	months := strings.Split(i18n.T(lang, "certificates.pdf_months"), ",")
+	return i18n.T(lang, "certificates.pdf_date", t.Day(), months[t.Month()-1], t.Year())
+}
+
+// winAnsi convierte el texto a la codificación WinAnsi de las fuentes estándar de PDF
+func winAnsi(text string) []byte {
+	encoded, err := encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder()).Bytes([]byte(text))
+	if err != nil {
+		return []byte(text)
+	}
+	return encoded
+}
+
+// textWidth calcula el ancho en puntos del texto codificado
+func textWidth(text []byte, size float64) float64 {
+	total := 0
+	for _, b := range text {
+		if b >= 32 && int(b-32) < len(helveticaWidths) {
+			total += helveticaWidths[b-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// pdfString escribe el texto como cadena literal de PDF escapando los caracteres especiales
+func pdfString(text []byte) string {
+	var b bytes.Buffer
+	b.WriteByte('(')
+	for _, c := range text {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// centered agrega una línea centrada, achicando la fuente si no entra en maxTextWidth
+func centered(content *bytes.Buffer, font string, size, y float64, text string) {
+	encoded := winAnsi(text)
+	if width := textWidth(encoded, size); width > maxTextWidth {
+		size = size * maxTextWidth / width
+	}
+	x := (pageWidth - textWidth(encoded, size)) / 2
+	fmt.Fprintf(content, "BT /%s %.2f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, y, pdfString(encoded))
+}
+
+// RenderPDF genera el certificado como un PDF de una página en el idioma lang con los datos, el
+// código de verificación y la firma
+func RenderPDF(c Certificate, verifyURL, lang string) []byte {
+	title := i18n.T(lang, "certificates.pdf_title")
+	var content bytes.Buffer
+	content.WriteString("0.16 0.29 0.48 RG 4 w 24 24 794 547 re S\n")
+	content.WriteString("1 w 36 36 770 523 re S\n")
+	content.WriteString("0.16 0.29 0.48 rg\n")
+	centered(&content, "F2", 32, 460, title)
+	content.WriteString("0 0 0 rg\n")
+	centered(&content, "F1", 14, 405, i18n.T(lang, "certificates.pdf_certifies"))
+	centered(&content, "F2", 26, 365, c.UserName)
+	centered(&content, "F1", 14, 325, i18n.T(lang, "certificates.pdf_completed"))
+	centered(&content, "F2", 22, 285, c.CourseTitle)
+	centered(&content, "F1", 14, 245, formatDate(c.CompletedAt, lang))
+	content.WriteString("0.35 0.35 0.35 rg\n")
+	centered(&content, "F1", 9, 110, i18n.T(lang, "certificates.pdf_code", c.ID.Hex()))
+	centered(&content, "F1", 9, 96, i18n.T(lang, "certificates.pdf_verify", verifyURL))
+	centered(&content, "F1", 7, 82, i18n.T(lang, "certificates.pdf_signature", Algorithm, c.KeyID, c.Signature))
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> /Contents 4 0 R >>", pageWidth, pageHeight),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title %s /Subject %s /Keywords %s /CreationDate (D:%s) >>",
+			pdfString(winAnsi(title)),
+			pdfString(winAnsi(c.CourseTitle)),
+			pdfString([]byte(c.ID.Hex()+" "+c.Signature)),
+			c.IssuedAt.UTC().Format("20060102150405Z")),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, len(objects), xref)
+	return out.Bytes()
+}
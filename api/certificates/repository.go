@@ -0,0 +1,21 @@
+package certificates
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrCertificateNotFound se devuelve cuando no existe el certificado buscado
+	ErrCertificateNotFound = errors.New("certificado no encontrado")
+	// ErrCertificateExists se devuelve cuando la inscripción ya tiene un certificado emitido
+	ErrCertificateExists = errors.New("la inscripción ya tiene un certificado")
+)
+
+// Repository define el acceso a los certificados emitidos
+type Repository interface {
+	// Create guarda el certificado firmado; devuelve ErrCertificateExists si la inscripción ya tiene uno
+	Create(ctx context.Context, certificate Certificate) error
+	GetByID(ctx context.Context, id string) (Certificate, error)
+	GetByEnrollment(ctx context.Context, userID int, courseID string) (Certificate, error)
+}
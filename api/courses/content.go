@@ -0,0 +1,495 @@
+package courses
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/validate"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/i18n"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tipos de lección
+const (
+	LessonVideo = "video"
+	LessonText  = "text"
+	LessonQuiz  = "quiz"
+)
+
+// Lesson es una unidad de contenido dentro de un módulo
+type Lesson struct {
+	ID               string `json:"id" bson:"id"`
+	Title            string `json:"title" bson:"title" validate:"required,max=200"`
+	Order            int    `json:"order" bson:"order" validate:"min=0"`
+	Type             string `json:"type" bson:"type" validate:"required,oneof=video text quiz"`
+	EstimatedMinutes int    `json:"estimated_minutes" bson:"estimated_minutes" validate:"min=0"`
+	// Body es el contenido de las lecciones de texto y ResourceURL el recurso de las de video
+	Body        string `json:"body,omitempty" bson:"body,omitempty"`
+	ResourceURL string `json:"resource_url,omitempty" bson:"resource_url,omitempty"`
+}
+
+// Module agrupa lecciones de un curso
+type Module struct {
+	ID      string   `json:"id" bson:"id"`
+	Title   string   `json:"title" bson:"title"`
+	Order   int      `json:"order" bson:"order"`
+	Lessons []Lesson `json:"lessons" bson:"lessons"`
+}
+
+// LessonCompletion registra una lección completada dentro de una inscripción
+type LessonCompletion struct {
+	LessonID    string    `json:"lesson_id" bson:"lesson_id"`
+	CompletedAt time.Time `json:"completed_at" bson:"completed_at"`
+}
+
+// validate verifica los campos que dependen del tipo de la lección; el resto se declara en las
+// etiquetas validate
+func (l Lesson) validate() error {
+	switch l.Type {
+	case LessonVideo:
+		if u, err := url.Parse(l.ResourceURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return apierror.Field("resource_url", apierror.FieldInvalid, "courses.lesson_video_url")
+		}
+	case LessonText:
+		if strings.TrimSpace(l.Body) == "" {
+			return apierror.Field("body", apierror.FieldRequired, "courses.lesson_text_body")
+		}
+	}
+	return nil
+}
+
+// moduleIndex devuelve la posición del módulo en el curso, o -1 si no existe
+func (c Course) moduleIndex(id string) int {
+	for i, module := range c.Modules {
+		if module.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// lessonIndex devuelve la posición de la lección en el módulo, o -1 si no existe
+func (m Module) lessonIndex(id string) int {
+	for i, lesson := range m.Lessons {
+		if lesson.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasLesson indica si la lección pertenece a algún módulo del curso
+func (c Course) HasLesson(id string) bool {
+	for _, module := range c.Modules {
+		if module.lessonIndex(id) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// pendingLessons devuelve los títulos de las lecciones del curso que la inscripción no completó
+func (e Enrollment) pendingLessons(course Course) []string {
+	var pending []string
+	for _, module := range course.Modules {
+		for _, lesson := range module.Lessons {
+			if !slices.ContainsFunc(e.CompletedLessons, func(c LessonCompletion) bool { return c.LessonID == lesson.ID }) {
+				pending = append(pending, lesson.Title)
+			}
+		}
+	}
+	return pending
+}
+
+// LessonCount devuelve la cantidad total de lecciones del curso
+func (c Course) LessonCount() int {
+	count := 0
+	for _, module := range c.Modules {
+		count += len(module.Lessons)
+	}
+	return count
+}
+
+// outline devuelve el curso sin el contenido de las lecciones, para quien no está inscrito
+func (c Course) outline() Course {
+	if len(c.Modules) == 0 {
+		return c
+	}
+	modules := make([]Module, len(c.Modules))
+	for i, module := range c.Modules {
+		lessons := make([]Lesson, len(module.Lessons))
+		for j, lesson := range module.Lessons {
+			lesson.Body = ""
+			lesson.ResourceURL = ""
+			lessons[j] = lesson
+		}
+		module.Lessons = lessons
+		modules[i] = module
+	}
+	c.Modules = modules
+	return c
+}
+
+// sortContent ordena los módulos y sus lecciones por Order
+func sortContent(modules []Module) {
+	sort.SliceStable(modules, func(i, j int) bool { return modules[i].Order < modules[j].Order })
+	for _, module := range modules {
+		sort.SliceStable(module.Lessons, func(i, j int) bool { return module.Lessons[i].Order < module.Lessons[j].Order })
+	}
+}
+
+// nextOrder devuelve el orden que sigue al mayor de la lista
+func nextOrder(orders ...int) int {
+	next := 1
+	for _, order := range orders {
+		next = max(next, order+1)
+	}
+	return next
+}
+
+// CompleteLesson registra la lección del curso como completada y recalcula el progreso;
+// devuelve false si ya estaba completada
+func (e *Enrollment) CompleteLesson(course Course, lessonID string, at time.Time) bool {
+	for _, completion := range e.CompletedLessons {
+		if completion.LessonID == lessonID {
+			return false
+		}
+	}
+	e.CompletedLessons = append(e.CompletedLessons, LessonCompletion{LessonID: lessonID, CompletedAt: at})
+	e.recalculateProgress(course)
+	e.UpdatedAt = at
+	return true
+}
+
+// recalculateProgress actualiza el progreso según las lecciones completadas que siguen en el curso
+func (e *Enrollment) recalculateProgress(course Course) {
+	total := course.LessonCount()
+	if total == 0 {
+		return
+	}
+	done := 0
+	for _, completion := range e.CompletedLessons {
+		if course.HasLesson(completion.LessonID) {
+			done++
+		}
+	}
+	e.Progress = done * 100 / total
+}
+
+// canViewContent indica si el usuario puede ver el contenido de las lecciones: quien edita el
+// curso o quien tiene una inscripción activa o completada
+func (h *Handler) canViewContent(r *http.Request, course Course) bool {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	if h.policy.AllowsOwned(claims, auth.PermCourseWrite, course.InstructorID) {
+		return true
+	}
+	enrollment, err := h.enrollments.Get(r.Context(), claims.UserID, course.ID.Hex())
+	return err == nil && (enrollment.Status == EnrollmentActive || enrollment.Status == EnrollmentCompleted)
+}
+
+// GetModules maneja GET /courses/{id}/modules; el contenido de las lecciones solo se incluye
+// para los inscritos y para quien puede editar el curso
+func (h *Handler) GetModules(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !primitive.IsValidObjectID(id) {
+		apierror.Write(w, r, apierror.ErrInvalidID)
+		return
+	}
+
+	course, err := h.courses.GetByID(r.Context(), id)
+	if errors.Is(err, ErrCourseNotFound) {
+		apierror.Write(w, r, errCourseNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.fetch_failed"))
+		return
+	}
+
+	if !h.canViewContent(r, course) {
+		course = course.outline()
+	}
+	modules := course.Modules
+	if modules == nil {
+		modules = []Module{}
+	}
+	json.NewEncoder(w).Encode(modules)
+}
+
+// editableContent obtiene el curso {id} si el usuario puede editarlo y no está archivado;
+// si no, responde el error y devuelve false
+func (h *Handler) editableContent(w http.ResponseWriter, r *http.Request) (Course, bool) {
+	id := r.PathValue("id")
+	if !primitive.IsValidObjectID(id) {
+		apierror.Write(w, r, apierror.ErrInvalidID)
+		return Course{}, false
+	}
+
+	course, ok := h.authorizeCourse(w, r, id, auth.PermCourseWrite)
+	if !ok {
+		return course, false
+	}
+	if course.ArchivedAt != nil {
+		apierror.Write(w, r, errCourseArchived)
+		return course, false
+	}
+	course.Modules = cloneModules(course.Modules)
+	return course, true
+}
+
+// cloneModules copia los módulos y sus lecciones para editarlos sin modificar el original
+func cloneModules(modules []Module) []Module {
+	cloned := make([]Module, len(modules))
+	for i, module := range modules {
+		module.Lessons = append([]Lesson{}, module.Lessons...)
+		cloned[i] = module
+	}
+	return cloned
+}
+
+// maxEditAttempts es la cantidad de veces que se intenta guardar un cambio cuando otra solicitud
+// modifica lo mismo entre la lectura y la escritura
+const maxEditAttempts = 3
+
+// editContent aplica edit sobre el curso {id} editable y guarda sus módulos ordenados; si otra
+// solicitud cambió el contenido mientras tanto vuelve a leer el curso y a aplicar edit. edit
+// devuelve el valor que se responde con status o el error que se responde.
+func (h *Handler) editContent(w http.ResponseWriter, r *http.Request, status int, edit func(*Course) (any, *apierror.Error)) {
+	for attempt := 1; ; attempt++ {
+		course, ok := h.editableContent(w, r)
+		if !ok {
+			return
+		}
+		value, apiErr := edit(&course)
+		if apiErr != nil {
+			apierror.Write(w, r, apiErr)
+			return
+		}
+
+		sortContent(course.Modules)
+		err := h.courses.SetModules(r.Context(), course.ID.Hex(), course.Modules, course.ContentVersion)
+		if errors.Is(err, ErrContentChanged) && attempt < maxEditAttempts {
+			continue
+		}
+		if errors.Is(err, ErrContentChanged) {
+			apierror.Write(w, r, errContentChanged)
+			return
+		} else if errors.Is(err, ErrCourseNotFound) {
+			apierror.Write(w, r, errCourseNotFound)
+			return
+		} else if err != nil {
+			log.Println("Error al guardar el contenido del curso", course.ID.Hex(), ":", err)
+			apierror.Write(w, r, apierror.Internal("courses.content_save_failed"))
+			return
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(value)
+		return
+	}
+}
+
+// moduleRequest es el cuerpo para crear o modificar un módulo; order 0 lo ubica al final
+// al crearlo y conserva su posición al modificarlo
+type moduleRequest struct {
+	Title string `json:"title" validate:"required,max=200"`
+	Order int    `json:"order" validate:"min=0"`
+}
+
+func decodeModule(w http.ResponseWriter, r *http.Request) (moduleRequest, bool) {
+	var req moduleRequest
+	return req, validate.DecodeJSON(w, r, &req)
+}
+
+// CreateModule maneja POST /courses/{id}/modules
+func (h *Handler) CreateModule(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeModule(w, r)
+	if !ok {
+		return
+	}
+
+	h.editContent(w, r, http.StatusCreated, func(course *Course) (any, *apierror.Error) {
+		module := Module{ID: primitive.NewObjectID().Hex(), Title: req.Title, Order: req.Order, Lessons: []Lesson{}}
+		if module.Order == 0 {
+			orders := make([]int, len(course.Modules))
+			for i, m := range course.Modules {
+				orders[i] = m.Order
+			}
+			module.Order = nextOrder(orders...)
+		}
+		course.Modules = append(course.Modules, module)
+		return module, nil
+	})
+}
+
+// UpdateModule maneja PUT /courses/{id}/modules/{module_id}; las lecciones no se modifican
+func (h *Handler) UpdateModule(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeModule(w, r)
+	if !ok {
+		return
+	}
+
+	h.editContent(w, r, http.StatusOK, func(course *Course) (any, *apierror.Error) {
+		module, apiErr := moduleFromRequest(r, course)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		module.Title = req.Title
+		if req.Order != 0 {
+			module.Order = req.Order
+		}
+		return *module, nil
+	})
+}
+
+// DeleteModule maneja DELETE /courses/{id}/modules/{module_id} junto con sus lecciones
+func (h *Handler) DeleteModule(w http.ResponseWriter, r *http.Request) {
+	h.editContent(w, r, http.StatusOK, func(course *Course) (any, *apierror.Error) {
+		i := course.moduleIndex(r.PathValue("module_id"))
+		if i < 0 {
+			return nil, errModuleNotFound
+		}
+		course.Modules = append(course.Modules[:i], course.Modules[i+1:]...)
+		return map[string]string{"message": i18n.Tr(r.Context(), "courses.module_deleted")}, nil
+	})
+}
+
+// lessonFromRequest decodifica y valida la lección del cuerpo
+func lessonFromRequest(w http.ResponseWriter, r *http.Request) (Lesson, bool) {
+	var lesson Lesson
+	if !validate.DecodeJSON(w, r, &lesson) {
+		return lesson, false
+	}
+	if err := lesson.validate(); err != nil {
+		apierror.Write(w, r, apierror.Validation(err))
+		return lesson, false
+	}
+	return lesson, true
+}
+
+// moduleFromRequest devuelve el módulo {module_id} del curso
+func moduleFromRequest(r *http.Request, course *Course) (*Module, *apierror.Error) {
+	i := course.moduleIndex(r.PathValue("module_id"))
+	if i < 0 {
+		return nil, errModuleNotFound
+	}
+	return &course.Modules[i], nil
+}
+
+// CreateLesson maneja POST /courses/{id}/modules/{module_id}/lessons
+func (h *Handler) CreateLesson(w http.ResponseWriter, r *http.Request) {
+	req, ok := lessonFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	h.editContent(w, r, http.StatusCreated, func(course *Course) (any, *apierror.Error) {
+		module, apiErr := moduleFromRequest(r, course)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		lesson := req
+		lesson.ID = primitive.NewObjectID().Hex()
+		if lesson.Order == 0 {
+			orders := make([]int, len(module.Lessons))
+			for j, l := range module.Lessons {
+				orders[j] = l.Order
+			}
+			lesson.Order = nextOrder(orders...)
+		}
+		module.Lessons = append(module.Lessons, lesson)
+		return lesson, nil
+	})
+}
+
+// UpdateLesson maneja PUT /courses/{id}/modules/{module_id}/lessons/{lesson_id}; reemplaza la
+// lección conservando su ID, y order 0 conserva su posición
+func (h *Handler) UpdateLesson(w http.ResponseWriter, r *http.Request) {
+	req, ok := lessonFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	h.editContent(w, r, http.StatusOK, func(course *Course) (any, *apierror.Error) {
+		module, apiErr := moduleFromRequest(r, course)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		j := module.lessonIndex(r.PathValue("lesson_id"))
+		if j < 0 {
+			return nil, errLessonNotFound
+		}
+		lesson := req
+		lesson.ID = module.Lessons[j].ID
+		if lesson.Order == 0 {
+			lesson.Order = module.Lessons[j].Order
+		}
+		module.Lessons[j] = lesson
+		return lesson, nil
+	})
+}
+
+// DeleteLesson maneja DELETE /courses/{id}/modules/{module_id}/lessons/{lesson_id}
+func (h *Handler) DeleteLesson(w http.ResponseWriter, r *http.Request) {
+	h.editContent(w, r, http.StatusOK, func(course *Course) (any, *apierror.Error) {
+		module, apiErr := moduleFromRequest(r, course)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		j := module.lessonIndex(r.PathValue("lesson_id"))
+		if j < 0 {
+			return nil, errLessonNotFound
+		}
+		module.Lessons = append(module.Lessons[:j], module.Lessons[j+1:]...)
+		return map[string]string{"message": i18n.Tr(r.Context(), "courses.lesson_deleted")}, nil
+	})
+}
+
+// CompleteLesson maneja POST /enrollments/{course_id}/lessons/{lesson_id}/complete: registra la
+// lección como completada y recalcula el progreso de la inscripción activa. Si la inscripción
+// cambió mientras tanto, por ejemplo al completar otra lección a la vez, se vuelve a leer.
+func (h *Handler) CompleteLesson(w http.ResponseWriter, r *http.Request) {
+	for attempt := 1; ; attempt++ {
+		enrollment, ok := h.enrollmentFromRequest(w, r)
+		if !ok {
+			return
+		}
+		if enrollment.Status != EnrollmentActive {
+			apierror.Write(w, r, errEnrollmentNotActive.WithMessage("enrollments.lessons_require_active"))
+			return
+		}
+
+		course, err := h.courses.GetByID(r.Context(), enrollment.CourseID)
+		if err != nil {
+			apierror.Write(w, r, apierror.Internal("courses.fetch_failed"))
+			return
+		}
+		lessonID := r.PathValue("lesson_id")
+		if !course.HasLesson(lessonID) {
+			apierror.Write(w, r, errLessonNotFound)
+			return
+		}
+
+		if !enrollment.CompleteLesson(course, lessonID, time.Now().UTC()) {
+			// Completar dos veces la misma lección no cambia nada
+			json.NewEncoder(w).Encode(enrollment)
+			return
+		}
+		err = h.enrollments.Save(r.Context(), enrollment, EnrollmentActive)
+		if errors.Is(err, ErrEnrollmentChanged) && attempt < maxEditAttempts {
+			continue
+		}
+		writeSavedEnrollment(w, r, enrollment, err)
+		return
+	}
+}
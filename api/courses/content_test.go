@@ -0,0 +1,145 @@
+package courses
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/auth"
+)
+
+// racingCourses ejecuta race antes del primer SetModules, como otra solicitud que guarda el
+// contenido entre la lectura y la escritura
+type racingCourses struct {
+	*MemoryCourseRepository
+	race func()
+}
+
+func (r *racingCourses) SetModules(ctx context.Context, id string, modules []Module, version int) error {
+	if race := r.race; race != nil {
+		r.race = nil
+		race()
+	}
+	return r.MemoryCourseRepository.SetModules(ctx, id, modules, version)
+}
+
+// racingEnrollments ejecuta race antes del primer Save
+type racingEnrollments struct {
+	*MemoryEnrollmentRepository
+	race func()
+}
+
+func (r *racingEnrollments) Save(ctx context.Context, enrollment Enrollment, expected string) error {
+	if race := r.race; race != nil {
+		r.race = nil
+		race()
+	}
+	return r.MemoryEnrollmentRepository.Save(ctx, enrollment, expected)
+}
+
+// newContentCourse crea un curso del instructor con un módulo que contiene las lecciones dadas
+func newContentCourse(t *testing.T, repo CourseRepository, instructorID int, lessons ...Lesson) Course {
+	t.Helper()
+	ctx := context.Background()
+	course := Course{Title: "Go", Level: "beginner", InstructorID: instructorID}
+	if err := repo.Create(ctx, &course); err != nil {
+		t.Fatal(err)
+	}
+	module := Module{ID: "m1", Title: "Introducción", Order: 1, Lessons: lessons}
+	if err := repo.SetModules(ctx, course.ID.Hex(), []Module{module}, 0); err != nil {
+		t.Fatal(err)
+	}
+	course, err := repo.GetByID(ctx, course.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return course
+}
+
+func TestSetModulesRejectsStaleVersion(t *testing.T) {
+	repo := NewMemoryCourseRepository()
+	course := newContentCourse(t, repo, 1)
+	if course.ContentVersion != 1 {
+		t.Fatalf("versión %d, se esperaba 1", course.ContentVersion)
+	}
+	if err := repo.SetModules(context.Background(), course.ID.Hex(), nil, 0); err != ErrContentChanged {
+		t.Fatalf("se obtuvo %v, se esperaba ErrContentChanged", err)
+	}
+}
+
+func TestCreateLessonRetriesConcurrentEdits(t *testing.T) {
+	repo := &racingCourses{MemoryCourseRepository: NewMemoryCourseRepository()}
+	api := newTestAPI(t)
+	instructor := api.addUser(t, "Ada", auth.RoleInstructor)
+	course := newContentCourse(t, repo, instructor.UserID)
+	id := course.ID.Hex()
+
+	// Otra solicitud agrega una lección después de que el handler leyó el curso
+	repo.race = func() {
+		modules := cloneModules(course.Modules)
+		modules[0].Lessons = append(modules[0].Lessons, Lesson{ID: "l1", Title: "Competidora", Order: 1, Type: LessonText})
+		if err := repo.MemoryCourseRepository.SetModules(context.Background(), id, modules, course.ContentVersion); err != nil {
+			t.Error(err)
+		}
+	}
+	h := NewHandler(repo, NewMemoryEnrollmentRepository(), api.users, api.broker, auth.DefaultPolicy, nil, nil)
+	api.mux.HandleFunc("POST /courses/{id}/modules/{module_id}/lessons", h.CreateLesson)
+
+	body := `{"title": "Variables", "type": "text", "body": "Hola"}`
+	if w := api.do(http.MethodPost, "/courses/"+id+"/modules/m1/lessons", body, instructor); w.Code != http.StatusCreated {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+
+	stored, err := repo.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lessons := stored.Modules[0].Lessons
+	if len(lessons) != 2 || lessons[0].Title != "Competidora" || lessons[1].Title != "Variables" || lessons[1].Order != 2 {
+		t.Fatalf("lecciones guardadas: %+v", lessons)
+	}
+}
+
+func TestCompleteLessonRetriesConcurrentCompletions(t *testing.T) {
+	courseRepo := NewMemoryCourseRepository()
+	enrollments := &racingEnrollments{MemoryEnrollmentRepository: NewMemoryEnrollmentRepository()}
+	api := newTestAPI(t)
+	student := api.addUser(t, "Grace", auth.RoleUser)
+	course := newContentCourse(t, courseRepo, 1,
+		Lesson{ID: "l1", Title: "Uno", Order: 1, Type: LessonText},
+		Lesson{ID: "l2", Title: "Dos", Order: 2, Type: LessonText},
+	)
+	id := course.ID.Hex()
+
+	ctx := context.Background()
+	if err := enrollments.Create(ctx, newEnrollment(student.UserID, id, EnrollmentActive, time.Now().UTC())); err != nil {
+		t.Fatal(err)
+	}
+	// Otra solicitud completa la lección l2 después de que el handler leyó la inscripción
+	enrollments.race = func() {
+		enrollment, err := enrollments.Get(ctx, student.UserID, id)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		enrollment.CompleteLesson(course, "l2", time.Now().UTC())
+		if err := enrollments.MemoryEnrollmentRepository.Save(ctx, enrollment, EnrollmentActive); err != nil {
+			t.Error(err)
+		}
+	}
+	h := NewHandler(courseRepo, enrollments, api.users, api.broker, auth.DefaultPolicy, nil, nil)
+	api.mux.HandleFunc("POST /enrollments/{course_id}/lessons/{lesson_id}/complete", h.CompleteLesson)
+
+	if w := api.do(http.MethodPost, "/enrollments/"+id+"/lessons/l1/complete", "", student); w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+
+	enrollment, err := enrollments.Get(ctx, student.UserID, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enrollment.CompletedLessons) != 2 || enrollment.Progress != 100 {
+		t.Fatalf("lecciones completadas %+v, progreso %d", enrollment.CompletedLessons, enrollment.Progress)
+	}
+}
@@ -0,0 +1,103 @@
+package courses
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeleteMode indica cómo se dio de baja un curso
+type DeleteMode string
+
+const (
+	// DeleteSoft archiva el curso y vence sus inscripciones conservando el historial
+	DeleteSoft DeleteMode = "soft"
+	// DeleteHard borra el curso y sus inscripciones
+	DeleteHard DeleteMode = "hard"
+)
+
+// CourseDeletion es el registro de la baja de un curso
+type CourseDeletion struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CourseID  string             `json:"course_id" bson:"course_id"`
+	Mode      DeleteMode         `json:"mode" bson:"mode"`
+	DeletedBy int                `json:"deleted_by" bson:"deleted_by"`
+	DeletedAt time.Time          `json:"deleted_at" bson:"deleted_at"`
+	// Enrollments es la cantidad de inscripciones vencidas o borradas en cascada
+	Enrollments int64 `json:"enrollments" bson:"enrollments"`
+	// Course es una copia del curso al momento de la baja
+	Course Course `json:"course" bson:"course"`
+}
+
+// DeleteCourse maneja DELETE /courses/{id}. Por defecto archiva el curso (mode=soft);
+// con mode=hard lo borra junto con sus inscripciones, lo que exige el permiso course:delete.
+func (h *Handler) DeleteCourse(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !primitive.IsValidObjectID(id) {
+		apierror.Write(w, r, apierror.ErrInvalidID)
+		return
+	}
+
+	mode := DeleteMode(r.URL.Query().Get("mode"))
+	switch mode {
+	case "":
+		mode = DeleteSoft
+	case DeleteSoft, DeleteHard:
+	default:
+		apierror.Write(w, r, apierror.Validation(apierror.Field("mode", apierror.FieldInvalid, "courses.delete_mode")))
+		return
+	}
+
+	course, ok := h.authorizeCourse(w, r, id, auth.PermCourseDelete)
+	if !ok {
+		return
+	}
+
+	claims, _ := auth.UserFromContext(r.Context())
+	deletion := CourseDeletion{
+		CourseID:  id,
+		Mode:      mode,
+		DeletedBy: claims.UserID,
+		DeletedAt: time.Now().UTC(),
+		Course:    course,
+	}
+
+	var err error
+	switch mode {
+	case DeleteHard:
+		if !h.policy.Allows(claims.Role, auth.PermCourseDelete) {
+			apierror.Write(w, r, apierror.ErrForbidden.WithMessage("courses.forbidden_hard_delete"))
+			return
+		}
+		if deletion.Enrollments, err = h.enrollments.DeleteByCourse(r.Context(), id); err == nil {
+			err = h.courses.Delete(r.Context(), id)
+		}
+	case DeleteSoft:
+		if course.ArchivedAt != nil {
+			apierror.Write(w, r, errCourseArchived.WithMessage("courses.already_archived"))
+			return
+		}
+		if err = h.courses.Archive(r.Context(), id, deletion.DeletedAt); err == nil {
+			deletion.Enrollments, err = h.enrollments.ExpireByCourse(r.Context(), id, deletion.DeletedAt)
+		}
+	}
+	if err != nil {
+		log.Println("Error al dar de baja el curso:", err)
+		apierror.Write(w, r, apierror.Internal("courses.delete_failed"))
+		return
+	}
+
+	if err := h.courses.RecordDeletion(r.Context(), &deletion); err != nil {
+		log.Println("Error al registrar la baja del curso", id, ":", err)
+	}
+
+	// Publicar el evento para que el indexador lo quite del motor de búsqueda
+	h.publishCourseEvent(r.Context(), CourseDeleted, id, nil)
+
+	json.NewEncoder(w).Encode(deletion)
+}
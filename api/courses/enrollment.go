@@ -0,0 +1,559 @@
+package courses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/validate"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/i18n"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Estados de una inscripción
+const (
+	// EnrollmentPending espera un lugar en un curso lleno
+	EnrollmentPending   = "pending"
+	EnrollmentActive    = "active"
+	EnrollmentCompleted = "completed"
+	// EnrollmentDropped es una inscripción dada de baja por el usuario; se conserva como historial
+	EnrollmentDropped = "dropped"
+	// EnrollmentExpired es una inscripción que perdió el acceso, por ejemplo al archivarse el curso
+	EnrollmentExpired = "expired"
+)
+
+// enrollmentTransitions indica a qué estados se puede pasar desde cada estado
+var enrollmentTransitions = map[string][]string{
+	EnrollmentPending:   {EnrollmentActive, EnrollmentDropped, EnrollmentExpired},
+	EnrollmentActive:    {EnrollmentCompleted, EnrollmentDropped, EnrollmentExpired},
+	EnrollmentCompleted: {},
+	// Volver a inscribirse reactiva la misma inscripción
+	EnrollmentDropped: {EnrollmentPending, EnrollmentActive},
+	EnrollmentExpired: {EnrollmentPending, EnrollmentActive},
+}
+
+// RequirementsError indica qué requisitos le faltan a la inscripción para completarse;
+// envuelve ErrCompletionRequirements
+type RequirementsError struct {
+	Pending []string
+}
+
+func (e RequirementsError) Error() string {
+	return fmt.Sprintf("%s: falta aprobar %s", ErrCompletionRequirements, strings.Join(e.Pending, ", "))
+}
+
+func (e RequirementsError) Unwrap() error {
+	return ErrCompletionRequirements
+}
+
+var (
+	// ErrInvalidTransition se devuelve cuando el cambio de estado no está permitido
+	ErrInvalidTransition = errors.New("cambio de estado de inscripción no permitido")
+	// ErrCompletionRequirements se devuelve cuando la inscripción no cumple los requisitos para completarse
+	ErrCompletionRequirements = errors.New("la inscripción no cumple los requisitos para completar el curso")
+)
+
+// CompletionGate decide si una inscripción puede completarse, por ejemplo exigiendo evaluaciones
+// aprobadas; devuelve un RequirementsError u otro error que envuelva ErrCompletionRequirements si no cumple
+type CompletionGate interface {
+	CheckCompletion(ctx context.Context, enrollment Enrollment) error
+}
+
+// CompletionHook se ejecuta después de guardar una inscripción completada, por ejemplo para emitir
+// el certificado
+type CompletionHook interface {
+	EnrollmentCompleted(ctx context.Context, enrollment Enrollment) error
+}
+
+// StatusChange registra un cambio de estado de una inscripción
+type StatusChange struct {
+	From string    `json:"from,omitempty" bson:"from,omitempty"`
+	To   string    `json:"to" bson:"to"`
+	At   time.Time `json:"at" bson:"at"`
+}
+
+// Enrollment representa la inscripción de un usuario en un curso
+type Enrollment struct {
+	UserID   int    `json:"user_id" bson:"user_id"`
+	CourseID string `json:"course_id" bson:"course_id" validate:"required,objectid"`
+	Status   string `json:"status" bson:"status" validate:"omitempty,oneof=pending active completed dropped expired"`
+	// Progress es el porcentaje completado del curso, de 0 a 100
+	Progress  int       `json:"progress" bson:"progress" validate:"min=0,max=100"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+	// History guarda cada cambio de estado con su fecha, empezando por el alta
+	History []StatusChange `json:"history" bson:"history"`
+	// CompletedLessons son las lecciones del curso que el usuario ya completó
+	CompletedLessons []LessonCompletion `json:"completed_lessons,omitempty" bson:"completed_lessons,omitempty"`
+	// Quizzes guarda el mejor resultado de cada evaluación del curso
+	Quizzes []QuizResult `json:"quizzes,omitempty" bson:"quizzes,omitempty"`
+	// Version cuenta los cambios guardados con Save para detectar modificaciones simultáneas
+	Version int `json:"-" bson:"version"`
+}
+
+// QuizResult resume los intentos de una evaluación dentro de una inscripción
+type QuizResult struct {
+	QuizID   string `json:"quiz_id" bson:"quiz_id"`
+	Attempts int    `json:"attempts" bson:"attempts"`
+	// BestScore es el mejor porcentaje obtenido y Passed indica si alguno alcanzó el mínimo
+	BestScore     int       `json:"best_score" bson:"best_score"`
+	Passed        bool      `json:"passed" bson:"passed"`
+	LastAttemptAt time.Time `json:"last_attempt_at" bson:"last_attempt_at"`
+}
+
+// RecordQuizResult suma un intento calificado de la evaluación y conserva el mejor resultado
+func (e *Enrollment) RecordQuizResult(quizID string, score int, passed bool, at time.Time) {
+	i := 0
+	for i < len(e.Quizzes) && e.Quizzes[i].QuizID != quizID {
+		i++
+	}
+	if i == len(e.Quizzes) {
+		e.Quizzes = append(e.Quizzes, QuizResult{QuizID: quizID})
+	}
+	result := &e.Quizzes[i]
+	result.Attempts++
+	result.BestScore = max(result.BestScore, score)
+	result.Passed = result.Passed || passed
+	result.LastAttemptAt = at
+	e.UpdatedAt = at
+}
+
+// QuizPassed indica si la inscripción aprobó la evaluación
+func (e Enrollment) QuizPassed(quizID string) bool {
+	for _, result := range e.Quizzes {
+		if result.QuizID == quizID {
+			return result.Passed
+		}
+	}
+	return false
+}
+
+// newEnrollment crea una inscripción en el estado inicial dado
+func newEnrollment(userID int, courseID, status string, at time.Time) Enrollment {
+	return Enrollment{
+		UserID:    userID,
+		CourseID:  courseID,
+		Status:    status,
+		CreatedAt: at,
+		UpdatedAt: at,
+		History:   []StatusChange{{To: status, At: at}},
+	}
+}
+
+// transition cambia el estado si la máquina de estados lo permite y lo registra en el historial
+func (e *Enrollment) transition(to string, at time.Time) error {
+	allowed := false
+	for _, next := range enrollmentTransitions[e.Status] {
+		allowed = allowed || next == to
+	}
+	if !allowed {
+		return fmt.Errorf("%w: de %s a %s", ErrInvalidTransition, e.Status, to)
+	}
+
+	e.History = append(e.History, StatusChange{From: e.Status, To: to, At: at})
+	e.Status = to
+	e.UpdatedAt = at
+	if to == EnrollmentCompleted {
+		e.Progress = 100
+	}
+	return nil
+}
+
+// EnrollUser maneja la inscripción de un usuario en un curso disponible. Si el curso no tiene
+// cupo la inscripción queda pendiente hasta que se libere un lugar.
+func (h *Handler) EnrollUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.Write(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+
+	userID, err := currentUserID(r)
+	if err != nil {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+
+	// Del cuerpo solo se usa course_id; el estado y las fechas los asigna el servidor
+	var req Enrollment
+	if !validate.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	course, err := h.courses.GetByID(r.Context(), req.CourseID)
+	if errors.Is(err, ErrCourseNotFound) || (err == nil && course.ArchivedAt != nil) {
+		apierror.Write(w, r, errCourseNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.fetch_failed"))
+		return
+	}
+	if !course.Availability {
+		apierror.Write(w, r, errCourseUnavailable)
+		return
+	}
+
+	// Una inscripción dada de baja o vencida se reactiva; cualquier otra impide inscribirse de nuevo
+	existing, err := h.enrollments.Get(r.Context(), userID, req.CourseID)
+	rejoin := err == nil
+	if rejoin && existing.Status != EnrollmentDropped && existing.Status != EnrollmentExpired {
+		apierror.Write(w, r, errEnrollmentExists)
+		return
+	} else if err != nil && !errors.Is(err, ErrEnrollmentNotFound) {
+		apierror.Write(w, r, apierror.Internal("enrollments.enroll_failed"))
+		return
+	}
+
+	reserved, err := h.courses.ReserveSeat(r.Context(), req.CourseID)
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("enrollments.enroll_failed"))
+		return
+	}
+	status := EnrollmentActive
+	if !reserved {
+		status = EnrollmentPending
+	}
+
+	now := time.Now().UTC()
+	enrollment := newEnrollment(userID, req.CourseID, status, now)
+	if rejoin {
+		enrollment = existing
+		previous := enrollment.Status
+		if err = enrollment.transition(status, now); err == nil {
+			err = h.enrollments.Save(r.Context(), enrollment, previous)
+		}
+	} else {
+		err = h.enrollments.Create(r.Context(), enrollment)
+	}
+	if err != nil && reserved {
+		h.releaseSeat(r.Context(), req.CourseID)
+	}
+	if errors.Is(err, ErrEnrollmentExists) || errors.Is(err, ErrEnrollmentChanged) {
+		apierror.Write(w, r, errEnrollmentExists)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("enrollments.enroll_failed"))
+		return
+	}
+
+	message := i18n.Tr(r.Context(), "enrollments.enrolled")
+	if !reserved {
+		message = i18n.Tr(r.Context(), "enrollments.waitlisted")
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"message": message, "status": enrollment.Status})
+}
+
+// releaseSeat libera un lugar del curso; los errores solo se registran
+func (h *Handler) releaseSeat(ctx context.Context, courseID string) {
+	if err := h.courses.ReleaseSeat(ctx, courseID); err != nil {
+		log.Println("Error al liberar lugar del curso", courseID, ":", err)
+	}
+}
+
+// promotePending le pasa el lugar liberado a la inscripción pendiente más antigua,
+// o lo libera si no hay nadie esperando
+func (h *Handler) promotePending(ctx context.Context, courseID string) {
+	for {
+		next, err := h.enrollments.NextPending(ctx, courseID)
+		if errors.Is(err, ErrEnrollmentNotFound) {
+			h.releaseSeat(ctx, courseID)
+			return
+		} else if err != nil {
+			log.Println("Error al buscar la lista de espera del curso", courseID, ":", err)
+			h.releaseSeat(ctx, courseID)
+			return
+		}
+
+		if err := next.transition(EnrollmentActive, time.Now().UTC()); err != nil {
+			log.Println("Error al promover la inscripción pendiente:", err)
+			h.releaseSeat(ctx, courseID)
+			return
+		}
+		// Si otra solicitud ya cambió la inscripción se intenta con la siguiente
+		err = h.enrollments.Save(ctx, next, EnrollmentPending)
+		if errors.Is(err, ErrEnrollmentChanged) {
+			continue
+		} else if err != nil {
+			log.Println("Error al promover la inscripción pendiente:", err)
+			h.releaseSeat(ctx, courseID)
+			return
+		}
+		log.Printf("Usuario %d promovido de la lista de espera del curso %s", next.UserID, courseID)
+		return
+	}
+}
+
+// EnrolledCourse es un curso junto con la inscripción del usuario
+type EnrolledCourse struct {
+	Course
+	Enrollment Enrollment `json:"enrollment"`
+}
+
+// GetEnrollments obtiene los cursos en los que está inscrito el usuario. Por defecto omite las
+// inscripciones dadas de baja o vencidas; ?status=<estado> filtra por estado y ?status=all las incluye.
+func (h *Handler) GetEnrollments(w http.ResponseWriter, r *http.Request) {
+	userID, err := currentUserID(r)
+	if err != nil {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if _, known := enrollmentTransitions[status]; status != "" && status != "all" && !known {
+		apierror.Write(w, r, apierror.Validation(apierror.Field("status", apierror.FieldInvalid, "validation.invalid", "status")))
+		return
+	}
+
+	// Buscar las inscripciones del usuario en la base de datos
+	enrollments, err := h.enrollments.ListByUser(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("enrollments.list_failed"))
+		return
+	}
+
+	var enrolledCourses []EnrolledCourse
+	for _, enrollment := range enrollments {
+		switch status {
+		case "":
+			if enrollment.Status == EnrollmentDropped || enrollment.Status == EnrollmentExpired {
+				continue
+			}
+		case "all":
+		default:
+			if enrollment.Status != status {
+				continue
+			}
+		}
+
+		// Buscar los detalles del curso inscrito
+		course, err := h.courses.GetByID(r.Context(), enrollment.CourseID)
+		if err != nil {
+			continue
+		}
+		enrolledCourses = append(enrolledCourses, EnrolledCourse{Course: course, Enrollment: enrollment})
+	}
+
+	// Verificar si no se encontraron cursos inscritos
+	if len(enrolledCourses) == 0 {
+		json.NewEncoder(w).Encode(map[string]string{"message": i18n.Tr(r.Context(), "enrollments.none")})
+		return
+	}
+
+	json.NewEncoder(w).Encode(enrolledCourses)
+}
+
+// UnenrollUser maneja la desinscripción de un usuario de un curso; la inscripción queda dada de baja
+func (h *Handler) UnenrollUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		apierror.Write(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+
+	// Extraer el ID del usuario desde el token
+	userID, err := currentUserID(r)
+	if err != nil {
+		log.Println("Error al obtener el ID del usuario:", err)
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+
+	// Obtener el `course_id` de los parámetros de la URL
+	courseID := r.URL.Query().Get("course_id")
+	if courseID == "" {
+		apierror.Write(w, r, apierror.Validation(apierror.Field("course_id", apierror.FieldRequired, "enrollments.course_id_missing")))
+		return
+	}
+
+	// Validar que `course_id` sea un ObjectID
+	if !primitive.IsValidObjectID(courseID) {
+		apierror.Write(w, r, errInvalidCourseID)
+		return
+	}
+
+	enrollment, err := h.enrollments.Get(r.Context(), userID, courseID)
+	if errors.Is(err, ErrEnrollmentNotFound) {
+		apierror.Write(w, r, errEnrollmentNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("enrollments.unenroll_failed"))
+		return
+	}
+
+	previous := enrollment.Status
+	if err := enrollment.transition(EnrollmentDropped, time.Now().UTC()); err != nil {
+		apierror.Write(w, r, errEnrollmentNotActive)
+		return
+	}
+	err = h.enrollments.Save(r.Context(), enrollment, previous)
+	if errors.Is(err, ErrEnrollmentChanged) {
+		apierror.Write(w, r, errEnrollmentChanged)
+		return
+	} else if err != nil {
+		log.Println("Error al desinscribirse:", err)
+		apierror.Write(w, r, apierror.Internal("enrollments.unenroll_failed"))
+		return
+	}
+
+	// El lugar que ocupaba pasa al primero de la lista de espera
+	if previous == EnrollmentActive {
+		h.promotePending(r.Context(), courseID)
+	}
+
+	log.Println("Desinscripción exitosa para userID:", userID, "y courseID:", courseID)
+	json.NewEncoder(w).Encode(map[string]string{"message": i18n.Tr(r.Context(), "enrollments.unenrolled")})
+}
+
+// enrollmentFromRequest obtiene la inscripción del curso {course_id} del usuario autenticado, o la
+// del usuario ?user_id= si quien llama tiene enrollment:manage; si falla responde el error
+func (h *Handler) enrollmentFromRequest(w http.ResponseWriter, r *http.Request) (Enrollment, bool) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return Enrollment{}, false
+	}
+
+	courseID := r.PathValue("course_id")
+	if !primitive.IsValidObjectID(courseID) {
+		apierror.Write(w, r, errInvalidCourseID)
+		return Enrollment{}, false
+	}
+
+	userID := claims.UserID
+	if v := r.URL.Query().Get("user_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			apierror.Write(w, r, apierror.Validation(apierror.Field("user_id", apierror.FieldInvalid, "validation.invalid", "user_id")))
+			return Enrollment{}, false
+		}
+		if id != claims.UserID && !h.policy.Allows(claims.Role, auth.PermEnrollmentManage) {
+			apierror.Write(w, r, apierror.ErrForbidden.WithMessage("enrollments.forbidden_other"))
+			return Enrollment{}, false
+		}
+		userID = id
+	}
+
+	enrollment, err := h.enrollments.Get(r.Context(), userID, courseID)
+	if errors.Is(err, ErrEnrollmentNotFound) {
+		apierror.Write(w, r, errEnrollmentNotFound)
+		return enrollment, false
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("enrollments.fetch_failed"))
+		return enrollment, false
+	}
+	return enrollment, true
+}
+
+// saveEnrollment guarda la inscripción modificada y la devuelve en la respuesta; si falla
+// responde el error y devuelve false
+func (h *Handler) saveEnrollment(w http.ResponseWriter, r *http.Request, enrollment Enrollment, previous string) bool {
+	return writeSavedEnrollment(w, r, enrollment, h.enrollments.Save(r.Context(), enrollment, previous))
+}
+
+// writeSavedEnrollment responde la inscripción si err es nil, o el error que devolvió Save
+func writeSavedEnrollment(w http.ResponseWriter, r *http.Request, enrollment Enrollment, err error) bool {
+	if errors.Is(err, ErrEnrollmentChanged) {
+		apierror.Write(w, r, errEnrollmentChanged)
+		return false
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("enrollments.save_failed"))
+		return false
+	}
+	json.NewEncoder(w).Encode(enrollment)
+	return true
+}
+
+// UpdateProgress maneja PUT /enrollments/{course_id}/progress con {"progress": 0-100}. Es una
+// corrección manual para quien tiene enrollment:manage; el progreso de los estudiantes se calcula
+// con CompleteLesson.
+func (h *Handler) UpdateProgress(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Progress *int `json:"progress" validate:"required,min=0,max=100"`
+	}
+	if !validate.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	enrollment, ok := h.enrollmentFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if enrollment.Status != EnrollmentActive {
+		apierror.Write(w, r, errEnrollmentNotActive.WithMessage("enrollments.progress_requires_active"))
+		return
+	}
+
+	enrollment.Progress = *req.Progress
+	enrollment.UpdatedAt = time.Now().UTC()
+	h.saveEnrollment(w, r, enrollment, EnrollmentActive)
+}
+
+// CompleteEnrollment maneja POST /enrollments/{course_id}/complete si se completaron todas las
+// lecciones y se cumplen los demás requisitos del curso. Quien tiene enrollment:manage puede
+// completarla sin verificarlos. El lugar que ocupaba la inscripción pasa a la lista de espera.
+func (h *Handler) CompleteEnrollment(w http.ResponseWriter, r *http.Request) {
+	enrollment, ok := h.enrollmentFromRequest(w, r)
+	if !ok {
+		return
+	}
+	claims, _ := auth.UserFromContext(r.Context())
+	if enrollment.Status == EnrollmentActive && !h.policy.Allows(claims.Role, auth.PermEnrollmentManage) &&
+		!h.checkRequirements(w, r, enrollment) {
+		return
+	}
+
+	previous := enrollment.Status
+	if err := enrollment.transition(EnrollmentCompleted, time.Now().UTC()); err != nil {
+		apierror.Write(w, r, errInvalidTransition.WithMessage("enrollments.invalid_transition", previous, EnrollmentCompleted))
+		return
+	}
+	if h.saveEnrollment(w, r, enrollment, previous) {
+		h.promotePending(r.Context(), enrollment.CourseID)
+		if h.onComplete != nil {
+			if err := h.onComplete.EnrollmentCompleted(r.Context(), enrollment); err != nil {
+				log.Println("Error al procesar la inscripción completada del curso", enrollment.CourseID, ":", err)
+			}
+		}
+	}
+}
+
+// checkRequirements verifica que la inscripción completó todas las lecciones del curso y cumple
+// los requisitos de la compuerta; si no, responde el error y devuelve false
+func (h *Handler) checkRequirements(w http.ResponseWriter, r *http.Request, enrollment Enrollment) bool {
+	course, err := h.courses.GetByID(r.Context(), enrollment.CourseID)
+	if errors.Is(err, ErrCourseNotFound) {
+		apierror.Write(w, r, errCourseNotFound)
+		return false
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.fetch_failed"))
+		return false
+	}
+	if pending := enrollment.pendingLessons(course); len(pending) > 0 {
+		apierror.Write(w, r, errCompletionRequirements.WithMessage("enrollments.lessons_pending", strings.Join(pending, ", ")))
+		return false
+	}
+
+	if h.gate != nil {
+		err := h.gate.CheckCompletion(r.Context(), enrollment)
+		var requirements RequirementsError
+		if errors.As(err, &requirements) {
+			apierror.Write(w, r, errCompletionRequirements.WithMessage("enrollments.completion_pending", strings.Join(requirements.Pending, ", ")))
+			return false
+		} else if errors.Is(err, ErrCompletionRequirements) {
+			apierror.Write(w, r, errCompletionRequirements)
+			return false
+		} else if err != nil {
+			log.Println("Error al verificar los requisitos del curso", enrollment.CourseID, ":", err)
+			apierror.Write(w, r, apierror.Internal("enrollments.complete_failed"))
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package courses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/queue"
+)
+
+const (
+	// CourseEventsQueue es la cola donde se publican los cambios de cursos
+	CourseEventsQueue = "course-events"
+	// CourseEventsDeadLetterQueue recibe los eventos que no se pudieron indexar
+	CourseEventsDeadLetterQueue = "course-events.dead-letter"
+)
+
+// CourseEventType identifica el tipo de cambio realizado sobre un curso
+type CourseEventType string
+
+const (
+	CourseCreated CourseEventType = "course.created"
+	CourseUpdated CourseEventType = "course.updated"
+	CourseDeleted CourseEventType = "course.deleted"
+)
+
+// CourseEvent representa un cambio de un curso que debe reflejarse en Solr
+type CourseEvent struct {
+	Type       CourseEventType `json:"type"`
+	CourseID   string          `json:"course_id"`
+	Course     *Course         `json:"course,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	// Error se completa cuando el evento termina en la cola de mensajes muertos
+	Error string `json:"error,omitempty"`
+}
+
+// PublishCourseEvent serializa el evento y lo envía a la cola de eventos de cursos
+func PublishCourseEvent(ctx context.Context, b queue.Broker, event CourseEvent) error {
+	if b == nil {
+		return errors.New("broker de eventos no configurado")
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.Publish(ctx, CourseEventsQueue, body)
+}
+
+// publishCourseEvent publica un evento usando el broker configurado; los errores solo se registran
+// porque el cambio en MongoDB ya fue confirmado
+func (h *Handler) publishCourseEvent(ctx context.Context, eventType CourseEventType, id string, course *Course) {
+	event := CourseEvent{
+		Type:       eventType,
+		CourseID:   id,
+		Course:     course,
+		OccurredAt: time.Now().UTC(),
+	}
+	if err := PublishCourseEvent(ctx, h.events, event); err != nil {
+		log.Println("Error al publicar evento de curso:", event.Type, id, err)
+	}
+}
+
+// PublishStoredCourse vuelve a leer el curso id y lo publica como actualizado, para que el índice
+// refleje lo guardado y no lo que envió el cliente. Los cursos archivados ya no están en el índice
+// y no se publican. Los errores solo se registran porque el cambio ya fue confirmado.
+func PublishStoredCourse(ctx context.Context, repo CourseRepository, b queue.Broker, id string) {
+	course, err := repo.GetByID(ctx, id)
+	if err != nil {
+		log.Println("Error al obtener el curso para reindexarlo:", id, err)
+		return
+	}
+	if course.ArchivedAt != nil {
+		return
+	}
+	event := CourseEvent{
+		Type:       CourseUpdated,
+		CourseID:   id,
+		Course:     &course,
+		OccurredAt: time.Now().UTC(),
+	}
+	if err := PublishCourseEvent(ctx, b, event); err != nil {
+		log.Println("Error al publicar evento de curso:", event.Type, id, err)
+	}
+}
@@ -3,408 +3,307 @@ package courses
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"log"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/search"
 	"github.com/hugodiazo/arq-soft-2/api/users"
-	"github.com/hugodiazo/arq-soft-2/db"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/hugodiazo/arq-soft-2/api/validate"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/i18n"
+	"github.com/hugodiazo/arq-soft-2/queue"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-var jwtKey = []byte("my_secret_key")
-
-// getUserIDFromToken extrae el ID del usuario del token JWT
-func getUserIDFromToken(r *http.Request) (int, error) {
-	authHeader := r.Header.Get("Authorization")
-	log.Println("Encabezado Authorization:", authHeader) // Depurar el encabezado
-
-	if authHeader == "" {
-		return 0, fmt.Errorf("token no proporcionado")
-	}
-
-	// Extraer el token
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
-	// Parsear el token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("método de firma inesperado: %v", token.Header["alg"])
-		}
-		return jwtKey, nil
-	})
-
-	if err != nil || !token.Valid {
-		log.Println("Error al parsear o token inválido:", err)
-		return 0, fmt.Errorf("token inválido")
-	}
+// Errores de la API de cursos e inscripciones
+var (
+	errInvalidCourseID        = apierror.ErrInvalidID.WithMessage("courses.invalid_id")
+	errCourseNotFound         = apierror.New(http.StatusNotFound, "course_not_found", "courses.not_found")
+	errCourseArchived         = apierror.New(http.StatusConflict, "course_archived", "courses.archived")
+	errCourseUnavailable      = apierror.New(http.StatusConflict, "course_unavailable", "courses.unavailable")
+	errModuleNotFound         = apierror.New(http.StatusNotFound, "module_not_found", "courses.module_not_found")
+	errLessonNotFound         = apierror.New(http.StatusNotFound, "lesson_not_found", "courses.lesson_not_found")
+	errContentChanged         = apierror.New(http.StatusConflict, "content_changed", "courses.content_changed")
+	errEnrollmentNotFound     = apierror.New(http.StatusNotFound, "enrollment_not_found", "enrollments.not_found")
+	errEnrollmentExists       = apierror.New(http.StatusConflict, "enrollment_exists", "enrollments.exists")
+	errEnrollmentChanged      = apierror.New(http.StatusConflict, "enrollment_changed", "enrollments.changed")
+	errEnrollmentNotActive    = apierror.New(http.StatusConflict, "enrollment_not_active", "enrollments.not_active")
+	errInvalidTransition      = apierror.New(http.StatusConflict, "invalid_transition", "enrollments.invalid_transition")
+	errCompletionRequirements = apierror.New(http.StatusConflict, "completion_requirements", "enrollments.completion_requirements")
+)
 
-	// Extraer las reclamaciones del token
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID, ok := claims["user_id"].(float64)
-		if !ok {
-			return 0, fmt.Errorf("ID de usuario no encontrado en el token")
-		}
-		log.Println("ID de usuario extraído:", int(userID))
-		return int(userID), nil
+// currentUserID obtiene el ID del usuario autenticado por auth.Authenticate
+func currentUserID(r *http.Request) (int, error) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return 0, auth.ErrMissingToken
 	}
-
-	return 0, fmt.Errorf("no se pudieron obtener las reclamaciones del token")
+	return claims.UserID, nil
 }
 
 // Course representa un curso en la base de datos
 type Course struct {
-	ID           primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Title        string             `json:"title"`
-	Description  string             `json:"description"`
-	Instructor   string             `json:"instructor"`
-	Duration     int                `json:"duration"`
-	Level        string             `json:"level"`
-	Availability bool               `json:"availability"`
+	ID          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Title       string             `json:"title" validate:"required,max=200"`
+	Description string             `json:"description" validate:"max=5000"`
+	Instructor  string             `json:"instructor" validate:"max=100"`
+	// InstructorID es el ID en la tabla users del instructor dueño del curso
+	InstructorID int `json:"instructor_id" bson:"instructor_id" validate:"min=0"`
+	// Duration es la duración en horas y Level el nivel: beginner, intermediate o advanced
+	Duration     int    `json:"duration" validate:"min=0"`
+	Level        string `json:"level" validate:"required,oneof=beginner intermediate advanced"`
+	Availability bool   `json:"availability"`
+	// Capacity es el cupo máximo de inscriptos activos; nil indica sin límite
+	Capacity *int `json:"capacity,omitempty" bson:"capacity,omitempty" validate:"min=0"`
+	// Enrolled es la cantidad de lugares ocupados; solo lo modifican ReserveSeat y ReleaseSeat
+	Enrolled int `json:"enrolled" bson:"enrolled,omitempty"`
+	// ArchivedAt indica que el curso fue dado de baja lógica; se conserva para el historial
+	ArchivedAt *time.Time `json:"archived_at,omitempty" bson:"archived_at,omitempty"`
+	// Modules es el contenido del curso; se edita con las rutas de /courses/{id}/modules
+	Modules []Module `json:"modules,omitempty" bson:"modules,omitempty"`
+	// ContentVersion cuenta los cambios de Modules para detectar ediciones simultáneas
+	ContentVersion int `json:"-" bson:"content_version,omitempty"`
+	// RatingAverage y RatingCount resumen las reseñas visibles; solo los modifica SetRating
+	RatingAverage float64 `json:"rating_average" bson:"rating_average,omitempty"`
+	RatingCount   int     `json:"rating_count" bson:"rating_count,omitempty"`
+	// CategoryID es la categoría del curso y CategoryPath los IDs desde la raíz hasta ella inclusive;
+	// Tags son los slugs de sus etiquetas. Solo los modifican SetClassification y ReplaceTag.
+	CategoryID   string   `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	CategoryPath []string `json:"category_path,omitempty" bson:"category_path,omitempty"`
+	Tags         []string `json:"tags,omitempty" bson:"tags,omitempty"`
 }
 
-func indexCourseInSolr(course Course, id string) {
-	// Construimos la URL de Solr
-	url := "http://localhost:8983/solr/courses/update/json/docs?commit=true"
-
-	// Construir el JSON del curso
-	body, err := json.Marshal(map[string]interface{}{
-		"id":           id,
-		"title":        course.Title,
-		"description":  course.Description,
-		"instructor":   course.Instructor,
-		"duration":     course.Duration,
-		"level":        course.Level,
-		"availability": course.Availability,
-	})
-	if err != nil {
-		log.Println("Error al crear el JSON para Solr:", err)
-		return
-	}
-
-	// Enviar la solicitud POST a Solr
-	resp, err := http.Post(url, "application/json", strings.NewReader(string(body)))
-	if err != nil {
-		log.Println("Error al indexar curso en Solr:", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		log.Println("Error en la respuesta de Solr:", resp.Status)
-	}
+// SearchDocument convierte el curso al documento del índice de búsqueda, con su hash de contenido
+func (c Course) SearchDocument() search.Course {
+	doc := search.Course{
+		ID:           c.ID.Hex(),
+		Title:        c.Title,
+		Description:  c.Description,
+		Instructor:   c.Instructor,
+		Duration:     c.Duration,
+		Level:        c.Level,
+		Availability: c.Availability,
+		Rating:       c.RatingAverage,
+		RatingCount:  c.RatingCount,
+		Categories:   c.CategoryPath,
+		Tags:         c.Tags,
+	}
+	return doc.WithContentHash()
 }
 
-func IndexAllCoursesInSolr() {
-	cursor, err := db.MongoDB.Collection("courses").Find(context.TODO(), bson.M{})
-	if err != nil {
-		log.Println("Error al obtener cursos de MongoDB:", err)
-		return
-	}
-	defer cursor.Close(context.TODO())
-
-	for cursor.Next(context.TODO()) {
-		var course Course
-		if err := cursor.Decode(&course); err != nil {
-			log.Println("Error al decodificar curso:", err)
-			continue
-		}
-
-		// Convierte el ObjectID a una cadena para Solr
-		stringID := course.ID.Hex()
+// Handler agrupa los handlers HTTP de cursos e inscripciones y sus dependencias
+type Handler struct {
+	courses     CourseRepository
+	enrollments EnrollmentRepository
+	users       users.UserRepository
+	events      queue.Broker
+	policy      auth.Policy
+	// gate y onComplete pueden ser nil si completar un curso no tiene requisitos ni efectos
+	gate       CompletionGate
+	onComplete CompletionHook
+}
 
-		// Indexa el curso en Solr
-		indexCourseInSolr(course, stringID)
+// NewHandler crea los handlers de cursos con los repositorios, el broker de eventos, la política de
+// permisos, los requisitos para completar un curso y lo que se ejecuta al completarlo
+func NewHandler(courses CourseRepository, enrollments EnrollmentRepository, users users.UserRepository, events queue.Broker, policy auth.Policy, gate CompletionGate, onComplete CompletionHook) *Handler {
+	return &Handler{
+		courses:     courses,
+		enrollments: enrollments,
+		users:       users,
+		events:      events,
+		policy:      policy,
+		gate:        gate,
+		onComplete:  onComplete,
 	}
-
-	log.Println("Todos los cursos se han indexado en Solr")
 }
 
 // CreateCourse maneja la creación de un curso
-func CreateCourse(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateCourse(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		apierror.Write(w, r, apierror.ErrMethodNotAllowed)
 		return
 	}
 
-	// Obtener el ID del usuario desde el token
-	userID, err := getUserIDFromToken(r)
-	if err != nil {
-		http.Error(w, "No autorizado", http.StatusUnauthorized)
-		return
-	}
-
-	// Obtener el usuario desde la base de datos
-	user, err := users.GetUserByID(userID)
-	if err != nil || user.Role != "admin" {
-		http.Error(w, "No tienes permiso para crear un curso", http.StatusForbidden)
+	// El permiso course:create se verifica en el middleware de la ruta
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
 		return
 	}
 
 	// Procesar la creación del curso
 	var course Course
-	if err := json.NewDecoder(r.Body).Decode(&course); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !validate.DecodeJSON(w, r, &course) {
 		return
 	}
+	// Los lugares ocupados, el archivado, el contenido, las calificaciones y la clasificación no se
+	// asignan desde esta ruta
+	course.Enrolled = 0
+	course.ArchivedAt = nil
+	course.Modules = nil
+	course.RatingAverage, course.RatingCount = 0, 0
+	course.CategoryID, course.CategoryPath, course.Tags = "", nil, nil
 
-	// Crear un nuevo ObjectID
-	course.ID = primitive.NewObjectID()
+	// Solo quien puede editar cualquier curso puede asignarlo a otro instructor
+	if course.InstructorID == 0 || !h.policy.Allows(claims.Role, auth.PermCourseWrite) {
+		course.InstructorID = claims.UserID
+	}
+	if err := h.resolveInstructor(r.Context(), &course); err != nil {
+		apierror.Write(w, r, apierror.Validation(err))
+		return
+	}
 
 	// Insertar el curso en MongoDB
-	_, err = db.MongoDB.Collection("courses").InsertOne(context.TODO(), course)
-	if err != nil {
-		http.Error(w, "Error al crear el curso", http.StatusInternalServerError)
+	if err := h.courses.Create(r.Context(), &course); err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.create_failed"))
 		return
 	}
 
-	// Convertir el ID a string para Solr y limpiar el ObjectID si es necesario
-	stringID := course.ID.Hex()
-	course.ID = primitive.ObjectID{}
+	// Publicar el evento para que el indexador lo agregue al motor de búsqueda
+	h.publishCourseEvent(r.Context(), CourseCreated, course.ID.Hex(), &course)
 
-	// Indexar el curso en Solr
-	indexCourseInSolr(course, stringID)
-
-	json.NewEncoder(w).Encode(map[string]string{"message": "Curso creado con éxito"})
+	json.NewEncoder(w).Encode(map[string]string{"message": i18n.Tr(r.Context(), "courses.created")})
 }
 
-// GetCourses maneja la obtención de todos los cursos
-func GetCourses(w http.ResponseWriter, r *http.Request) {
-	cursor, err := db.MongoDB.Collection("courses").Find(context.TODO(), bson.M{})
-	if err != nil {
-		http.Error(w, "Error al obtener cursos", http.StatusInternalServerError)
-		return
+// authorizeCourse obtiene el curso y verifica que el usuario tenga perm sobre cualquier curso
+// o su variante ":own" siendo el instructor; si no, responde el error y devuelve false
+func (h *Handler) authorizeCourse(w http.ResponseWriter, r *http.Request, id string, perm auth.Permission) (Course, bool) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return Course{}, false
 	}
-	defer cursor.Close(context.TODO())
 
-	var courses []Course
-	for cursor.Next(context.TODO()) {
-		var course Course
-		if err := cursor.Decode(&course); err != nil {
-			continue
-		}
-		courses = append(courses, course)
+	course, err := h.courses.GetByID(r.Context(), id)
+	if errors.Is(err, ErrCourseNotFound) {
+		apierror.Write(w, r, errCourseNotFound)
+		return course, false
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.fetch_failed"))
+		return course, false
 	}
 
-	json.NewEncoder(w).Encode(courses)
+	if !h.policy.AllowsOwned(claims, perm, course.InstructorID) {
+		apierror.Write(w, r, apierror.ErrForbidden.WithMessage("courses.forbidden_edit"))
+		return course, false
+	}
+	return course, true
 }
 
-// GetCourseByID maneja la obtención de un curso por ID
-func GetCourseByID(w http.ResponseWriter, r *http.Request) {
-	id := strings.TrimPrefix(r.URL.Path, "/courses/")
-
-	objectID, err := primitive.ObjectIDFromHex(id)
+// resolveInstructor verifica que InstructorID sea un instructor o administrador y completa su nombre
+func (h *Handler) resolveInstructor(ctx context.Context, course *Course) error {
+	instructor, err := h.users.GetByID(ctx, course.InstructorID)
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
-		return
+		return apierror.Field("instructor_id", apierror.FieldInvalid, "courses.instructor_not_found", course.InstructorID)
 	}
-
-	var course Course
-	filter := bson.M{"_id": objectID}
-
-	err = db.MongoDB.Collection("courses").FindOne(context.TODO(), filter).Decode(&course)
-	if err != nil {
-		http.Error(w, "Curso no encontrado", http.StatusNotFound)
-		return
+	if instructor.Role != auth.RoleInstructor && instructor.Role != auth.RoleAdmin {
+		return apierror.Field("instructor_id", apierror.FieldInvalid, "courses.not_instructor", course.InstructorID)
 	}
-
-	json.NewEncoder(w).Encode(course)
-}
-
-// UpdateCourse maneja la actualización de un curso
-func UpdateCourse(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
-		return
+	if course.Instructor == "" {
+		course.Instructor = instructor.Name
 	}
+	return nil
+}
 
-	id := strings.TrimPrefix(r.URL.Path, "/courses/update/")
-	objectID, err := primitive.ObjectIDFromHex(id)
+// GetCourses maneja la obtención paginada de cursos con filtros y orden
+func (h *Handler) GetCourses(w http.ResponseWriter, r *http.Request) {
+	query, err := ParseCourseQuery(r.URL.Query())
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		apierror.Write(w, r, apierror.Validation(err))
 		return
 	}
 
-	var course Course
-	if err := json.NewDecoder(r.Body).Decode(&course); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	page, err := h.courses.Find(r.Context(), query)
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.list_failed"))
 		return
 	}
 
-	filter := bson.M{"_id": objectID}
-	update := bson.M{"$set": course}
-
-	result, err := db.MongoDB.Collection("courses").UpdateOne(context.TODO(), filter, update)
-	if err != nil || result.MatchedCount == 0 {
-		http.Error(w, "Error al actualizar curso", http.StatusInternalServerError)
-		return
+	for i, course := range page.Courses {
+		page.Courses[i] = course.outline()
 	}
-
-	// Convertir el ID a string para Solr
-	stringID := objectID.Hex()
-
-	// Actualizar el curso en Solr
-	indexCourseInSolr(course, stringID)
-
-	json.NewEncoder(w).Encode(map[string]string{"message": "Curso actualizado con éxito"})
+	json.NewEncoder(w).Encode(page)
 }
 
-// Enrollment representa la inscripción de un usuario en un curso
-type Enrollment struct {
-	UserID   int    `json:"user_id" bson:"user_id"`
-	CourseID string `json:"course_id" bson:"course_id"`
-	Status   string `json:"status" bson:"status"`
-}
-
-// EnrollUser maneja la inscripción de un usuario en un curso
-func EnrollUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
-		return
-	}
+// GetCourseByID maneja la obtención de un curso por ID
+func (h *Handler) GetCourseByID(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/courses/")
 
-	userID, err := getUserIDFromToken(r)
-	if err != nil {
-		http.Error(w, "No se pudo obtener el ID del usuario", http.StatusUnauthorized)
+	if !primitive.IsValidObjectID(id) {
+		apierror.Write(w, r, apierror.ErrInvalidID)
 		return
 	}
 
-	var enrollment Enrollment
-	if err := json.NewDecoder(r.Body).Decode(&enrollment); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	course, err := h.courses.GetByID(r.Context(), id)
+	if errors.Is(err, ErrCourseNotFound) {
+		apierror.Write(w, r, errCourseNotFound)
 		return
-	}
-
-	enrollment.UserID = userID
-	enrollment.Status = "active"
-
-	_, err = db.MongoDB.Collection("enrollments").InsertOne(context.TODO(), enrollment)
-	if err != nil {
-		http.Error(w, "Error al inscribir usuario", http.StatusInternalServerError)
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.fetch_failed"))
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{"message": "Usuario inscrito con éxito"})
+	json.NewEncoder(w).Encode(course.outline())
 }
 
-// GetEnrollments obtiene todas las inscripciones
-func GetEnrollments(w http.ResponseWriter, r *http.Request) {
-	userID, err := getUserIDFromToken(r)
-	if err != nil {
-		http.Error(w, "No se pudo obtener el ID del usuario", http.StatusUnauthorized)
+// UpdateCourse maneja la actualización de un curso
+func (h *Handler) UpdateCourse(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		apierror.Write(w, r, apierror.ErrMethodNotAllowed)
 		return
 	}
 
-	// Buscar las inscripciones del usuario en la base de datos
-	cursor, err := db.MongoDB.Collection("enrollments").Find(context.TODO(), bson.M{"user_id": userID})
-	if err != nil {
-		http.Error(w, "Error al obtener inscripciones", http.StatusInternalServerError)
+	id := r.PathValue("id")
+	if !primitive.IsValidObjectID(id) {
+		apierror.Write(w, r, apierror.ErrInvalidID)
 		return
 	}
-	defer cursor.Close(context.TODO())
 
-	var enrolledCourses []Course
-	for cursor.Next(context.TODO()) {
-		var enrollment Enrollment
-		if err := cursor.Decode(&enrollment); err != nil {
-			continue
-		}
-
-		// Convertir el course_id de string a ObjectID
-		objectID, err := primitive.ObjectIDFromHex(enrollment.CourseID)
-		if err != nil {
-			continue
-		}
-
-		// Buscar los detalles del curso usando el ObjectID
-		var course Course
-		err = db.MongoDB.Collection("courses").FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&course)
-		if err != nil {
-			continue
-		}
-		enrolledCourses = append(enrolledCourses, course)
-	}
-
-	// Verificar si no se encontraron cursos inscritos
-	if len(enrolledCourses) == 0 {
-		json.NewEncoder(w).Encode(map[string]string{"message": "No estás inscrito en ningún curso."})
+	existing, ok := h.authorizeCourse(w, r, id, auth.PermCourseWrite)
+	if !ok {
 		return
 	}
-
-	json.NewEncoder(w).Encode(enrolledCourses)
-}
-
-// DeleteCourse maneja la eliminación de un curso por ID
-func DeleteCourse(w http.ResponseWriter, r *http.Request) {
-	id := strings.TrimPrefix(r.URL.Path, "/courses/delete/")
-
-	// Convertir el ID de string a ObjectId
-	objectID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+	if existing.ArchivedAt != nil {
+		apierror.Write(w, r, errCourseArchived)
 		return
 	}
 
-	// Intentar eliminar el curso de la base de datos
-	filter := bson.M{"_id": objectID}
-	result, err := db.MongoDB.Collection("courses").DeleteOne(context.TODO(), filter)
-	if err != nil || result.DeletedCount == 0 {
-		http.Error(w, "Curso no encontrado o no pudo ser eliminado", http.StatusNotFound)
+	var course Course
+	if !validate.DecodeJSON(w, r, &course) {
 		return
 	}
+	// Los cursos se archivan solo con DeleteCourse, que también vence sus inscripciones
+	course.ArchivedAt = nil
 
-	json.NewEncoder(w).Encode(map[string]string{"message": "Curso eliminado con éxito"})
-}
-
-// UnenrollUser maneja la desinscripción de un usuario de un curso
-func UnenrollUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
-		return
+	// Los instructores no pueden reasignar sus cursos
+	claims, _ := auth.UserFromContext(r.Context())
+	if course.InstructorID == 0 || !h.policy.Allows(claims.Role, auth.PermCourseWrite) {
+		course.InstructorID = existing.InstructorID
+		if course.Instructor == "" {
+			course.Instructor = existing.Instructor
+		}
 	}
-
-	// Extraer el ID del usuario desde el token
-	userID, err := getUserIDFromToken(r)
-	if err != nil {
-		log.Println("Error al obtener el ID del usuario:", err)
-		http.Error(w, "No se pudo obtener el ID del usuario", http.StatusUnauthorized)
-		return
+	if course.InstructorID != 0 {
+		if err := h.resolveInstructor(r.Context(), &course); err != nil {
+			apierror.Write(w, r, apierror.Validation(err))
+			return
+		}
 	}
-	log.Println("ID de usuario extraído:", userID)
 
-	// Obtener el `course_id` de los parámetros de la URL
-	courseID := r.URL.Query().Get("course_id")
-	if courseID == "" {
-		log.Println("ID del curso no proporcionado")
-		http.Error(w, "ID del curso no proporcionado", http.StatusBadRequest)
+	err := h.courses.Update(r.Context(), id, course)
+	if errors.Is(err, ErrCourseNotFound) {
+		apierror.Write(w, r, errCourseNotFound)
 		return
-	}
-	log.Println("ID del curso proporcionado:", courseID)
-
-	// Convertir `course_id` de cadena a `ObjectID`
-	objectID, err := primitive.ObjectIDFromHex(courseID)
-	if err != nil {
-		log.Println("ID del curso inválido:", err)
-		http.Error(w, "ID del curso inválido", http.StatusBadRequest)
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.update_failed"))
 		return
 	}
-	log.Println("ObjectID del curso convertido correctamente:", objectID)
 
-	// Eliminar la inscripción de la base de datos
-	filter := bson.M{
-		"user_id":   userID,
-		"course_id": courseID, // Usa el `courseID` como una cadena si está almacenado como tal
-	}
-	result, err := db.MongoDB.Collection("enrollments").DeleteOne(context.TODO(), filter)
-	if err != nil || result.DeletedCount == 0 {
-		log.Println("Error al desinscribirse o inscripción no encontrada:", err)
-		http.Error(w, "Error al desinscribirse o inscripción no encontrada", http.StatusInternalServerError)
-		return
-	}
+	// Se publica el curso guardado: el cuerpo no trae las calificaciones ni la clasificación
+	PublishStoredCourse(r.Context(), h.courses, h.events, id)
 
-	log.Println("Desinscripción exitosa para userID:", userID, "y courseID:", courseID)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Desinscripción exitosa"})
+	json.NewEncoder(w).Encode(map[string]string{"message": i18n.Tr(r.Context(), "courses.updated")})
 }
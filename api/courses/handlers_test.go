@@ -0,0 +1,302 @@
+package courses
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/search"
+	"github.com/hugodiazo/arq-soft-2/api/users"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/queue"
+)
+
+// testAPI reúne los handlers de cursos y de búsqueda sobre los repositorios y el motor en memoria
+type testAPI struct {
+	mux     *http.ServeMux
+	courses *MemoryCourseRepository
+	users   *users.MemoryUserRepository
+	broker  *queue.MemoryBroker
+	engine  *search.MemoryEngine
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	api := &testAPI{
+		mux:     http.NewServeMux(),
+		courses: NewMemoryCourseRepository(),
+		users:   users.NewMemoryUserRepository(),
+		broker:  queue.NewMemoryBroker(),
+		engine:  search.NewMemoryEngine(),
+	}
+	h := NewHandler(api.courses, NewMemoryEnrollmentRepository(), api.users, api.broker, auth.DefaultPolicy, nil, nil)
+	api.mux.HandleFunc("GET /courses", h.GetCourses)
+	api.mux.HandleFunc("POST /courses", h.CreateCourse)
+	api.mux.HandleFunc("/courses/", h.GetCourseByID)
+	api.mux.HandleFunc("PUT /courses/update/{id}", h.UpdateCourse)
+	api.mux.HandleFunc("GET /search", search.NewHandler(api.engine).SearchCourses)
+	return api
+}
+
+// addUser crea un usuario con el rol dado y devuelve las reclamaciones de su token
+func (api *testAPI) addUser(t *testing.T, name, role string) *auth.Claims {
+	t.Helper()
+	user := users.User{Name: name, Email: strings.ToLower(name) + "@example.com", Password: "secreto123", Role: role}
+	if err := api.users.Create(context.Background(), &user); err != nil {
+		t.Fatal(err)
+	}
+	return &auth.Claims{UserID: user.ID, Email: user.Email, Role: role}
+}
+
+// do envía la solicitud como el usuario de claims, o sin autenticar si es nil
+func (api *testAPI) do(method, target, body string, claims *auth.Claims) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if claims != nil {
+		r = r.WithContext(auth.WithUser(r.Context(), claims))
+	}
+	w := httptest.NewRecorder()
+	api.mux.ServeHTTP(w, r)
+	return w
+}
+
+// index aplica en el motor de búsqueda los eventos publicados hasta el momento
+func (api *testAPI) index(t *testing.T) {
+	t.Helper()
+	worker := &IndexWorker{Broker: api.broker, Engine: api.engine, MaxAttempts: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages, err := api.broker.Consume(ctx, CourseEventsQueue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for api.broker.Pending(CourseEventsQueue) > 0 {
+		if err := worker.handle(ctx, (<-messages).Body); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// search devuelve los resultados de GET /search para el texto dado
+func (api *testAPI) search(t *testing.T, text string) []search.Hit {
+	t.Helper()
+	w := api.do(http.MethodGet, "/search?q="+text, "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /search: estado %d: %s", w.Code, w.Body)
+	}
+	var result search.Result
+	decodeBody(t, w, &result)
+	return result.Results
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+		t.Fatalf("respuesta inválida: %v", err)
+	}
+}
+
+// expectProblem verifica el estado y el código de una respuesta de error y devuelve su cuerpo
+func expectProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) apierror.Problem {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("estado %d, se esperaba %d: %s", w.Code, status, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != apierror.ContentType {
+		t.Fatalf("Content-Type %q, se esperaba %q", ct, apierror.ContentType)
+	}
+	var problem apierror.Problem
+	decodeBody(t, w, &problem)
+	if problem.Code != code {
+		t.Fatalf("código %q, se esperaba %q", problem.Code, code)
+	}
+	return problem
+}
+
+// onlyCourse devuelve el único curso guardado en el repositorio
+func (api *testAPI) onlyCourse(t *testing.T) Course {
+	t.Helper()
+	stored, err := api.courses.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("hay %d cursos guardados, se esperaba 1", len(stored))
+	}
+	return stored[0]
+}
+
+func TestCreateCourse(t *testing.T) {
+	api := newTestAPI(t)
+	instructor := api.addUser(t, "Ada", auth.RoleInstructor)
+
+	body := `{"title": "Go concurrente", "level": "intermediate", "duration": 12, "availability": true, "enrolled": 40, "rating_average": 5}`
+	if w := api.do(http.MethodPost, "/courses", body, instructor); w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+
+	course := api.onlyCourse(t)
+	if course.InstructorID != instructor.UserID || course.Instructor != "Ada" {
+		t.Errorf("instructor %d %q, se esperaba %d %q", course.InstructorID, course.Instructor, instructor.UserID, "Ada")
+	}
+	if course.Enrolled != 0 || course.RatingAverage != 0 {
+		t.Errorf("el cuerpo asignó enrolled=%d rating_average=%v", course.Enrolled, course.RatingAverage)
+	}
+
+	api.index(t)
+	hits := api.search(t, "concurrente")
+	if len(hits) != 1 || hits[0].ID != course.ID.Hex() {
+		t.Fatalf("la búsqueda devolvió %+v, se esperaba el curso %s", hits, course.ID.Hex())
+	}
+}
+
+func TestCreateCourseRequiresInstructor(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.addUser(t, "Root", auth.RoleAdmin)
+	student := api.addUser(t, "Bruno", auth.RoleUser)
+
+	body := fmt.Sprintf(`{"title": "Go", "level": "beginner", "instructor_id": %d}`, student.UserID)
+	problem := expectProblem(t, api.do(http.MethodPost, "/courses", body, admin), http.StatusBadRequest, "validation_failed")
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "instructor_id" {
+		t.Fatalf("errores %+v, se esperaba uno de instructor_id", problem.Errors)
+	}
+
+	expectProblem(t, api.do(http.MethodPost, "/courses", `{"title": "Go", "level": "beginner"}`, nil), http.StatusUnauthorized, "unauthorized")
+}
+
+func TestCreateCourseValidation(t *testing.T) {
+	api := newTestAPI(t)
+	instructor := api.addUser(t, "Ada", auth.RoleInstructor)
+
+	body := `{"title": " ", "level": "expert", "duration": -1, "price": 10}`
+	problem := expectProblem(t, api.do(http.MethodPost, "/courses", body, instructor), http.StatusBadRequest, "validation_failed")
+
+	fields := make(map[string]string)
+	for _, field := range problem.Errors {
+		fields[field.Field] = field.Code
+	}
+	want := map[string]string{
+		"title":    apierror.FieldRequired,
+		"level":    apierror.FieldInvalid,
+		"duration": apierror.FieldOutOfRange,
+		"price":    apierror.FieldUnknown,
+	}
+	for field, code := range want {
+		if fields[field] != code {
+			t.Errorf("campo %s: código %q, se esperaba %q", field, fields[field], code)
+		}
+	}
+	if len(fields) != len(want) {
+		t.Errorf("errores %v, se esperaban %v", fields, want)
+	}
+}
+
+func TestGetCourseByID(t *testing.T) {
+	api := newTestAPI(t)
+	course := Course{Title: "Go", Level: "beginner", Modules: []Module{{ID: "m1", Title: "Intro"}}}
+	if err := api.courses.Create(context.Background(), &course); err != nil {
+		t.Fatal(err)
+	}
+
+	w := api.do(http.MethodGet, "/courses/"+course.ID.Hex(), "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+	var got Course
+	decodeBody(t, w, &got)
+	if got.ID != course.ID || got.Title != "Go" {
+		t.Errorf("se obtuvo %+v", got)
+	}
+
+	expectProblem(t, api.do(http.MethodGet, "/courses/no-es-un-id", "", nil), http.StatusBadRequest, "invalid_id")
+	expectProblem(t, api.do(http.MethodGet, "/courses/000000000000000000000000", "", nil), http.StatusNotFound, "course_not_found")
+}
+
+func TestGetCoursesFilters(t *testing.T) {
+	api := newTestAPI(t)
+	for _, course := range []Course{
+		{Title: "Go básico", Level: "beginner", Availability: true},
+		{Title: "Go avanzado", Level: "advanced", Availability: true},
+		{Title: "Rust básico", Level: "beginner"},
+	} {
+		if err := api.courses.Create(context.Background(), &course); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := api.do(http.MethodGet, "/courses?level=beginner&sort=title", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+	var page CoursePage
+	decodeBody(t, w, &page)
+	if len(page.Courses) != 2 || page.Courses[0].Title != "Go básico" || page.Courses[1].Title != "Rust básico" {
+		t.Fatalf("se obtuvo %+v", page.Courses)
+	}
+
+	expectProblem(t, api.do(http.MethodGet, "/courses?sort=price", "", nil), http.StatusBadRequest, "validation_failed")
+}
+
+func TestUpdateCourse(t *testing.T) {
+	api := newTestAPI(t)
+	instructor := api.addUser(t, "Ada", auth.RoleInstructor)
+	capacity := 20
+	course := Course{Title: "Go", Level: "beginner", InstructorID: instructor.UserID, Instructor: "Ada", Capacity: &capacity}
+	if err := api.courses.Create(context.Background(), &course); err != nil {
+		t.Fatal(err)
+	}
+	id := course.ID.Hex()
+	if err := api.courses.SetRating(context.Background(), id, 4.5, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// El cuerpo no puede archivar el curso ni cambiar sus calificaciones, y sin capacity no hay cupo
+	body := `{"title": "Go práctico", "level": "advanced", "archived_at": "2024-01-01T00:00:00Z", "rating_average": 1}`
+	if w := api.do(http.MethodPut, "/courses/update/"+id, body, instructor); w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+
+	stored := api.onlyCourse(t)
+	if stored.Title != "Go práctico" || stored.Level != "advanced" {
+		t.Errorf("no se guardaron los cambios: %+v", stored)
+	}
+	if stored.ArchivedAt != nil || stored.Capacity != nil || stored.RatingAverage != 4.5 {
+		t.Errorf("archived_at=%v capacity=%v rating_average=%v", stored.ArchivedAt, stored.Capacity, stored.RatingAverage)
+	}
+
+	// Se indexa el curso guardado, con sus calificaciones
+	api.index(t)
+	hits := api.search(t, "práctico")
+	if len(hits) != 1 || hits[0].Rating != 4.5 || hits[0].RatingCount != 2 {
+		t.Fatalf("la búsqueda devolvió %+v, se esperaba el curso con rating 4.5", hits)
+	}
+}
+
+func TestUpdateCourseForbidden(t *testing.T) {
+	api := newTestAPI(t)
+	owner := api.addUser(t, "Ada", auth.RoleInstructor)
+	other := api.addUser(t, "Grace", auth.RoleInstructor)
+	course := Course{Title: "Go", Level: "beginner", InstructorID: owner.UserID}
+	if err := api.courses.Create(context.Background(), &course); err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"title": "Otro", "level": "beginner"}`
+	expectProblem(t, api.do(http.MethodPut, "/courses/update/"+course.ID.Hex(), body, other), http.StatusForbidden, "forbidden")
+	if stored := api.onlyCourse(t); stored.Title != "Go" {
+		t.Errorf("el título cambió a %q", stored.Title)
+	}
+
+	if err := api.courses.Archive(context.Background(), course.ID.Hex(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	expectProblem(t, api.do(http.MethodPut, "/courses/update/"+course.ID.Hex(), body, owner), http.StatusConflict, "course_archived")
+	if n := api.broker.Pending(CourseEventsQueue); n != 0 {
+		t.Errorf("se publicaron %d eventos, se esperaba ninguno", n)
+	}
+}
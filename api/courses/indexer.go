@@ -0,0 +1,133 @@
+package courses
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/search"
+	"github.com/hugodiazo/arq-soft-2/queue"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxRequeueDelay limita la espera antes de devolver a la cola un evento que no se pudo resguardar
+const maxRequeueDelay = 30 * time.Second
+
+// IndexWorker consume la cola de eventos de cursos y los aplica en el índice con reintentos
+type IndexWorker struct {
+	Broker queue.Broker
+	Engine search.Engine
+	// MaxAttempts es la cantidad de intentos antes de enviar el evento a la cola de mensajes muertos
+	MaxAttempts int
+	// Backoff es la espera inicial entre intentos; se duplica en cada reintento
+	Backoff time.Duration
+}
+
+// NewIndexWorker crea un consumidor con la política de reintentos por defecto
+func NewIndexWorker(b queue.Broker, engine search.Engine) *IndexWorker {
+	return &IndexWorker{
+		Broker:      b,
+		Engine:      engine,
+		MaxAttempts: 5,
+		Backoff:     500 * time.Millisecond,
+	}
+}
+
+// Run procesa eventos hasta que se cancele ctx
+func (w *IndexWorker) Run(ctx context.Context) error {
+	messages, err := w.Broker.Consume(ctx, CourseEventsQueue)
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	for msg := range messages {
+		if err := w.handle(ctx, msg.Body); err != nil {
+			// Si no se pudo resguardar el evento se devuelve a la cola, esperando cada vez más
+			// para no repetirlo sin pausa mientras la cola de mensajes muertos no responda
+			failures++
+			delay := w.requeueDelay(failures)
+			log.Printf("Error al procesar evento de curso, se devolverá a la cola en %s: %v", delay, err)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+			}
+			msg.Nack(true)
+			continue
+		}
+		failures = 0
+		msg.Ack()
+	}
+	return ctx.Err()
+}
+
+// requeueDelay duplica Backoff por cada falla seguida, hasta maxRequeueDelay
+func (w *IndexWorker) requeueDelay(failures int) time.Duration {
+	delay := w.Backoff
+	for i := 1; i < failures && delay < maxRequeueDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRequeueDelay)
+}
+
+// handle aplica el evento con reintentos; si se agotan lo envía a la cola de mensajes muertos.
+// Solo devuelve error si el evento no pudo aplicarse ni resguardarse.
+func (w *IndexWorker) handle(ctx context.Context, body []byte) error {
+	var event CourseEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		log.Println("Evento de curso con formato inválido:", err)
+		return w.deadLetter(ctx, body)
+	}
+
+	backoff := w.Backoff
+	var lastErr error
+	for attempt := 1; attempt <= w.MaxAttempts; attempt++ {
+		if lastErr = w.apply(ctx, event); lastErr == nil {
+			return nil
+		}
+		log.Printf("Intento %d/%d fallido al indexar curso %s: %v", attempt, w.MaxAttempts, event.CourseID, lastErr)
+
+		if attempt == w.MaxAttempts {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	event.Error = lastErr.Error()
+	failed, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return w.deadLetter(ctx, failed)
+}
+
+// apply ejecuta la operación correspondiente al tipo de evento
+func (w *IndexWorker) apply(ctx context.Context, event CourseEvent) error {
+	switch event.Type {
+	case CourseCreated, CourseUpdated:
+		if event.Course == nil {
+			return fmt.Errorf("evento %s sin datos del curso", event.Type)
+		}
+		course := *event.Course
+		if course.ID.IsZero() {
+			course.ID, _ = primitive.ObjectIDFromHex(event.CourseID)
+		}
+		return w.Engine.Index(ctx, course.SearchDocument())
+	case CourseDeleted:
+		return w.Engine.Delete(ctx, event.CourseID)
+	default:
+		return fmt.Errorf("tipo de evento desconocido: %s", event.Type)
+	}
+}
+
+func (w *IndexWorker) deadLetter(ctx context.Context, body []byte) error {
+	log.Println("Enviando evento a la cola de mensajes muertos")
+	return w.Broker.Publish(ctx, CourseEventsDeadLetterQueue, body)
+}
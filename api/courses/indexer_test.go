@@ -0,0 +1,215 @@
+package courses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/search"
+	"github.com/hugodiazo/arq-soft-2/queue"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// flakyEngine falla las primeras failures llamadas a Index y registra el momento de cada intento
+type flakyEngine struct {
+	search.Engine
+	failures int
+
+	mu       sync.Mutex
+	attempts []time.Time
+}
+
+func (e *flakyEngine) Index(ctx context.Context, course search.Course) error {
+	e.mu.Lock()
+	e.attempts = append(e.attempts, time.Now())
+	attempt := len(e.attempts)
+	e.mu.Unlock()
+
+	if attempt <= e.failures {
+		return errors.New("motor no disponible")
+	}
+	return e.Engine.Index(ctx, course)
+}
+
+func (e *flakyEngine) Attempts() []time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]time.Time(nil), e.attempts...)
+}
+
+// startWorker publica los eventos y ejecuta el worker hasta que termine la prueba
+func startWorker(t *testing.T, engine search.Engine, events ...CourseEvent) *queue.MemoryBroker {
+	t.Helper()
+	broker := queue.NewMemoryBroker()
+	for _, event := range events {
+		if err := PublishCourseEvent(context.Background(), broker, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	worker := &IndexWorker{Broker: broker, Engine: engine, MaxAttempts: 3, Backoff: 5 * time.Millisecond}
+	go func() {
+		defer close(done)
+		worker.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return broker
+}
+
+// waitFor espera hasta que cond se cumpla o falla la prueba
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("tiempo agotado esperando %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func indexedIDs(t *testing.T, engine search.Engine) []string {
+	t.Helper()
+	var ids []string
+	err := engine.Documents(context.Background(), func(ref search.DocumentRef) error {
+		ids = append(ids, ref.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func courseEvent(eventType CourseEventType, title string) CourseEvent {
+	id := primitive.NewObjectID()
+	return CourseEvent{
+		Type:     eventType,
+		CourseID: id.Hex(),
+		Course:   &Course{Title: title, Level: "beginner"},
+	}
+}
+
+func TestIndexWorkerAppliesEvents(t *testing.T) {
+	engine := search.NewMemoryEngine()
+	created := courseEvent(CourseCreated, "Go desde cero")
+	deleted := courseEvent(CourseCreated, "Curso dado de baja")
+	broker := startWorker(t, engine, created, deleted, CourseEvent{Type: CourseDeleted, CourseID: deleted.CourseID})
+
+	waitFor(t, "que se procesen los eventos", func() bool {
+		ids := indexedIDs(t, engine)
+		return len(ids) == 1 && ids[0] == created.CourseID
+	})
+	if n := broker.Pending(CourseEventsDeadLetterQueue); n != 0 {
+		t.Fatalf("hay %d eventos en la cola de mensajes muertos, se esperaba ninguno", n)
+	}
+}
+
+func TestIndexWorkerRetriesWithBackoff(t *testing.T) {
+	engine := &flakyEngine{Engine: search.NewMemoryEngine(), failures: 2}
+	event := courseEvent(CourseUpdated, "Go avanzado")
+	broker := startWorker(t, engine, event)
+
+	waitFor(t, "que se indexe el curso", func() bool { return len(indexedIDs(t, engine)) == 1 })
+
+	attempts := engine.Attempts()
+	if len(attempts) != 3 {
+		t.Fatalf("se hicieron %d intentos, se esperaban 3", len(attempts))
+	}
+	// La espera entre intentos se duplica: 5ms y luego 10ms
+	for i, min := range []time.Duration{5 * time.Millisecond, 10 * time.Millisecond} {
+		if gap := attempts[i+1].Sub(attempts[i]); gap < min {
+			t.Errorf("espera antes del intento %d: %v, se esperaba al menos %v", i+2, gap, min)
+		}
+	}
+	if n := broker.Pending(CourseEventsDeadLetterQueue); n != 0 {
+		t.Fatalf("hay %d eventos en la cola de mensajes muertos, se esperaba ninguno", n)
+	}
+}
+
+func TestIndexWorkerDeadLettersAfterMaxAttempts(t *testing.T) {
+	engine := &flakyEngine{Engine: search.NewMemoryEngine(), failures: 10}
+	event := courseEvent(CourseCreated, "Go sin índice")
+	broker := startWorker(t, engine, event)
+
+	waitFor(t, "el evento en la cola de mensajes muertos", func() bool {
+		return broker.Pending(CourseEventsDeadLetterQueue) == 1
+	})
+	if n := len(engine.Attempts()); n != 3 {
+		t.Fatalf("se hicieron %d intentos, se esperaban 3", n)
+	}
+	if ids := indexedIDs(t, engine); len(ids) != 0 {
+		t.Fatalf("se indexaron %v, se esperaba ninguno", ids)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages, err := broker.Consume(ctx, CourseEventsDeadLetterQueue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed CourseEvent
+	if err := json.Unmarshal((<-messages).Body, &failed); err != nil {
+		t.Fatal(err)
+	}
+	if failed.CourseID != event.CourseID || failed.Error != "motor no disponible" {
+		t.Fatalf("evento en la cola de mensajes muertos: %+v", failed)
+	}
+}
+
+func TestIndexWorkerDeadLettersMalformedEvents(t *testing.T) {
+	engine := &flakyEngine{Engine: search.NewMemoryEngine()}
+	broker := queue.NewMemoryBroker()
+	worker := &IndexWorker{Broker: broker, Engine: engine, MaxAttempts: 3, Backoff: time.Millisecond}
+
+	if err := worker.handle(context.Background(), []byte("{no es json")); err != nil {
+		t.Fatal(err)
+	}
+	if n := broker.Pending(CourseEventsDeadLetterQueue); n != 1 {
+		t.Fatalf("hay %d eventos en la cola de mensajes muertos, se esperaba 1", n)
+	}
+	if n := len(engine.Attempts()); n != 0 {
+		t.Fatalf("se hicieron %d intentos, se esperaba ninguno", n)
+	}
+}
+
+// failingDeadLetters es un broker cuya cola de mensajes muertos no acepta mensajes
+type failingDeadLetters struct {
+	*queue.MemoryBroker
+}
+
+func (b failingDeadLetters) Publish(ctx context.Context, name string, body []byte) error {
+	if name == CourseEventsDeadLetterQueue {
+		return errors.New("cola no disponible")
+	}
+	return b.MemoryBroker.Publish(ctx, name, body)
+}
+
+func TestIndexWorkerBacksOffWhenDeadLetterFails(t *testing.T) {
+	broker := failingDeadLetters{queue.NewMemoryBroker()}
+	if err := broker.Publish(context.Background(), CourseEventsQueue, []byte("{no es json")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	worker := &IndexWorker{Broker: broker, Engine: search.NewMemoryEngine(), MaxAttempts: 1, Backoff: 20 * time.Millisecond}
+	start := time.Now()
+	worker.Run(ctx)
+
+	// Con esperas de 20, 40 y 80 ms solo entran unos pocos reintentos antes de cancelar
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("el worker terminó en %s sin esperar entre reintentos", elapsed)
+	}
+	if got := worker.requeueDelay(20); got != maxRequeueDelay {
+		t.Errorf("espera tras 20 fallas %s, se esperaba %s", got, maxRequeueDelay)
+	}
+}
@@ -0,0 +1,428 @@
+package courses
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryCourseRepository implementa CourseRepository en memoria para pruebas y desarrollo
+type MemoryCourseRepository struct {
+	mu        sync.RWMutex
+	courses   map[string]Course
+	order     []string
+	deletions []CourseDeletion
+}
+
+// NewMemoryCourseRepository crea un repositorio de cursos vacío
+func NewMemoryCourseRepository() *MemoryCourseRepository {
+	return &MemoryCourseRepository{courses: make(map[string]Course)}
+}
+
+func (r *MemoryCourseRepository) List(ctx context.Context) ([]Course, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	courses := make([]Course, 0, len(r.order))
+	for _, id := range r.order {
+		if course := r.courses[id]; course.ArchivedAt == nil {
+			courses = append(courses, course)
+		}
+	}
+	return courses, nil
+}
+
+func (r *MemoryCourseRepository) Each(ctx context.Context, fn func(Course) error) error {
+	courses, _ := r.List(ctx)
+	sort.Slice(courses, func(i, j int) bool { return courses[i].ID.Hex() < courses[j].ID.Hex() })
+	for _, course := range courses {
+		if err := fn(course); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryCourseRepository) Find(ctx context.Context, q CourseQuery) (CoursePage, error) {
+	all, _ := r.List(ctx)
+
+	matched := []Course{}
+	for _, course := range all {
+		if matchesCourseQuery(q, course) {
+			matched = append(matched, course)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return compareCourses(q, matched[i], matched[j]) < 0
+	})
+
+	page := CoursePage{Total: int64(len(matched)), Courses: []Course{}}
+	start := 0
+	if q.After != nil {
+		start = len(matched)
+		for i, course := range matched {
+			if compareToCursor(q, course, *q.After) > 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	end := min(start+q.Limit, len(matched))
+	page.Courses = append(page.Courses, matched[start:end]...)
+	if end < len(matched) {
+		page.NextCursor = cursorFor(q, matched[end-1]).Encode()
+	}
+	return page, nil
+}
+
+func matchesCourseQuery(q CourseQuery, course Course) bool {
+	switch {
+	case q.Level != "" && course.Level != q.Level,
+		q.Availability != nil && course.Availability != *q.Availability,
+		q.InstructorID != 0 && course.InstructorID != q.InstructorID,
+		q.Instructor != "" && !strings.EqualFold(course.Instructor, q.Instructor),
+		q.MinDuration != nil && course.Duration < *q.MinDuration,
+		q.MaxDuration != nil && course.Duration > *q.MaxDuration,
+		q.Category != "" && !slices.Contains(course.CategoryPath, q.Category):
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !slices.Contains(course.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareCourses compara dos cursos en el orden pedido, desempatando por ID
+func compareCourses(q CourseQuery, a, b Course) int {
+	return compareToCursor(q, a, cursorFor(q, b))
+}
+
+// compareToCursor indica si el curso va antes (<0) o después (>0) del cursor en el orden pedido
+func compareToCursor(q CourseQuery, course Course, c Cursor) int {
+	var result int
+	switch q.Sort {
+	case SortByTitle:
+		result = strings.Compare(course.Title, c.Title)
+	case SortByDuration:
+		result = course.Duration - c.Duration
+	}
+	if result == 0 {
+		result = strings.Compare(course.ID.Hex(), c.ID)
+	}
+	if q.Desc {
+		result = -result
+	}
+	return result
+}
+
+func (r *MemoryCourseRepository) GetByID(ctx context.Context, id string) (Course, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	course, ok := r.courses[id]
+	if !ok {
+		return Course{}, ErrCourseNotFound
+	}
+	return course, nil
+}
+
+func (r *MemoryCourseRepository) GetByIDs(ctx context.Context, ids []string) ([]Course, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var courses []Course
+	for _, id := range ids {
+		if course, ok := r.courses[id]; ok {
+			courses = append(courses, course)
+		}
+	}
+	return courses, nil
+}
+
+func (r *MemoryCourseRepository) Create(ctx context.Context, course *Course) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	course.ID = primitive.NewObjectID()
+	id := course.ID.Hex()
+	r.courses[id] = *course
+	r.order = append(r.order, id)
+	return nil
+}
+
+func (r *MemoryCourseRepository) Update(ctx context.Context, id string, course Course) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.courses[id]
+	if !ok {
+		return ErrCourseNotFound
+	}
+	// Igual que en MongoDB solo cambian los campos editables; sin capacity no hay límite de cupo
+	course.ID = existing.ID
+	course.Enrolled = existing.Enrolled
+	course.ArchivedAt = existing.ArchivedAt
+	course.Modules, course.ContentVersion = existing.Modules, existing.ContentVersion
+	course.RatingAverage, course.RatingCount = existing.RatingAverage, existing.RatingCount
+	course.CategoryID, course.CategoryPath, course.Tags = existing.CategoryID, existing.CategoryPath, existing.Tags
+	r.courses[id] = course
+	return nil
+}
+
+func (r *MemoryCourseRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.courses[id]; !ok {
+		return ErrCourseNotFound
+	}
+	delete(r.courses, id)
+	for i, existing := range r.order {
+		if existing == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *MemoryCourseRepository) Archive(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	course, ok := r.courses[id]
+	if !ok {
+		return ErrCourseNotFound
+	}
+	course.ArchivedAt = &at
+	r.courses[id] = course
+	return nil
+}
+
+func (r *MemoryCourseRepository) ReserveSeat(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	course, ok := r.courses[id]
+	if !ok {
+		return false, ErrCourseNotFound
+	}
+	if course.Capacity != nil && course.Enrolled >= *course.Capacity {
+		return false, nil
+	}
+	course.Enrolled++
+	r.courses[id] = course
+	return true, nil
+}
+
+func (r *MemoryCourseRepository) ReleaseSeat(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	course, ok := r.courses[id]
+	if !ok {
+		return ErrCourseNotFound
+	}
+	if course.Enrolled > 0 {
+		course.Enrolled--
+		r.courses[id] = course
+	}
+	return nil
+}
+
+func (r *MemoryCourseRepository) SetModules(ctx context.Context, id string, modules []Module, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	course, ok := r.courses[id]
+	if !ok {
+		return ErrCourseNotFound
+	}
+	if course.ContentVersion != version {
+		return ErrContentChanged
+	}
+	course.Modules = modules
+	course.ContentVersion++
+	r.courses[id] = course
+	return nil
+}
+
+func (r *MemoryCourseRepository) SetRating(ctx context.Context, id string, average float64, count int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	course, ok := r.courses[id]
+	if !ok {
+		return ErrCourseNotFound
+	}
+	course.RatingAverage, course.RatingCount = average, count
+	r.courses[id] = course
+	return nil
+}
+
+func (r *MemoryCourseRepository) SetClassification(ctx context.Context, id, categoryID string, categoryPath, tags []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	course, ok := r.courses[id]
+	if !ok {
+		return ErrCourseNotFound
+	}
+	course.CategoryID = categoryID
+	course.CategoryPath = append([]string(nil), categoryPath...)
+	course.Tags = append([]string(nil), tags...)
+	r.courses[id] = course
+	return nil
+}
+
+func (r *MemoryCourseRepository) ReplaceTag(ctx context.Context, from, to string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []string
+	for _, id := range r.order {
+		course := r.courses[id]
+		if !slices.Contains(course.Tags, from) {
+			continue
+		}
+		tags := make([]string, 0, len(course.Tags))
+		for _, tag := range course.Tags {
+			if tag != from && tag != to {
+				tags = append(tags, tag)
+			}
+		}
+		if to != "" {
+			tags = append(tags, to)
+		}
+		course.Tags = tags
+		r.courses[id] = course
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (r *MemoryCourseRepository) RecordDeletion(ctx context.Context, deletion *CourseDeletion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deletion.ID = primitive.NewObjectID()
+	r.deletions = append(r.deletions, *deletion)
+	return nil
+}
+
+// MemoryEnrollmentRepository implementa EnrollmentRepository en memoria para pruebas y desarrollo
+type MemoryEnrollmentRepository struct {
+	mu          sync.RWMutex
+	enrollments []Enrollment
+}
+
+// NewMemoryEnrollmentRepository crea un repositorio de inscripciones vacío
+func NewMemoryEnrollmentRepository() *MemoryEnrollmentRepository {
+	return &MemoryEnrollmentRepository{}
+}
+
+func (r *MemoryEnrollmentRepository) Create(ctx context.Context, enrollment Enrollment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.enrollments {
+		if existing.UserID == enrollment.UserID && existing.CourseID == enrollment.CourseID {
+			return ErrEnrollmentExists
+		}
+	}
+	r.enrollments = append(r.enrollments, enrollment)
+	return nil
+}
+
+func (r *MemoryEnrollmentRepository) Get(ctx context.Context, userID int, courseID string) (Enrollment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, enrollment := range r.enrollments {
+		if enrollment.UserID == userID && enrollment.CourseID == courseID {
+			return enrollment, nil
+		}
+	}
+	return Enrollment{}, ErrEnrollmentNotFound
+}
+
+// NextPending recorre en orden de inserción, que coincide con el de llegada
+func (r *MemoryEnrollmentRepository) NextPending(ctx context.Context, courseID string) (Enrollment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, enrollment := range r.enrollments {
+		if enrollment.CourseID == courseID && enrollment.Status == EnrollmentPending {
+			return enrollment, nil
+		}
+	}
+	return Enrollment{}, ErrEnrollmentNotFound
+}
+
+func (r *MemoryEnrollmentRepository) Save(ctx context.Context, enrollment Enrollment, expected string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.enrollments {
+		if existing.UserID == enrollment.UserID && existing.CourseID == enrollment.CourseID {
+			if existing.Status != expected || existing.Version != enrollment.Version {
+				return ErrEnrollmentChanged
+			}
+			enrollment.Version++
+			r.enrollments[i] = enrollment
+			return nil
+		}
+	}
+	return ErrEnrollmentChanged
+}
+
+func (r *MemoryEnrollmentRepository) ListByUser(ctx context.Context, userID int) ([]Enrollment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var enrollments []Enrollment
+	for _, enrollment := range r.enrollments {
+		if enrollment.UserID == userID {
+			enrollments = append(enrollments, enrollment)
+		}
+	}
+	return enrollments, nil
+}
+
+func (r *MemoryEnrollmentRepository) ExpireByCourse(ctx context.Context, courseID string, at time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired int64
+	for i := range r.enrollments {
+		enrollment := &r.enrollments[i]
+		if enrollment.CourseID == courseID && enrollment.transition(EnrollmentExpired, at) == nil {
+			enrollment.Version++
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func (r *MemoryEnrollmentRepository) DeleteByCourse(ctx context.Context, courseID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.enrollments[:0]
+	for _, enrollment := range r.enrollments {
+		if enrollment.CourseID != courseID {
+			kept = append(kept, enrollment)
+		}
+	}
+	deleted := int64(len(r.enrollments) - len(kept))
+	r.enrollments = kept
+	return deleted, nil
+}
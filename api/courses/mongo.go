@@ -0,0 +1,560 @@
+package courses
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCourseRepository implementa CourseRepository sobre la colección courses
+type MongoCourseRepository struct {
+	collection *mongo.Collection
+	// deletions guarda el registro de bajas de cursos
+	deletions *mongo.Collection
+}
+
+// NewMongoCourseRepository crea un repositorio de cursos sobre la base dada
+func NewMongoCourseRepository(db *mongo.Database) *MongoCourseRepository {
+	return &MongoCourseRepository{
+		collection: db.Collection("courses"),
+		deletions:  db.Collection("course_deletions"),
+	}
+}
+
+// notArchived selecciona los cursos que no fueron archivados
+var notArchived = bson.M{"archived_at": bson.M{"$exists": false}}
+
+func (r *MongoCourseRepository) List(ctx context.Context) ([]Course, error) {
+	cursor, err := r.collection.Find(ctx, notArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var courses []Course
+	for cursor.Next(ctx) {
+		var course Course
+		if err := cursor.Decode(&course); err != nil {
+			return nil, err
+		}
+		courses = append(courses, course)
+	}
+	return courses, cursor.Err()
+}
+
+func (r *MongoCourseRepository) Each(ctx context.Context, fn func(Course) error) error {
+	cursor, err := r.collection.Find(ctx, notArchived, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var course Course
+		if err := cursor.Decode(&course); err != nil {
+			return err
+		}
+		if err := fn(course); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (r *MongoCourseRepository) Find(ctx context.Context, q CourseQuery) (CoursePage, error) {
+	var page CoursePage
+	filter := courseFilter(q)
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return page, err
+	}
+	page.Total = total
+
+	if q.After != nil {
+		after, err := afterCursorFilter(q)
+		if err != nil {
+			return page, err
+		}
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	direction := 1
+	if q.Desc {
+		direction = -1
+	}
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if field := sortField(q.Sort); field != "_id" {
+		sort = append(bson.D{{Key: field, Value: direction}}, sort...)
+	}
+
+	// Se pide un curso extra para saber si hay una página siguiente
+	opts := options.Find().SetSort(sort).SetLimit(int64(q.Limit + 1))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return page, err
+	}
+	defer cursor.Close(ctx)
+
+	page.Courses = []Course{}
+	if err := cursor.All(ctx, &page.Courses); err != nil {
+		return page, err
+	}
+	if len(page.Courses) > q.Limit {
+		page.Courses = page.Courses[:q.Limit]
+		page.NextCursor = cursorFor(q, page.Courses[q.Limit-1]).Encode()
+	}
+	return page, nil
+}
+
+// sortField traduce el orden pedido al campo del documento
+func sortField(sort string) string {
+	switch sort {
+	case SortByTitle:
+		return "title"
+	case SortByDuration:
+		return "duration"
+	default:
+		return "_id"
+	}
+}
+
+// courseFilter arma el filtro de MongoDB para los filtros de la consulta
+func courseFilter(q CourseQuery) bson.M {
+	filter := bson.M{"archived_at": bson.M{"$exists": false}}
+	if q.Level != "" {
+		filter["level"] = q.Level
+	}
+	if q.Availability != nil {
+		filter["availability"] = *q.Availability
+	}
+	if q.InstructorID != 0 {
+		filter["instructor_id"] = q.InstructorID
+	}
+	if q.Instructor != "" {
+		filter["instructor"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(q.Instructor) + "$", Options: "i"}
+	}
+	if q.MinDuration != nil || q.MaxDuration != nil {
+		duration := bson.M{}
+		if q.MinDuration != nil {
+			duration["$gte"] = *q.MinDuration
+		}
+		if q.MaxDuration != nil {
+			duration["$lte"] = *q.MaxDuration
+		}
+		filter["duration"] = duration
+	}
+	if q.Category != "" {
+		filter["category_path"] = q.Category
+	}
+	if len(q.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": q.Tags}
+	}
+	return filter
+}
+
+// afterCursorFilter selecciona los cursos posteriores al cursor en el orden pedido
+func afterCursorFilter(q CourseQuery) (bson.M, error) {
+	id, err := q.After.objectID()
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	op := "$gt"
+	if q.Desc {
+		op = "$lt"
+	}
+
+	var value interface{}
+	switch q.Sort {
+	case SortByTitle:
+		value = q.After.Title
+	case SortByDuration:
+		value = q.After.Duration
+	default:
+		return bson.M{"_id": bson.M{op: id}}, nil
+	}
+
+	// El _id desempata los cursos con el mismo valor en el campo de orden
+	field := sortField(q.Sort)
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: value}},
+		bson.M{field: value, "_id": bson.M{op: id}},
+	}}, nil
+}
+
+func (r *MongoCourseRepository) GetByID(ctx context.Context, id string) (Course, error) {
+	var course Course
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return course, ErrCourseNotFound
+	}
+
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&course)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return course, ErrCourseNotFound
+	}
+	return course, err
+}
+
+func (r *MongoCourseRepository) GetByIDs(ctx context.Context, ids []string) ([]Course, error) {
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": objectIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var courses []Course
+	if err := cursor.All(ctx, &courses); err != nil {
+		return nil, err
+	}
+	return courses, nil
+}
+
+func (r *MongoCourseRepository) Create(ctx context.Context, course *Course) error {
+	course.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, course)
+	return err
+}
+
+func (r *MongoCourseRepository) Update(ctx context.Context, id string, course Course) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrCourseNotFound
+	}
+
+	// Solo se modifican los campos editables; el ID, los lugares ocupados, el archivado, el
+	// contenido, las calificaciones y la clasificación tienen sus propias operaciones
+	set := bson.M{
+		"title":         course.Title,
+		"description":   course.Description,
+		"instructor":    course.Instructor,
+		"instructor_id": course.InstructorID,
+		"duration":      course.Duration,
+		"level":         course.Level,
+		"availability":  course.Availability,
+	}
+	update := bson.M{"$set": set}
+	// Sin capacity el curso pasa a no tener límite de cupo
+	if course.Capacity != nil {
+		set["capacity"] = *course.Capacity
+	} else {
+		update["$unset"] = bson.M{"capacity": ""}
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCourseNotFound
+	}
+	return nil
+}
+
+func (r *MongoCourseRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrCourseNotFound
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrCourseNotFound
+	}
+	return nil
+}
+
+func (r *MongoCourseRepository) Archive(ctx context.Context, id string, at time.Time) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrCourseNotFound
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"archived_at": at}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCourseNotFound
+	}
+	return nil
+}
+
+func (r *MongoCourseRepository) ReserveSeat(ctx context.Context, id string) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, ErrCourseNotFound
+	}
+
+	// El filtro y el incremento se aplican de forma atómica sobre el documento
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"_id": objectID,
+		"$or": bson.A{
+			bson.M{"capacity": bson.M{"$exists": false}},
+			bson.M{"$expr": bson.M{"$lt": bson.A{bson.M{"$ifNull": bson.A{"$enrolled", 0}}, "$capacity"}}},
+		},
+	}, bson.M{"$inc": bson.M{"enrolled": 1}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoCourseRepository) ReleaseSeat(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrCourseNotFound
+	}
+
+	_, err = r.collection.UpdateOne(ctx,
+		bson.M{"_id": objectID, "enrolled": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"enrolled": -1}},
+	)
+	return err
+}
+
+func (r *MongoCourseRepository) SetModules(ctx context.Context, id string, modules []Module, version int) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrCourseNotFound
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objectID, "content_version": versionFilter(version)},
+		bson.M{"$set": bson.M{"modules": modules}, "$inc": bson.M{"content_version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		// Sin coincidencias el curso no existe o alguien guardó otra versión del contenido
+		n, err := r.collection.CountDocuments(ctx, bson.M{"_id": objectID})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrCourseNotFound
+		}
+		return ErrContentChanged
+	}
+	return nil
+}
+
+// versionFilter filtra por la versión dada; los documentos guardados antes de que existiera el
+// campo de versión cuentan como versión 0
+func versionFilter(version int) any {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
+
+func (r *MongoCourseRepository) SetRating(ctx context.Context, id string, average float64, count int) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrCourseNotFound
+	}
+
+	update := bson.M{"$set": bson.M{"rating_average": average, "rating_count": count}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCourseNotFound
+	}
+	return nil
+}
+
+func (r *MongoCourseRepository) SetClassification(ctx context.Context, id, categoryID string, categoryPath, tags []string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrCourseNotFound
+	}
+
+	// Las listas vacías se guardan como arreglos para cumplir el validador de la colección
+	if categoryPath == nil {
+		categoryPath = []string{}
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	update := bson.M{"$set": bson.M{
+		"category_id":   categoryID,
+		"category_path": categoryPath,
+		"tags":          tags,
+	}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCourseNotFound
+	}
+	return nil
+}
+
+// ReplaceTag primero agrega to y después quita from, porque MongoDB no permite $addToSet y $pull
+// sobre el mismo campo en una sola actualización
+func (r *MongoCourseRepository) ReplaceTag(ctx context.Context, from, to string) ([]string, error) {
+	filter := bson.M{"tags": from}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	if to != "" {
+		if _, err := r.collection.UpdateMany(ctx, filter, bson.M{"$addToSet": bson.M{"tags": to}}); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := r.collection.UpdateMany(ctx, filter, bson.M{"$pull": bson.M{"tags": from}}); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID.Hex())
+	}
+	return ids, nil
+}
+
+func (r *MongoCourseRepository) RecordDeletion(ctx context.Context, deletion *CourseDeletion) error {
+	deletion.ID = primitive.NewObjectID()
+	_, err := r.deletions.InsertOne(ctx, deletion)
+	return err
+}
+
+// MongoEnrollmentRepository implementa EnrollmentRepository sobre la colección enrollments
+type MongoEnrollmentRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoEnrollmentRepository crea un repositorio de inscripciones sobre la base dada
+func NewMongoEnrollmentRepository(db *mongo.Database) *MongoEnrollmentRepository {
+	return &MongoEnrollmentRepository{collection: db.Collection("enrollments")}
+}
+
+func (r *MongoEnrollmentRepository) Create(ctx context.Context, enrollment Enrollment) error {
+	// El upsert solo inserta si el usuario no está inscrito, aunque falte el índice único de db
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"user_id": enrollment.UserID, "course_id": enrollment.CourseID},
+		bson.M{"$setOnInsert": enrollment},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrEnrollmentExists
+	} else if err != nil {
+		return err
+	}
+	if result.UpsertedCount == 0 {
+		return ErrEnrollmentExists
+	}
+	return nil
+}
+
+func (r *MongoEnrollmentRepository) Get(ctx context.Context, userID int, courseID string) (Enrollment, error) {
+	var enrollment Enrollment
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID, "course_id": courseID}).Decode(&enrollment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return enrollment, ErrEnrollmentNotFound
+	}
+	return enrollment, err
+}
+
+func (r *MongoEnrollmentRepository) NextPending(ctx context.Context, courseID string) (Enrollment, error) {
+	var enrollment Enrollment
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	err := r.collection.FindOne(ctx, bson.M{"course_id": courseID, "status": EnrollmentPending}, opts).Decode(&enrollment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return enrollment, ErrEnrollmentNotFound
+	}
+	return enrollment, err
+}
+
+func (r *MongoEnrollmentRepository) Save(ctx context.Context, enrollment Enrollment, expected string) error {
+	filter := bson.M{
+		"user_id":   enrollment.UserID,
+		"course_id": enrollment.CourseID,
+		"status":    expected,
+		"version":   versionFilter(enrollment.Version),
+	}
+	enrollment.Version++
+	result, err := r.collection.ReplaceOne(ctx, filter, enrollment)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrEnrollmentChanged
+	}
+	return nil
+}
+
+func (r *MongoEnrollmentRepository) ListByUser(ctx context.Context, userID int) ([]Enrollment, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var enrollments []Enrollment
+	for cursor.Next(ctx) {
+		var enrollment Enrollment
+		if err := cursor.Decode(&enrollment); err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+	return enrollments, cursor.Err()
+}
+
+func (r *MongoEnrollmentRepository) ExpireByCourse(ctx context.Context, courseID string, at time.Time) (int64, error) {
+	// La actualización con pipeline permite registrar en el historial el estado anterior de cada una
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"course_id": courseID, "status": bson.M{"$in": bson.A{EnrollmentPending, EnrollmentActive}}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"history": bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$history", bson.A{}}},
+				bson.A{bson.M{"from": "$status", "to": EnrollmentExpired, "at": at}},
+			}},
+			"status":     EnrollmentExpired,
+			"updated_at": at,
+			"version":    bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+		}}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *MongoEnrollmentRepository) DeleteByCourse(ctx context.Context, courseID string) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"course_id": courseID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,196 @@
+package courses
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Campos por los que se pueden ordenar los cursos
+const (
+	SortByTitle    = "title"
+	SortByDuration = "duration"
+	// SortByCreated ordena por fecha de creación usando la marca de tiempo del ObjectID
+	SortByCreated = "created"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// ErrInvalidCursor se devuelve cuando el cursor de paginación no es válido
+var ErrInvalidCursor = errors.New("cursor inválido")
+
+// CourseQuery describe los filtros, el orden y la página pedidos en GET /courses
+type CourseQuery struct {
+	Level        string
+	Availability *bool
+	InstructorID int
+	// Instructor filtra por nombre exacto, sin distinguir mayúsculas
+	Instructor  string
+	MinDuration *int
+	MaxDuration *int
+	// Category incluye los cursos de sus subcategorías; Tags exige todas las etiquetas
+	Category string
+	Tags     []string
+
+	Sort  string
+	Desc  bool
+	Limit int
+	// After es el cursor devuelto como next_cursor por la página anterior
+	After *Cursor
+}
+
+// CoursePage es una página de cursos junto con el total que cumple los filtros
+type CoursePage struct {
+	Courses    []Course `json:"data"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	Total      int64    `json:"total"`
+}
+
+// Cursor es la posición del último curso de una página dentro del orden pedido
+type Cursor struct {
+	Sort     string `json:"s"`
+	Desc     bool   `json:"o,omitempty"`
+	Title    string `json:"t,omitempty"`
+	Duration int    `json:"d,omitempty"`
+	ID       string `json:"id"`
+}
+
+// cursorFor construye el cursor que apunta al curso dado
+func cursorFor(q CourseQuery, course Course) Cursor {
+	c := Cursor{Sort: q.Sort, Desc: q.Desc, ID: course.ID.Hex()}
+	switch q.Sort {
+	case SortByTitle:
+		c.Title = course.Title
+	case SortByDuration:
+		c.Duration = course.Duration
+	}
+	return c
+}
+
+// Encode serializa el cursor como una cadena opaca
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// objectID devuelve el ObjectID del último curso de la página
+func (c Cursor) objectID() (primitive.ObjectID, error) {
+	return primitive.ObjectIDFromHex(c.ID)
+}
+
+func decodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || !primitive.IsValidObjectID(c.ID) {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// invalidParam crea el error de validación del parámetro de consulta name con el mensaje messageID
+func invalidParam(name, messageID string, args ...any) error {
+	return apierror.Field(name, apierror.FieldInvalid, messageID, args...)
+}
+
+// ParseCourseQuery interpreta los parámetros limit, after, sort, order, level, availability,
+// instructor, min_duration, max_duration, category y tag (repetible, con el slug de la etiqueta)
+func ParseCourseQuery(values url.Values) (CourseQuery, error) {
+	q := CourseQuery{
+		Level: values.Get("level"),
+		Sort:  SortByCreated,
+		Limit: defaultPageLimit,
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return q, invalidParam("limit", "validation.positive_integer", "limit")
+		}
+		q.Limit = min(limit, maxPageLimit)
+	}
+
+	if v := values.Get("sort"); v != "" {
+		switch v {
+		case SortByTitle, SortByDuration, SortByCreated:
+			q.Sort = v
+		default:
+			return q, invalidParam("sort", "courses.query_sort", SortByTitle, SortByDuration, SortByCreated)
+		}
+	}
+
+	switch strings.ToLower(values.Get("order")) {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return q, invalidParam("order", "courses.query_order")
+	}
+
+	if v := values.Get("availability"); v != "" {
+		available, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, invalidParam("availability", "validation.boolean", "availability")
+		}
+		q.Availability = &available
+	}
+
+	// instructor acepta el ID del instructor o su nombre
+	if v := values.Get("instructor"); v != "" {
+		if id, err := strconv.Atoi(v); err == nil {
+			q.InstructorID = id
+		} else {
+			q.Instructor = v
+		}
+	}
+
+	for name, target := range map[string]**int{
+		"min_duration": &q.MinDuration,
+		"max_duration": &q.MaxDuration,
+	} {
+		if v := values.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return q, invalidParam(name, "validation.non_negative_integer", name)
+			}
+			*target = &n
+		}
+	}
+	if q.MinDuration != nil && q.MaxDuration != nil && *q.MinDuration > *q.MaxDuration {
+		return q, invalidParam("min_duration", "courses.query_duration_range")
+	}
+
+	if v := values.Get("category"); v != "" {
+		if !primitive.IsValidObjectID(v) {
+			return q, invalidParam("category", "courses.query_category")
+		}
+		q.Category = v
+	}
+	for _, tag := range values["tag"] {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			q.Tags = append(q.Tags, tag)
+		}
+	}
+
+	if v := values.Get("after"); v != "" {
+		cursor, err := decodeCursor(v)
+		// Un cursor solo es válido para el mismo orden con el que se generó
+		if err != nil || cursor.Sort != q.Sort || cursor.Desc != q.Desc {
+			return q, invalidParam("after", "courses.query_cursor")
+		}
+		q.After = cursor
+	}
+
+	return q, nil
+}
@@ -0,0 +1,226 @@
+package courses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/search"
+)
+
+const (
+	// maxReportedIDs limita la cantidad de IDs listados por categoría en el reporte
+	maxReportedIDs = 100
+	// repairBatchSize es la cantidad de cursos que se vuelven a leer juntos para reindexarlos
+	repairBatchSize = 200
+)
+
+// errStopStream corta el recorrido del índice cuando la reconciliación termina antes
+var errStopStream = errors.New("recorrido del índice cancelado")
+
+// ReconcileDiff agrupa los cursos con un mismo tipo de diferencia
+type ReconcileDiff struct {
+	Count int      `json:"count"`
+	IDs   []string `json:"ids,omitempty"`
+}
+
+func (d *ReconcileDiff) add(id string) {
+	d.Count++
+	if len(d.IDs) < maxReportedIDs {
+		d.IDs = append(d.IDs, id)
+	}
+}
+
+// ReconcileReport es el resultado de comparar MongoDB con el índice de búsqueda
+type ReconcileReport struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Courses es la cantidad de cursos en MongoDB e Indexed la de documentos en el índice
+	Courses int `json:"courses"`
+	Indexed int `json:"indexed"`
+	// Missing son cursos sin documento, Stale documentos con otro contenido y Orphaned
+	// documentos sin curso en MongoDB
+	Missing  ReconcileDiff `json:"missing"`
+	Stale    ReconcileDiff `json:"stale"`
+	Orphaned ReconcileDiff `json:"orphaned"`
+	Repaired bool          `json:"repaired"`
+	// RepairErrors cuenta los documentos que no se pudieron corregir
+	RepairErrors int    `json:"repair_errors,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Reconciler detecta y opcionalmente corrige las diferencias entre MongoDB y el índice
+type Reconciler struct {
+	courses CourseRepository
+	engine  search.Engine
+
+	mu   sync.Mutex
+	last *ReconcileReport
+}
+
+// NewReconciler crea un reconciliador sobre el repositorio y el motor dados
+func NewReconciler(courses CourseRepository, engine search.Engine) *Reconciler {
+	return &Reconciler{courses: courses, engine: engine}
+}
+
+// Run compara ambos lados recorriéndolos ordenados por ID y, si repair es true, reindexa los
+// cursos faltantes o desactualizados y borra los documentos huérfanos
+func (rc *Reconciler) Run(ctx context.Context, repair bool) (ReconcileReport, error) {
+	report := ReconcileReport{StartedAt: time.Now().UTC()}
+
+	toIndex, orphaned, err := rc.diff(ctx, &report)
+	if err == nil && repair {
+		report.Repaired = true
+		report.RepairErrors += rc.reindex(ctx, toIndex)
+		for _, id := range orphaned {
+			if err := rc.engine.Delete(ctx, id); err != nil {
+				log.Println("Error al borrar documento huérfano", id, ":", err)
+				report.RepairErrors++
+			}
+		}
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	rc.mu.Lock()
+	rc.last = &report
+	rc.mu.Unlock()
+
+	log.Printf("Reconciliación: %d cursos, %d indexados, %d faltantes, %d desactualizados, %d huérfanos",
+		report.Courses, report.Indexed, report.Missing.Count, report.Stale.Count, report.Orphaned.Count)
+	return report, err
+}
+
+// reindex vuelve a leer los cursos por lotes y los indexa; devuelve cuántos no se pudieron corregir
+func (rc *Reconciler) reindex(ctx context.Context, ids []string) int {
+	failed := 0
+	for start := 0; start < len(ids); start += repairBatchSize {
+		batch := ids[start:min(start+repairBatchSize, len(ids))]
+		courses, err := rc.courses.GetByIDs(ctx, batch)
+		if err != nil {
+			log.Println("Error al leer los cursos a reindexar:", err)
+			failed += len(batch)
+			continue
+		}
+		for _, course := range courses {
+			// Un curso archivado desde la comparación ya no debe estar en el índice
+			if course.ArchivedAt != nil {
+				continue
+			}
+			if err := rc.engine.Index(ctx, course.SearchDocument()); err != nil {
+				log.Println("Error al reindexar curso", course.ID.Hex(), ":", err)
+				failed++
+			}
+		}
+	}
+	return failed
+}
+
+// diff une los dos recorridos ordenados y devuelve los IDs de los cursos a reindexar y de los
+// documentos huérfanos; solo guarda IDs para no retener los cursos completos
+func (rc *Reconciler) diff(ctx context.Context, report *ReconcileReport) ([]string, []string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// El índice se recorre en otra goroutine para avanzar ambos lados a la par
+	refs := make(chan search.DocumentRef, 256)
+	streamErr := make(chan error, 1)
+	go func() {
+		defer close(refs)
+		streamErr <- rc.engine.Documents(ctx, func(ref search.DocumentRef) error {
+			select {
+			case refs <- ref:
+				return nil
+			case <-ctx.Done():
+				return errStopStream
+			}
+		})
+	}()
+
+	var toIndex []string
+	var orphaned []string
+	next, ok := <-refs
+
+	err := rc.courses.Each(ctx, func(course Course) error {
+		report.Courses++
+		id := course.ID.Hex()
+
+		for ok && next.ID < id {
+			report.Indexed++
+			report.Orphaned.add(next.ID)
+			orphaned = append(orphaned, next.ID)
+			next, ok = <-refs
+		}
+
+		switch {
+		case !ok || next.ID != id:
+			report.Missing.add(id)
+			toIndex = append(toIndex, id)
+		default:
+			report.Indexed++
+			if next.ContentHash != course.SearchDocument().ContentHash {
+				report.Stale.add(id)
+				toIndex = append(toIndex, id)
+			}
+			next, ok = <-refs
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for ; ok; next, ok = <-refs {
+		report.Indexed++
+		report.Orphaned.add(next.ID)
+		orphaned = append(orphaned, next.ID)
+	}
+	if err := <-streamErr; err != nil {
+		return nil, nil, err
+	}
+	return toIndex, orphaned, nil
+}
+
+// Schedule ejecuta la reconciliación cada interval hasta que se cancele ctx
+func (rc *Reconciler) Schedule(ctx context.Context, interval time.Duration, repair bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rc.Run(ctx, repair)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// LastReport devuelve el resultado de la última reconciliación, si hubo alguna
+func (rc *Reconciler) LastReport() (ReconcileReport, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.last == nil {
+		return ReconcileReport{}, false
+	}
+	return *rc.last, true
+}
+
+// GetLastReconcile maneja GET /admin/reconcile
+func (rc *Reconciler) GetLastReconcile(w http.ResponseWriter, r *http.Request) {
+	report, ok := rc.LastReport()
+	if !ok {
+		apierror.Write(w, r, apierror.New(http.StatusNotFound, "reconcile_not_found", "courses.reconcile_not_found"))
+		return
+	}
+	json.NewEncoder(w).Encode(report)
+}
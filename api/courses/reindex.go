@@ -0,0 +1,130 @@
+package courses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/search"
+	"github.com/hugodiazo/arq-soft-2/i18n"
+)
+
+// ErrReindexRunning se devuelve cuando ya hay una reindexación en curso
+var ErrReindexRunning = errors.New("ya hay una reindexación en curso")
+
+// ReindexStatus describe la última reindexación lanzada
+type ReindexStatus struct {
+	Running    bool       `json:"running"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Courses    int        `json:"courses"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Reindexer reconstruye el índice de búsqueda completo a partir de los cursos de MongoDB.
+// Los eventos que el indexador aplique mientras se construye el índice nuevo pueden perderse
+// al cambiar de índice; la reconciliación los corrige.
+type Reindexer struct {
+	courses CourseRepository
+	engine  search.Engine
+
+	mu     sync.Mutex
+	status ReindexStatus
+}
+
+// NewReindexer crea un reindexador sobre el repositorio y el motor dados
+func NewReindexer(courses CourseRepository, engine search.Engine) *Reindexer {
+	return &Reindexer{courses: courses, engine: engine}
+}
+
+// Run reindexa todos los cursos y devuelve cuántos se indexaron; no admite ejecuciones simultáneas
+func (ri *Reindexer) Run(ctx context.Context) (int, error) {
+	started, ok := ri.begin()
+	if !ok {
+		return 0, ErrReindexRunning
+	}
+	return ri.finish(ctx, started)
+}
+
+// begin marca el inicio de una reindexación; devuelve false si ya hay una en curso
+func (ri *Reindexer) begin() (time.Time, bool) {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+
+	if ri.status.Running {
+		return time.Time{}, false
+	}
+	started := time.Now().UTC()
+	ri.status = ReindexStatus{Running: true, StartedAt: &started}
+	return started, true
+}
+
+// finish ejecuta la reindexación iniciada por begin y guarda su resultado
+func (ri *Reindexer) finish(ctx context.Context, started time.Time) (int, error) {
+	count, err := ri.reindex(ctx)
+
+	finished := time.Now().UTC()
+	ri.mu.Lock()
+	ri.status.Running = false
+	ri.status.FinishedAt = &finished
+	ri.status.Courses = count
+	if err != nil {
+		ri.status.Error = err.Error()
+	}
+	ri.mu.Unlock()
+
+	if err != nil {
+		log.Println("Error al reindexar cursos:", err)
+	} else {
+		log.Printf("Se reindexaron %d cursos en %s", count, finished.Sub(started).Round(time.Millisecond))
+	}
+	return count, err
+}
+
+// reindex recorre los cursos con Each para no cargarlos todos en memoria; el motor los envía
+// en lotes al índice nuevo
+func (ri *Reindexer) reindex(ctx context.Context) (int, error) {
+	count := 0
+	err := ri.engine.Reindex(ctx, func(yield func(search.Course) error) error {
+		return ri.courses.Each(ctx, func(course Course) error {
+			count++
+			return yield(course.SearchDocument())
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Status devuelve el estado de la última reindexación
+func (ri *Reindexer) Status() ReindexStatus {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	return ri.status
+}
+
+// StartReindex maneja POST /admin/reindex: lanza la reindexación en segundo plano
+func (ri *Reindexer) StartReindex(w http.ResponseWriter, r *http.Request) {
+	started, ok := ri.begin()
+	if !ok {
+		apierror.Write(w, r, apierror.New(http.StatusConflict, "reindex_running", "courses.reindex_running"))
+		return
+	}
+
+	// La reindexación sigue aunque se corte la solicitud
+	go ri.finish(context.Background(), started)
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": i18n.Tr(r.Context(), "courses.reindex_started")})
+}
+
+// GetReindexStatus maneja GET /admin/reindex
+func (ri *Reindexer) GetReindexStatus(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(ri.Status())
+}
@@ -0,0 +1,73 @@
+package courses
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrCourseNotFound se devuelve cuando no existe el curso buscado
+	ErrCourseNotFound = errors.New("curso no encontrado")
+	// ErrEnrollmentNotFound se devuelve cuando no existe la inscripción buscada
+	ErrEnrollmentNotFound = errors.New("inscripción no encontrada")
+	// ErrEnrollmentExists se devuelve cuando el usuario ya está inscrito en el curso
+	ErrEnrollmentExists = errors.New("el usuario ya está inscrito en el curso")
+	// ErrEnrollmentChanged se devuelve cuando la inscripción cambió antes de guardarla
+	ErrEnrollmentChanged = errors.New("la inscripción cambió, volver a intentar")
+	// ErrContentChanged se devuelve cuando el contenido del curso cambió antes de guardarlo
+	ErrContentChanged = errors.New("el contenido del curso cambió, volver a intentar")
+)
+
+// CourseRepository define el acceso a los cursos persistidos
+type CourseRepository interface {
+	List(ctx context.Context) ([]Course, error)
+	// Each recorre los cursos no archivados ordenados por ID sin cargarlos todos en memoria
+	Each(ctx context.Context, fn func(Course) error) error
+	// Find devuelve una página de cursos filtrada y ordenada según la consulta
+	Find(ctx context.Context, q CourseQuery) (CoursePage, error)
+	GetByID(ctx context.Context, id string) (Course, error)
+	// GetByIDs devuelve los cursos que existan entre los IDs dados, incluidos los archivados
+	GetByIDs(ctx context.Context, ids []string) ([]Course, error)
+	// Create guarda el curso y completa su ID
+	Create(ctx context.Context, course *Course) error
+	// Update reemplaza los campos editables del curso; sin Capacity el curso queda sin límite de cupo
+	Update(ctx context.Context, id string, course Course) error
+	Delete(ctx context.Context, id string) error
+	// Archive marca el curso como archivado; List y Find dejan de devolverlo
+	Archive(ctx context.Context, id string, at time.Time) error
+	// ReserveSeat ocupa un lugar del curso si tiene cupo; devuelve false si está lleno.
+	// Los cursos sin Capacity siempre tienen lugar.
+	ReserveSeat(ctx context.Context, id string) (bool, error)
+	// ReleaseSeat libera un lugar ocupado con ReserveSeat
+	ReleaseSeat(ctx context.Context, id string) error
+	// SetModules reemplaza el contenido del curso solo si ContentVersion sigue siendo version y la
+	// incrementa; si no devuelve ErrContentChanged. Update no modifica el contenido.
+	SetModules(ctx context.Context, id string, modules []Module, version int) error
+	// SetRating guarda el promedio y la cantidad de reseñas visibles del curso
+	SetRating(ctx context.Context, id string, average float64, count int) error
+	// SetClassification guarda la categoría, su ruta desde la raíz y las etiquetas del curso
+	SetClassification(ctx context.Context, id, categoryID string, categoryPath, tags []string) error
+	// ReplaceTag cambia la etiqueta from por to en todos los cursos, incluidos los archivados, y
+	// devuelve los IDs de los cursos modificados; si to está vacío la quita
+	ReplaceTag(ctx context.Context, from, to string) ([]string, error)
+	// RecordDeletion guarda el registro de una baja de curso y completa su ID
+	RecordDeletion(ctx context.Context, deletion *CourseDeletion) error
+}
+
+// EnrollmentRepository define el acceso a las inscripciones de usuarios en cursos
+type EnrollmentRepository interface {
+	// Create guarda la inscripción; devuelve ErrEnrollmentExists si el usuario ya está inscrito
+	Create(ctx context.Context, enrollment Enrollment) error
+	Get(ctx context.Context, userID int, courseID string) (Enrollment, error)
+	ListByUser(ctx context.Context, userID int) ([]Enrollment, error)
+	// NextPending devuelve la inscripción pendiente más antigua del curso
+	NextPending(ctx context.Context, courseID string) (Enrollment, error)
+	// Save reemplaza la inscripción solo si sigue en el estado expected y en la versión con la que
+	// se leyó, e incrementa la versión; si no devuelve ErrEnrollmentChanged
+	Save(ctx context.Context, enrollment Enrollment, expected string) error
+	// ExpireByCourse vence las inscripciones pendientes o activas del curso y devuelve cuántas cambió
+	ExpireByCourse(ctx context.Context, courseID string, at time.Time) (int64, error)
+	// DeleteByCourse elimina las inscripciones del curso y devuelve cuántas borró
+	DeleteByCourse(ctx context.Context, courseID string) (int64, error)
+}
@@ -3,27 +3,30 @@ package middleware
 import (
 	"net/http"
 
-	"github.com/hugodiazo/arq-soft-2/api/users"
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/auth"
 )
 
-// CheckRole verifica si el usuario tiene el rol adecuado
-func CheckRole(requiredRole string, next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Extraer el userID del token
-		userID, err := users.GetUserIDFromToken(r)
-		if err != nil {
-			http.Error(w, "No autorizado", http.StatusUnauthorized)
-			return
-		}
+// RequirePermission verifica que el rol del usuario autenticado tenga todos los permisos indicados
+// según la política; debe usarse detrás de auth.Authenticate
+func RequirePermission(policy auth.Policy, perms ...auth.Permission) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			// Obtener el usuario verificado por el middleware de autenticación
+			claims, ok := auth.UserFromContext(r.Context())
+			if !ok {
+				apierror.Write(w, r, apierror.ErrUnauthorized)
+				return
+			}
 
-		// Obtener el usuario desde la base de datos
-		user, err := users.GetUserByIDFromDB(userID)
-		if err != nil || user.Role != requiredRole {
-			http.Error(w, "No tienes permiso para acceder a esta ruta", http.StatusForbidden)
-			return
-		}
+			// El rol viaja firmado en el token, no hace falta consultar la base de datos
+			if !policy.Allows(claims.Role, perms...) {
+				apierror.Write(w, r, apierror.ErrForbidden)
+				return
+			}
 
-		// Si todo está bien, proceder al siguiente handler
-		next(w, r)
+			// Si todo está bien, proceder al siguiente handler
+			next(w, r)
+		}
 	}
 }
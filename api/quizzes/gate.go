@@ -0,0 +1,36 @@
+package quizzes
+
+import (
+	"context"
+
+	"github.com/hugodiazo/arq-soft-2/api/courses"
+)
+
+// CompletionGate implementa courses.CompletionGate exigiendo aprobar las evaluaciones obligatorias
+type CompletionGate struct {
+	quizzes QuizRepository
+}
+
+// NewCompletionGate crea el requisito de completitud sobre el repositorio de evaluaciones dado
+func NewCompletionGate(quizzes QuizRepository) *CompletionGate {
+	return &CompletionGate{quizzes: quizzes}
+}
+
+// CheckCompletion verifica que la inscripción haya aprobado todas las evaluaciones obligatorias del curso
+func (g *CompletionGate) CheckCompletion(ctx context.Context, enrollment courses.Enrollment) error {
+	quizzes, err := g.quizzes.ListByCourse(ctx, enrollment.CourseID)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, quiz := range quizzes {
+		if quiz.Required && !enrollment.QuizPassed(quiz.ID.Hex()) {
+			pending = append(pending, quiz.Title)
+		}
+	}
+	if len(pending) > 0 {
+		return courses.RequirementsError{Pending: pending}
+	}
+	return nil
+}
@@ -0,0 +1,455 @@
+package quizzes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/courses"
+	"github.com/hugodiazo/arq-soft-2/api/validate"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/i18n"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Errores de la API de evaluaciones
+var (
+	errCourseNotFound     = apierror.New(http.StatusNotFound, "course_not_found", "courses.not_found")
+	errCourseArchived     = apierror.New(http.StatusConflict, "course_archived", "courses.archived")
+	errQuizNotFound       = apierror.New(http.StatusNotFound, "quiz_not_found", "quizzes.not_found")
+	errAttemptNotFound    = apierror.New(http.StatusNotFound, "attempt_not_found", "quizzes.attempt_not_found")
+	errAttemptExists      = apierror.New(http.StatusConflict, "attempt_exists", "quizzes.attempt_exists")
+	errAttemptClosed      = apierror.New(http.StatusConflict, "attempt_closed", "quizzes.attempt_closed")
+	errAttemptExpired     = apierror.New(http.StatusConflict, "attempt_expired", "quizzes.attempt_expired")
+	errAttemptsExhausted  = apierror.New(http.StatusConflict, "attempts_exhausted", "quizzes.attempts_exhausted")
+	errEnrollmentRequired = apierror.ErrForbidden.WithMessage("quizzes.enrollment_required")
+)
+
+// Handler agrupa los handlers HTTP de evaluaciones e intentos y sus dependencias
+type Handler struct {
+	quizzes     QuizRepository
+	attempts    AttemptRepository
+	courses     courses.CourseRepository
+	enrollments courses.EnrollmentRepository
+	policy      auth.Policy
+}
+
+// NewHandler crea los handlers de evaluaciones con los repositorios y la política de permisos dados
+func NewHandler(quizzes QuizRepository, attempts AttemptRepository, courseRepo courses.CourseRepository, enrollments courses.EnrollmentRepository, policy auth.Policy) *Handler {
+	return &Handler{
+		quizzes:     quizzes,
+		attempts:    attempts,
+		courses:     courseRepo,
+		enrollments: enrollments,
+		policy:      policy,
+	}
+}
+
+// AttemptView es un intento junto con las preguntas de la evaluación sin las respuestas
+type AttemptView struct {
+	Attempt Attempt `json:"attempt"`
+	Quiz    Quiz    `json:"quiz"`
+}
+
+// canEdit indica si el usuario puede editar las evaluaciones del curso
+func (h *Handler) canEdit(r *http.Request, course courses.Course) bool {
+	claims, ok := auth.UserFromContext(r.Context())
+	return ok && h.policy.AllowsOwned(claims, auth.PermCourseWrite, course.InstructorID)
+}
+
+// getCourse obtiene el curso; si falla responde el error y devuelve false
+func (h *Handler) getCourse(w http.ResponseWriter, r *http.Request, id string) (courses.Course, bool) {
+	course, err := h.courses.GetByID(r.Context(), id)
+	if errors.Is(err, courses.ErrCourseNotFound) {
+		apierror.Write(w, r, errCourseNotFound)
+		return course, false
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.fetch_failed"))
+		return course, false
+	}
+	return course, true
+}
+
+// editableCourse obtiene el curso si el usuario puede editarlo y no está archivado
+func (h *Handler) editableCourse(w http.ResponseWriter, r *http.Request, id string) (courses.Course, bool) {
+	course, ok := h.getCourse(w, r, id)
+	if !ok {
+		return course, false
+	}
+	if !h.canEdit(r, course) {
+		apierror.Write(w, r, apierror.ErrForbidden.WithMessage("courses.forbidden_edit"))
+		return course, false
+	}
+	if course.ArchivedAt != nil {
+		apierror.Write(w, r, errCourseArchived)
+		return course, false
+	}
+	return course, true
+}
+
+// quizFromRequest obtiene la evaluación {id}; si falla responde el error y devuelve false
+func (h *Handler) quizFromRequest(w http.ResponseWriter, r *http.Request) (Quiz, bool) {
+	quiz, err := h.quizzes.GetByID(r.Context(), r.PathValue("id"))
+	if errors.Is(err, ErrQuizNotFound) {
+		apierror.Write(w, r, errQuizNotFound)
+		return quiz, false
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("quizzes.fetch_failed"))
+		return quiz, false
+	}
+	return quiz, true
+}
+
+// decodeQuiz decodifica y valida la evaluación del cuerpo; lesson_id debe ser una lección de
+// tipo quiz del curso
+func decodeQuiz(w http.ResponseWriter, r *http.Request, course courses.Course) (Quiz, bool) {
+	// El valor por defecto solo queda si el cuerpo no trae passing_score; un 0 explícito se respeta
+	quiz := Quiz{PassingScore: defaultPassingScore}
+	if !validate.DecodeJSON(w, r, &quiz) {
+		return quiz, false
+	}
+	err := quiz.prepare()
+	if quiz.LessonID != "" && !isQuizLesson(course, quiz.LessonID) {
+		err = errors.Join(err, apierror.Field("lesson_id", apierror.FieldInvalid, "quizzes.lesson_not_quiz"))
+	}
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation(err))
+		return quiz, false
+	}
+	return quiz, true
+}
+
+func isQuizLesson(course courses.Course, lessonID string) bool {
+	for _, module := range course.Modules {
+		for _, lesson := range module.Lessons {
+			if lesson.ID == lessonID {
+				return lesson.Type == courses.LessonQuiz
+			}
+		}
+	}
+	return false
+}
+
+// CreateQuiz maneja POST /courses/{id}/quizzes
+func (h *Handler) CreateQuiz(w http.ResponseWriter, r *http.Request) {
+	courseID := r.PathValue("id")
+	if !primitive.IsValidObjectID(courseID) {
+		apierror.Write(w, r, apierror.ErrInvalidID.WithMessage("courses.invalid_id"))
+		return
+	}
+	course, ok := h.editableCourse(w, r, courseID)
+	if !ok {
+		return
+	}
+	quiz, ok := decodeQuiz(w, r, course)
+	if !ok {
+		return
+	}
+
+	quiz.CourseID = courseID
+	quiz.CreatedAt = time.Now().UTC()
+	quiz.UpdatedAt = quiz.CreatedAt
+	if err := h.quizzes.Create(r.Context(), &quiz); err != nil {
+		log.Println("Error al crear la evaluación:", err)
+		apierror.Write(w, r, apierror.Internal("quizzes.create_failed"))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(quiz)
+}
+
+// ListQuizzes maneja GET /courses/{id}/quizzes; los alumnos no ven las preguntas hasta iniciar un intento
+func (h *Handler) ListQuizzes(w http.ResponseWriter, r *http.Request) {
+	courseID := r.PathValue("id")
+	if !primitive.IsValidObjectID(courseID) {
+		apierror.Write(w, r, apierror.ErrInvalidID.WithMessage("courses.invalid_id"))
+		return
+	}
+	course, ok := h.getCourse(w, r, courseID)
+	if !ok {
+		return
+	}
+
+	quizzes, err := h.quizzes.ListByCourse(r.Context(), courseID)
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("quizzes.list_failed"))
+		return
+	}
+	if !h.canEdit(r, course) {
+		for i, quiz := range quizzes {
+			quizzes[i] = quiz.summary()
+		}
+	}
+	if quizzes == nil {
+		quizzes = []Quiz{}
+	}
+	json.NewEncoder(w).Encode(quizzes)
+}
+
+// GetQuiz maneja GET /quizzes/{id}
+func (h *Handler) GetQuiz(w http.ResponseWriter, r *http.Request) {
+	quiz, ok := h.quizFromRequest(w, r)
+	if !ok {
+		return
+	}
+	course, ok := h.getCourse(w, r, quiz.CourseID)
+	if !ok {
+		return
+	}
+
+	if !h.canEdit(r, course) {
+		quiz = quiz.summary()
+	}
+	json.NewEncoder(w).Encode(quiz)
+}
+
+// UpdateQuiz maneja PUT /quizzes/{id}; los intentos ya entregados conservan su nota
+func (h *Handler) UpdateQuiz(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.quizFromRequest(w, r)
+	if !ok {
+		return
+	}
+	course, ok := h.editableCourse(w, r, existing.CourseID)
+	if !ok {
+		return
+	}
+	quiz, ok := decodeQuiz(w, r, course)
+	if !ok {
+		return
+	}
+
+	quiz.ID = existing.ID
+	quiz.CourseID = existing.CourseID
+	quiz.CreatedAt = existing.CreatedAt
+	quiz.UpdatedAt = time.Now().UTC()
+	if err := h.quizzes.Update(r.Context(), quiz); err != nil {
+		log.Println("Error al actualizar la evaluación:", err)
+		apierror.Write(w, r, apierror.Internal("quizzes.update_failed"))
+		return
+	}
+	json.NewEncoder(w).Encode(quiz)
+}
+
+// DeleteQuiz maneja DELETE /quizzes/{id}
+func (h *Handler) DeleteQuiz(w http.ResponseWriter, r *http.Request) {
+	quiz, ok := h.quizFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := h.editableCourse(w, r, quiz.CourseID); !ok {
+		return
+	}
+
+	if err := h.quizzes.Delete(r.Context(), quiz.ID.Hex()); err != nil && !errors.Is(err, ErrQuizNotFound) {
+		apierror.Write(w, r, apierror.Internal("quizzes.delete_failed"))
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"message": i18n.Tr(r.Context(), "quizzes.deleted")})
+}
+
+// StartAttempt maneja POST /quizzes/{id}/attempts. Si el alumno tiene un intento en curso lo
+// devuelve en lugar de iniciar otro.
+func (h *Handler) StartAttempt(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+	quiz, ok := h.quizFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.enrollments.Get(r.Context(), claims.UserID, quiz.CourseID)
+	if err != nil && !errors.Is(err, courses.ErrEnrollmentNotFound) {
+		apierror.Write(w, r, apierror.Internal("enrollments.fetch_failed"))
+		return
+	}
+	if err != nil || enrollment.Status != courses.EnrollmentActive {
+		apierror.Write(w, r, errEnrollmentRequired)
+		return
+	}
+
+	attempts, err := h.attempts.ListByUser(r.Context(), quiz.ID.Hex(), claims.UserID)
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("quizzes.attempts_failed"))
+		return
+	}
+	now := time.Now().UTC()
+	for _, attempt := range attempts {
+		if attempt.Status != AttemptInProgress {
+			continue
+		}
+		if !attempt.expired(now) {
+			json.NewEncoder(w).Encode(AttemptView{Attempt: attempt, Quiz: quiz.forStudent()})
+			return
+		}
+		h.closeExpired(r.Context(), quiz, attempt, now)
+	}
+	if quiz.MaxAttempts > 0 && len(attempts) >= quiz.MaxAttempts {
+		apierror.Write(w, r, errAttemptsExhausted)
+		return
+	}
+
+	attempt := Attempt{
+		QuizID:    quiz.ID.Hex(),
+		CourseID:  quiz.CourseID,
+		UserID:    claims.UserID,
+		Number:    len(attempts) + 1,
+		Status:    AttemptInProgress,
+		StartedAt: now,
+	}
+	if quiz.TimeLimit > 0 {
+		deadline := now.Add(time.Duration(quiz.TimeLimit) * time.Minute)
+		attempt.Deadline = &deadline
+	}
+	err = h.attempts.Create(r.Context(), &attempt)
+	if errors.Is(err, ErrAttemptExists) {
+		apierror.Write(w, r, errAttemptExists)
+		return
+	} else if err != nil {
+		log.Println("Error al iniciar el intento:", err)
+		apierror.Write(w, r, apierror.Internal("quizzes.attempt_start_failed"))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(AttemptView{Attempt: attempt, Quiz: quiz.forStudent()})
+}
+
+// SubmitAttempt maneja POST /quizzes/{id}/attempts/{attempt_id}/submit con {"answers": [...]};
+// corrige el intento y guarda el resultado en la inscripción
+func (h *Handler) SubmitAttempt(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+
+	var req struct {
+		Answers []Answer `json:"answers"`
+	}
+	if !validate.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	quiz, ok := h.quizFromRequest(w, r)
+	if !ok {
+		return
+	}
+	attempt, err := h.attempts.Get(r.Context(), r.PathValue("attempt_id"))
+	if errors.Is(err, ErrAttemptNotFound) || (err == nil && (attempt.QuizID != quiz.ID.Hex() || attempt.UserID != claims.UserID)) {
+		apierror.Write(w, r, errAttemptNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("quizzes.attempt_fetch_failed"))
+		return
+	}
+	if attempt.Status != AttemptInProgress {
+		apierror.Write(w, r, errAttemptClosed)
+		return
+	}
+
+	now := time.Now().UTC()
+	if attempt.expired(now) {
+		h.closeExpired(r.Context(), quiz, attempt, now)
+		apierror.Write(w, r, errAttemptExpired)
+		return
+	}
+
+	attempt.grade(quiz, req.Answers, now)
+	err = h.attempts.Close(r.Context(), attempt)
+	if errors.Is(err, ErrAttemptClosed) {
+		apierror.Write(w, r, errAttemptClosed)
+		return
+	} else if err != nil {
+		log.Println("Error al guardar el intento:", err)
+		apierror.Write(w, r, apierror.Internal("quizzes.attempt_save_failed"))
+		return
+	}
+	h.recordResult(r.Context(), quiz, attempt)
+
+	json.NewEncoder(w).Encode(attempt)
+}
+
+// ListAttempts maneja GET /quizzes/{id}/attempts con los intentos del usuario autenticado
+func (h *Handler) ListAttempts(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+	quiz, ok := h.quizFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	attempts, err := h.attempts.ListByUser(r.Context(), quiz.ID.Hex(), claims.UserID)
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("quizzes.attempts_failed"))
+		return
+	}
+	if attempts == nil {
+		attempts = []Attempt{}
+	}
+	json.NewEncoder(w).Encode(attempts)
+}
+
+// closeExpired cierra con nota 0 un intento vencido y lo cuenta en la inscripción
+func (h *Handler) closeExpired(ctx context.Context, quiz Quiz, attempt Attempt, now time.Time) {
+	attempt.expire(quiz, now)
+	if err := h.attempts.Close(ctx, attempt); err != nil {
+		if !errors.Is(err, ErrAttemptClosed) {
+			log.Println("Error al cerrar el intento vencido", attempt.ID.Hex(), ":", err)
+		}
+		return
+	}
+	h.recordResult(ctx, quiz, attempt)
+}
+
+// maxSaveAttempts es la cantidad de veces que se intenta guardar el resultado cuando la
+// inscripción cambia entre la lectura y la escritura
+const maxSaveAttempts = 3
+
+// recordResult guarda el resultado del intento en la inscripción activa del alumno y, si aprobó,
+// completa la lección vinculada. Save falla si la inscripción cambió desde que se leyó, por
+// ejemplo al completar una lección a la vez, y entonces se vuelve a leer y aplicar el resultado.
+// Los errores solo se registran porque el intento ya está guardado.
+func (h *Handler) recordResult(ctx context.Context, quiz Quiz, attempt Attempt) {
+	var course *courses.Course
+	if attempt.Passed && quiz.LessonID != "" {
+		if c, err := h.courses.GetByID(ctx, quiz.CourseID); err == nil {
+			course = &c
+		}
+	}
+
+	at := *attempt.SubmittedAt
+	for i := 1; ; i++ {
+		enrollment, err := h.enrollments.Get(ctx, attempt.UserID, quiz.CourseID)
+		if err != nil {
+			log.Println("Error al obtener la inscripción del intento", attempt.ID.Hex(), ":", err)
+			return
+		}
+		if enrollment.Status != courses.EnrollmentActive {
+			return
+		}
+
+		enrollment.RecordQuizResult(quiz.ID.Hex(), attempt.Score, attempt.Passed, at)
+		if course != nil {
+			enrollment.CompleteLesson(*course, quiz.LessonID, at)
+		}
+		err = h.enrollments.Save(ctx, enrollment, courses.EnrollmentActive)
+		if errors.Is(err, courses.ErrEnrollmentChanged) && i < maxSaveAttempts {
+			continue
+		}
+		if err != nil {
+			log.Println("Error al guardar el resultado del intento", attempt.ID.Hex(), ":", err)
+		}
+		return
+	}
+}
@@ -0,0 +1,110 @@
+package quizzes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/courses"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// racingEnrollments ejecuta race antes del primer Save, como otra solicitud que guarda la
+// inscripción entre la lectura y la escritura
+type racingEnrollments struct {
+	*courses.MemoryEnrollmentRepository
+	race func()
+}
+
+func (r *racingEnrollments) Save(ctx context.Context, enrollment courses.Enrollment, expected string) error {
+	if race := r.race; race != nil {
+		r.race = nil
+		race()
+	}
+	return r.MemoryEnrollmentRepository.Save(ctx, enrollment, expected)
+}
+
+func TestRecordResultRetriesConcurrentChanges(t *testing.T) {
+	ctx := context.Background()
+	courseRepo := courses.NewMemoryCourseRepository()
+	course := courses.Course{Title: "Go", Level: "beginner"}
+	if err := courseRepo.Create(ctx, &course); err != nil {
+		t.Fatal(err)
+	}
+	id := course.ID.Hex()
+	modules := []courses.Module{{ID: "m1", Title: "Introducción", Order: 1, Lessons: []courses.Lesson{
+		{ID: "l1", Title: "Lectura", Order: 1, Type: courses.LessonText},
+		{ID: "l2", Title: "Evaluación", Order: 2, Type: courses.LessonQuiz},
+	}}}
+	if err := courseRepo.SetModules(ctx, id, modules, 0); err != nil {
+		t.Fatal(err)
+	}
+	course, err := courseRepo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const userID = 7
+	enrollments := &racingEnrollments{MemoryEnrollmentRepository: courses.NewMemoryEnrollmentRepository()}
+	if err := enrollments.Create(ctx, courses.Enrollment{UserID: userID, CourseID: id, Status: courses.EnrollmentActive}); err != nil {
+		t.Fatal(err)
+	}
+	// Otra solicitud completa la lección l1 después de que recordResult leyó la inscripción
+	enrollments.race = func() {
+		enrollment, err := enrollments.Get(ctx, userID, id)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		enrollment.CompleteLesson(course, "l1", time.Now().UTC())
+		if err := enrollments.MemoryEnrollmentRepository.Save(ctx, enrollment, courses.EnrollmentActive); err != nil {
+			t.Error(err)
+		}
+	}
+
+	h := NewHandler(nil, nil, courseRepo, enrollments, auth.DefaultPolicy)
+	quiz := Quiz{ID: primitive.NewObjectID(), CourseID: id, LessonID: "l2"}
+	submitted := time.Now().UTC()
+	h.recordResult(ctx, quiz, Attempt{ID: primitive.NewObjectID(), UserID: userID, Score: 90, Passed: true, SubmittedAt: &submitted})
+
+	enrollment, err := enrollments.Get(ctx, userID, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enrollment.CompletedLessons) != 2 || enrollment.Progress != 100 {
+		t.Errorf("lecciones completadas %+v, progreso %d", enrollment.CompletedLessons, enrollment.Progress)
+	}
+	if len(enrollment.Quizzes) != 1 || enrollment.Quizzes[0].Attempts != 1 || enrollment.Quizzes[0].BestScore != 90 {
+		t.Errorf("resultados de evaluaciones: %+v", enrollment.Quizzes)
+	}
+}
+
+func TestDecodeQuizPassingScore(t *testing.T) {
+	const questions = `"questions": [{"type": "true_false", "prompt": "¿Go tiene goroutines?", "correct_answer": true}]`
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"por defecto", `{"title": "Final", ` + questions + `}`, defaultPassingScore},
+		{"cero explícito", `{"title": "Final", "passing_score": 0, ` + questions + `}`, 0},
+		{"otro valor", `{"title": "Final", "passing_score": 80, ` + questions + `}`, 80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/courses/1/quizzes", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			quiz, ok := decodeQuiz(w, r, courses.Course{})
+			if !ok {
+				t.Fatalf("estado %d: %s", w.Code, w.Body)
+			}
+			if quiz.PassingScore != tt.want {
+				t.Errorf("passing_score %d, se esperaba %d", quiz.PassingScore, tt.want)
+			}
+		})
+	}
+}
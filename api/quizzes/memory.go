@@ -0,0 +1,147 @@
+package quizzes
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryQuizRepository implementa QuizRepository en memoria para pruebas y desarrollo
+type MemoryQuizRepository struct {
+	mu      sync.RWMutex
+	quizzes []Quiz
+}
+
+// NewMemoryQuizRepository crea un repositorio de evaluaciones vacío
+func NewMemoryQuizRepository() *MemoryQuizRepository {
+	return &MemoryQuizRepository{}
+}
+
+func (r *MemoryQuizRepository) Create(ctx context.Context, quiz *Quiz) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	quiz.ID = primitive.NewObjectID()
+	r.quizzes = append(r.quizzes, *quiz)
+	return nil
+}
+
+func (r *MemoryQuizRepository) GetByID(ctx context.Context, id string) (Quiz, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, quiz := range r.quizzes {
+		if quiz.ID.Hex() == id {
+			return quiz, nil
+		}
+	}
+	return Quiz{}, ErrQuizNotFound
+}
+
+// ListByCourse devuelve las evaluaciones en orden de creación
+func (r *MemoryQuizRepository) ListByCourse(ctx context.Context, courseID string) ([]Quiz, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var quizzes []Quiz
+	for _, quiz := range r.quizzes {
+		if quiz.CourseID == courseID {
+			quizzes = append(quizzes, quiz)
+		}
+	}
+	return quizzes, nil
+}
+
+func (r *MemoryQuizRepository) Update(ctx context.Context, quiz Quiz) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.quizzes {
+		if existing.ID == quiz.ID {
+			r.quizzes[i] = quiz
+			return nil
+		}
+	}
+	return ErrQuizNotFound
+}
+
+func (r *MemoryQuizRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, quiz := range r.quizzes {
+		if quiz.ID.Hex() == id {
+			r.quizzes = append(r.quizzes[:i], r.quizzes[i+1:]...)
+			return nil
+		}
+	}
+	return ErrQuizNotFound
+}
+
+// MemoryAttemptRepository implementa AttemptRepository en memoria para pruebas y desarrollo
+type MemoryAttemptRepository struct {
+	mu       sync.RWMutex
+	attempts []Attempt
+}
+
+// NewMemoryAttemptRepository crea un repositorio de intentos vacío
+func NewMemoryAttemptRepository() *MemoryAttemptRepository {
+	return &MemoryAttemptRepository{}
+}
+
+func (r *MemoryAttemptRepository) Create(ctx context.Context, attempt *Attempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.attempts {
+		if existing.QuizID == attempt.QuizID && existing.UserID == attempt.UserID && existing.Number == attempt.Number {
+			return ErrAttemptExists
+		}
+	}
+	attempt.ID = primitive.NewObjectID()
+	r.attempts = append(r.attempts, *attempt)
+	return nil
+}
+
+func (r *MemoryAttemptRepository) Get(ctx context.Context, id string) (Attempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, attempt := range r.attempts {
+		if attempt.ID.Hex() == id {
+			return attempt, nil
+		}
+	}
+	return Attempt{}, ErrAttemptNotFound
+}
+
+// ListByUser recorre en orden de creación, que coincide con el de número de intento
+func (r *MemoryAttemptRepository) ListByUser(ctx context.Context, quizID string, userID int) ([]Attempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var attempts []Attempt
+	for _, attempt := range r.attempts {
+		if attempt.QuizID == quizID && attempt.UserID == userID {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
+
+func (r *MemoryAttemptRepository) Close(ctx context.Context, attempt Attempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.attempts {
+		if existing.ID == attempt.ID {
+			if existing.Status != AttemptInProgress {
+				return ErrAttemptClosed
+			}
+			r.attempts[i] = attempt
+			return nil
+		}
+	}
+	return ErrAttemptNotFound
+}
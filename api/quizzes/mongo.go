@@ -0,0 +1,142 @@
+package quizzes
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoQuizRepository implementa QuizRepository sobre la colección quizzes
+type MongoQuizRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoQuizRepository crea un repositorio de evaluaciones sobre la base dada
+func NewMongoQuizRepository(db *mongo.Database) *MongoQuizRepository {
+	return &MongoQuizRepository{collection: db.Collection("quizzes")}
+}
+
+func (r *MongoQuizRepository) Create(ctx context.Context, quiz *Quiz) error {
+	quiz.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, quiz)
+	return err
+}
+
+func (r *MongoQuizRepository) GetByID(ctx context.Context, id string) (Quiz, error) {
+	var quiz Quiz
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return quiz, ErrQuizNotFound
+	}
+
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&quiz)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return quiz, ErrQuizNotFound
+	}
+	return quiz, err
+}
+
+func (r *MongoQuizRepository) ListByCourse(ctx context.Context, courseID string) ([]Quiz, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"course_id": courseID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var quizzes []Quiz
+	if err := cursor.All(ctx, &quizzes); err != nil {
+		return nil, err
+	}
+	return quizzes, nil
+}
+
+func (r *MongoQuizRepository) Update(ctx context.Context, quiz Quiz) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": quiz.ID}, quiz)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrQuizNotFound
+	}
+	return nil
+}
+
+func (r *MongoQuizRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrQuizNotFound
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrQuizNotFound
+	}
+	return nil
+}
+
+// MongoAttemptRepository implementa AttemptRepository sobre la colección quiz_attempts
+type MongoAttemptRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoAttemptRepository crea un repositorio de intentos sobre la base dada
+func NewMongoAttemptRepository(db *mongo.Database) *MongoAttemptRepository {
+	return &MongoAttemptRepository{collection: db.Collection("quiz_attempts")}
+}
+
+func (r *MongoAttemptRepository) Create(ctx context.Context, attempt *Attempt) error {
+	attempt.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, attempt)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAttemptExists
+	}
+	return err
+}
+
+func (r *MongoAttemptRepository) Get(ctx context.Context, id string) (Attempt, error) {
+	var attempt Attempt
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return attempt, ErrAttemptNotFound
+	}
+
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&attempt)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return attempt, ErrAttemptNotFound
+	}
+	return attempt, err
+}
+
+func (r *MongoAttemptRepository) ListByUser(ctx context.Context, quizID string, userID int) ([]Attempt, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "number", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"quiz_id": quizID, "user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var attempts []Attempt
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (r *MongoAttemptRepository) Close(ctx context.Context, attempt Attempt) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": attempt.ID, "status": AttemptInProgress}, attempt)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrAttemptClosed
+	}
+	return nil
+}
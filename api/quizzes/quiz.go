@@ -0,0 +1,261 @@
+package quizzes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Tipos de pregunta
+const (
+	MultipleChoice = "multiple_choice"
+	TrueFalse      = "true_false"
+	ShortAnswer    = "short_answer"
+)
+
+// Estados de un intento
+const (
+	AttemptInProgress = "in_progress"
+	AttemptSubmitted  = "submitted"
+	// AttemptExpired es un intento que no se entregó dentro del tiempo límite; cuenta con nota 0
+	AttemptExpired = "expired"
+)
+
+// defaultPassingScore es el porcentaje mínimo para aprobar si la evaluación no indica otro
+const defaultPassingScore = 60
+
+// submitGrace tolera la demora de la red al entregar un intento con tiempo límite
+const submitGrace = 30 * time.Second
+
+// Question es una pregunta de una evaluación. Las respuestas correctas se ocultan a los alumnos.
+type Question struct {
+	ID      string   `json:"id" bson:"id"`
+	Type    string   `json:"type" bson:"type"`
+	Prompt  string   `json:"prompt" bson:"prompt"`
+	Points  int      `json:"points" bson:"points"`
+	Options []string `json:"options,omitempty" bson:"options,omitempty"`
+	// CorrectOption es el índice de la opción correcta de multiple_choice
+	CorrectOption *int `json:"correct_option,omitempty" bson:"correct_option,omitempty"`
+	// CorrectAnswer es la respuesta de true_false
+	CorrectAnswer *bool `json:"correct_answer,omitempty" bson:"correct_answer,omitempty"`
+	// AcceptedAnswers son las respuestas válidas de short_answer; se comparan sin mayúsculas ni acentos
+	AcceptedAnswers []string `json:"accepted_answers,omitempty" bson:"accepted_answers,omitempty"`
+}
+
+// Quiz es una evaluación de un curso
+type Quiz struct {
+	ID       primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CourseID string             `json:"course_id" bson:"course_id"`
+	// LessonID vincula la evaluación con una lección de tipo quiz, que se completa al aprobarla
+	LessonID string `json:"lesson_id,omitempty" bson:"lesson_id,omitempty"`
+	Title    string `json:"title" bson:"title" validate:"required,max=200"`
+	// TimeLimit es la duración de cada intento en minutos; 0 indica sin límite
+	TimeLimit int `json:"time_limit_minutes" bson:"time_limit_minutes" validate:"min=0"`
+	// MaxAttempts es la cantidad de intentos permitidos por alumno; 0 indica sin límite
+	MaxAttempts int `json:"max_attempts" bson:"max_attempts" validate:"min=0"`
+	// PassingScore es el porcentaje mínimo para aprobar; 0 aprueba cualquier intento entregado
+	PassingScore int `json:"passing_score" bson:"passing_score" validate:"min=0,max=100"`
+	// Required indica que hay que aprobarla para completar el curso
+	Required  bool       `json:"required" bson:"required"`
+	Questions []Question `json:"questions,omitempty" bson:"questions"`
+	// QuestionCount se completa al ocultar las preguntas
+	QuestionCount int       `json:"question_count,omitempty" bson:"-"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// prepare valida las preguntas, completa sus valores por defecto y asigna IDs a las preguntas
+// nuevas; devuelve todos los campos inválidos unidos con errors.Join. Las reglas de los demás
+// campos están en sus etiquetas validate.
+func (q *Quiz) prepare() error {
+	var problems []error
+	if len(q.Questions) == 0 {
+		problems = append(problems, apierror.Field("questions", apierror.FieldRequired, "quizzes.questions_required"))
+	}
+
+	seen := make(map[string]bool)
+	for i := range q.Questions {
+		question := &q.Questions[i]
+		if question.ID == "" || seen[question.ID] {
+			question.ID = primitive.NewObjectID().Hex()
+		}
+		seen[question.ID] = true
+		if question.Points == 0 {
+			question.Points = 1
+		}
+		if err := question.validate(fmt.Sprintf("questions[%d].", i)); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// validate verifica que la pregunta tenga la respuesta correcta que corresponde a su tipo; prefix
+// es la ubicación de la pregunta en el cuerpo, que antecede al nombre de los campos inválidos
+func (q Question) validate(prefix string) error {
+	var problems []error
+	if strings.TrimSpace(q.Prompt) == "" {
+		problems = append(problems, apierror.Field(prefix+"prompt", apierror.FieldRequired, "validation.required", prefix+"prompt"))
+	}
+	if q.Points < 0 {
+		problems = append(problems, apierror.Field(prefix+"points", apierror.FieldOutOfRange, "validation.min", prefix+"points", 0))
+	}
+	switch q.Type {
+	case MultipleChoice:
+		if len(q.Options) < 2 {
+			problems = append(problems, apierror.Field(prefix+"options", apierror.FieldInvalid, "quizzes.question_options"))
+		}
+		if q.CorrectOption == nil || *q.CorrectOption < 0 || *q.CorrectOption >= len(q.Options) {
+			problems = append(problems, apierror.Field(prefix+"correct_option", apierror.FieldInvalid, "quizzes.question_correct_option"))
+		}
+	case TrueFalse:
+		if q.CorrectAnswer == nil {
+			problems = append(problems, apierror.Field(prefix+"correct_answer", apierror.FieldRequired, "quizzes.question_correct_answer"))
+		}
+	case ShortAnswer:
+		if len(q.AcceptedAnswers) == 0 {
+			problems = append(problems, apierror.Field(prefix+"accepted_answers", apierror.FieldRequired, "quizzes.question_accepted_answers"))
+		}
+	default:
+		problems = append(problems, apierror.Field(prefix+"type", apierror.FieldInvalid, "validation.one_of", prefix+"type", strings.Join([]string{MultipleChoice, TrueFalse, ShortAnswer}, ", ")))
+	}
+	return errors.Join(problems...)
+}
+
+// forStudent devuelve la evaluación sin las respuestas correctas
+func (q Quiz) forStudent() Quiz {
+	questions := make([]Question, len(q.Questions))
+	for i, question := range q.Questions {
+		question.CorrectOption = nil
+		question.CorrectAnswer = nil
+		question.AcceptedAnswers = nil
+		questions[i] = question
+	}
+	q.Questions = questions
+	return q
+}
+
+// summary devuelve la evaluación sin preguntas, para mostrarla antes de empezar un intento
+func (q Quiz) summary() Quiz {
+	q.QuestionCount = len(q.Questions)
+	q.Questions = nil
+	return q
+}
+
+// Answer es la respuesta de un alumno a una pregunta; se usa el campo que corresponde al tipo
+type Answer struct {
+	QuestionID string `json:"question_id" bson:"question_id"`
+	Option     *int   `json:"option,omitempty" bson:"option,omitempty"`
+	Value      *bool  `json:"value,omitempty" bson:"value,omitempty"`
+	Text       string `json:"text,omitempty" bson:"text,omitempty"`
+}
+
+// QuestionResult es la corrección de una pregunta
+type QuestionResult struct {
+	QuestionID string `json:"question_id" bson:"question_id"`
+	Correct    bool   `json:"correct" bson:"correct"`
+	Points     int    `json:"points" bson:"points"`
+}
+
+// Attempt es un intento de un alumno en una evaluación
+type Attempt struct {
+	ID       primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	QuizID   string             `json:"quiz_id" bson:"quiz_id"`
+	CourseID string             `json:"course_id" bson:"course_id"`
+	UserID   int                `json:"user_id" bson:"user_id"`
+	// Number es el número de intento del alumno en la evaluación, empezando por 1
+	Number    int       `json:"number" bson:"number"`
+	Status    string    `json:"status" bson:"status"`
+	StartedAt time.Time `json:"started_at" bson:"started_at"`
+	// Deadline es el momento límite para entregar; nil si la evaluación no tiene tiempo límite
+	Deadline    *time.Time       `json:"deadline,omitempty" bson:"deadline,omitempty"`
+	SubmittedAt *time.Time       `json:"submitted_at,omitempty" bson:"submitted_at,omitempty"`
+	Answers     []Answer         `json:"answers,omitempty" bson:"answers,omitempty"`
+	Results     []QuestionResult `json:"results,omitempty" bson:"results,omitempty"`
+	Points      int              `json:"points" bson:"points"`
+	MaxPoints   int              `json:"max_points" bson:"max_points"`
+	// Score es el porcentaje obtenido
+	Score  int  `json:"score" bson:"score"`
+	Passed bool `json:"passed" bson:"passed"`
+}
+
+// expired indica si el intento ya no puede entregarse
+func (a Attempt) expired(now time.Time) bool {
+	return a.Deadline != nil && now.After(a.Deadline.Add(submitGrace))
+}
+
+// grade corrige las respuestas con la evaluación y cierra el intento como entregado
+func (a *Attempt) grade(quiz Quiz, answers []Answer, at time.Time) {
+	byQuestion := make(map[string]Answer, len(answers))
+	for _, answer := range answers {
+		byQuestion[answer.QuestionID] = answer
+	}
+
+	a.Answers = answers
+	a.Results = make([]QuestionResult, 0, len(quiz.Questions))
+	a.Points, a.MaxPoints = 0, 0
+	for _, question := range quiz.Questions {
+		result := QuestionResult{QuestionID: question.ID}
+		if answer, ok := byQuestion[question.ID]; ok && question.correct(answer) {
+			result.Correct = true
+			result.Points = question.Points
+		}
+		a.Results = append(a.Results, result)
+		a.Points += result.Points
+		a.MaxPoints += question.Points
+	}
+	a.Score = 100
+	if a.MaxPoints > 0 {
+		a.Score = a.Points * 100 / a.MaxPoints
+	}
+	a.Passed = a.Score >= quiz.PassingScore
+	a.Status = AttemptSubmitted
+	a.SubmittedAt = &at
+}
+
+// expire cierra el intento vencido con nota 0
+func (a *Attempt) expire(quiz Quiz, at time.Time) {
+	a.grade(quiz, nil, at)
+	a.Status = AttemptExpired
+	a.Passed = false
+	a.Score, a.Points = 0, 0
+}
+
+// correct indica si la respuesta es correcta para la pregunta
+func (q Question) correct(answer Answer) bool {
+	switch q.Type {
+	case MultipleChoice:
+		return answer.Option != nil && q.CorrectOption != nil && *answer.Option == *q.CorrectOption
+	case TrueFalse:
+		return answer.Value != nil && q.CorrectAnswer != nil && *answer.Value == *q.CorrectAnswer
+	case ShortAnswer:
+		given := normalizeAnswer(answer.Text)
+		if given == "" {
+			return false
+		}
+		for _, accepted := range q.AcceptedAnswers {
+			if normalizeAnswer(accepted) == given {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeAnswer pasa a minúsculas, quita los acentos y unifica los espacios de una respuesta corta
+func normalizeAnswer(text string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, text)
+	if err != nil {
+		folded = text
+	}
+	return strings.Join(strings.Fields(strings.ToLower(folded)), " ")
+}
@@ -0,0 +1,38 @@
+package quizzes
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrQuizNotFound se devuelve cuando no existe la evaluación buscada
+	ErrQuizNotFound = errors.New("evaluación no encontrada")
+	// ErrAttemptNotFound se devuelve cuando no existe el intento buscado
+	ErrAttemptNotFound = errors.New("intento no encontrado")
+	// ErrAttemptExists se devuelve cuando otro intento con el mismo número se creó a la vez
+	ErrAttemptExists = errors.New("ya se inició otro intento, volver a intentar")
+	// ErrAttemptClosed se devuelve al entregar un intento que ya no está en curso
+	ErrAttemptClosed = errors.New("el intento ya fue entregado o venció")
+)
+
+// QuizRepository define el acceso a las evaluaciones de los cursos
+type QuizRepository interface {
+	// Create guarda la evaluación y completa su ID
+	Create(ctx context.Context, quiz *Quiz) error
+	GetByID(ctx context.Context, id string) (Quiz, error)
+	ListByCourse(ctx context.Context, courseID string) ([]Quiz, error)
+	Update(ctx context.Context, quiz Quiz) error
+	Delete(ctx context.Context, id string) error
+}
+
+// AttemptRepository define el acceso a los intentos de los alumnos
+type AttemptRepository interface {
+	// Create guarda el intento y completa su ID; devuelve ErrAttemptExists si el número ya existe
+	Create(ctx context.Context, attempt *Attempt) error
+	Get(ctx context.Context, id string) (Attempt, error)
+	// ListByUser devuelve los intentos del alumno en la evaluación ordenados por número
+	ListByUser(ctx context.Context, quizID string, userID int) ([]Attempt, error)
+	// Close guarda el intento corregido solo si seguía en curso; si no devuelve ErrAttemptClosed
+	Close(ctx context.Context, attempt Attempt) error
+}
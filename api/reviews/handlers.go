@@ -0,0 +1,320 @@
+package reviews
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/courses"
+	"github.com/hugodiazo/arq-soft-2/api/users"
+	"github.com/hugodiazo/arq-soft-2/api/validate"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/queue"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Errores de la API de reseñas
+var (
+	errCourseNotFound     = apierror.New(http.StatusNotFound, "course_not_found", "courses.not_found")
+	errReviewNotFound     = apierror.New(http.StatusNotFound, "review_not_found", "reviews.not_found")
+	errReviewExists       = apierror.New(http.StatusConflict, "review_exists", "reviews.exists")
+	errEnrollmentRequired = apierror.ErrForbidden.WithMessage("reviews.enrollment_required")
+)
+
+// Handler agrupa los handlers HTTP de reseñas y sus dependencias
+type Handler struct {
+	reviews     Repository
+	courses     courses.CourseRepository
+	enrollments courses.EnrollmentRepository
+	users       users.UserRepository
+	events      queue.Broker
+	policy      auth.Policy
+}
+
+// NewHandler crea los handlers de reseñas; events recibe el curso actualizado cuando cambia su
+// calificación para reindexarlo
+func NewHandler(reviews Repository, courseRepo courses.CourseRepository, enrollments courses.EnrollmentRepository, userRepo users.UserRepository, events queue.Broker, policy auth.Policy) *Handler {
+	return &Handler{
+		reviews:     reviews,
+		courses:     courseRepo,
+		enrollments: enrollments,
+		users:       userRepo,
+		events:      events,
+		policy:      policy,
+	}
+}
+
+// ReviewPage es una página de reseñas con el total sin paginar
+type ReviewPage struct {
+	Reviews []Review `json:"data"`
+	Total   int64    `json:"total"`
+}
+
+// reviewInput son los campos que el autor puede enviar
+type reviewInput struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Text   string `json:"text" validate:"max=2000"`
+}
+
+// moderationInput es la decisión de un moderador sobre una reseña
+type moderationInput struct {
+	Status string `json:"status" validate:"required,oneof=visible hidden"`
+	Note   string `json:"note" validate:"max=500"`
+}
+
+// parsePage interpreta los parámetros limit y offset
+func parsePage(values url.Values, filter *Filter) error {
+	filter.Limit = defaultPageLimit
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return apierror.Field("limit", apierror.FieldInvalid, "validation.positive_integer", "limit")
+		}
+		filter.Limit = min(limit, maxPageLimit)
+	}
+	if v := values.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return apierror.Field("offset", apierror.FieldInvalid, "validation.non_negative_integer", "offset")
+		}
+		filter.Offset = offset
+	}
+	return nil
+}
+
+// invalidStatus es el error de un status de moderación desconocido
+func invalidStatus() error {
+	return apierror.Field("status", apierror.FieldInvalid, "validation.one_of", "status", StatusVisible+", "+StatusHidden)
+}
+
+// reviewFromRequest obtiene la reseña {id}; si falla responde el error y devuelve false
+func (h *Handler) reviewFromRequest(w http.ResponseWriter, r *http.Request) (Review, bool) {
+	review, err := h.reviews.GetByID(r.Context(), r.PathValue("id"))
+	if errors.Is(err, ErrReviewNotFound) {
+		apierror.Write(w, r, errReviewNotFound)
+		return review, false
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("reviews.fetch_failed"))
+		return review, false
+	}
+	return review, true
+}
+
+// refreshRating recalcula la calificación del curso a partir de sus reseñas visibles y publica el
+// curso actualizado para reindexarlo; los errores solo se registran porque la reseña ya se guardó
+func (h *Handler) refreshRating(ctx context.Context, courseID string) {
+	stats, err := h.reviews.Stats(ctx, courseID)
+	if err != nil {
+		log.Println("Error al calcular la calificación del curso:", courseID, err)
+		return
+	}
+	if err := h.courses.SetRating(ctx, courseID, stats.Average, stats.Count); err != nil {
+		log.Println("Error al guardar la calificación del curso:", courseID, err)
+		return
+	}
+	courses.PublishStoredCourse(ctx, h.courses, h.events, courseID)
+}
+
+// ListCourseReviews maneja GET /courses/{id}/reviews con las reseñas visibles del curso, de la
+// más reciente a la más antigua; acepta limit y offset
+func (h *Handler) ListCourseReviews(w http.ResponseWriter, r *http.Request) {
+	courseID := r.PathValue("id")
+	if _, err := h.courses.GetByID(r.Context(), courseID); errors.Is(err, courses.ErrCourseNotFound) {
+		apierror.Write(w, r, errCourseNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.fetch_failed"))
+		return
+	}
+
+	filter := Filter{CourseID: courseID, Status: StatusVisible}
+	if err := parsePage(r.URL.Query(), &filter); err != nil {
+		apierror.Write(w, r, apierror.Validation(err))
+		return
+	}
+	h.writePage(w, r, filter)
+}
+
+// ListReviews maneja GET /reviews para moderadores; filtra por course_id y status y acepta limit
+// y offset
+func (h *Handler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	filter := Filter{CourseID: values.Get("course_id"), Status: values.Get("status")}
+	switch filter.Status {
+	case "", StatusVisible, StatusHidden:
+	default:
+		apierror.Write(w, r, apierror.Validation(invalidStatus()))
+		return
+	}
+	if err := parsePage(values, &filter); err != nil {
+		apierror.Write(w, r, apierror.Validation(err))
+		return
+	}
+	h.writePage(w, r, filter)
+}
+
+func (h *Handler) writePage(w http.ResponseWriter, r *http.Request, filter Filter) {
+	reviews, total, err := h.reviews.List(r.Context(), filter)
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("reviews.list_failed"))
+		return
+	}
+	json.NewEncoder(w).Encode(ReviewPage{Reviews: reviews, Total: total})
+}
+
+// CreateReview maneja POST /courses/{id}/reviews; solo pueden opinar los usuarios con una
+// inscripción activa o completada, una vez por curso
+func (h *Handler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+	courseID := r.PathValue("id")
+	if !primitive.IsValidObjectID(courseID) {
+		apierror.Write(w, r, apierror.ErrInvalidID.WithMessage("courses.invalid_id"))
+		return
+	}
+
+	var input reviewInput
+	if !validate.DecodeJSON(w, r, &input) {
+		return
+	}
+	review := Review{
+		CourseID: courseID,
+		UserID:   claims.UserID,
+		Rating:   input.Rating,
+		Text:     strings.TrimSpace(input.Text),
+		Status:   StatusVisible,
+	}
+
+	enrollment, err := h.enrollments.Get(r.Context(), claims.UserID, courseID)
+	if err != nil && !errors.Is(err, courses.ErrEnrollmentNotFound) {
+		apierror.Write(w, r, apierror.Internal("enrollments.fetch_failed"))
+		return
+	}
+	if err != nil || (enrollment.Status != courses.EnrollmentActive && enrollment.Status != courses.EnrollmentCompleted) {
+		apierror.Write(w, r, errEnrollmentRequired)
+		return
+	}
+
+	user, err := h.users.GetByID(r.Context(), claims.UserID)
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("users.fetch_failed"))
+		return
+	}
+	review.UserName = user.Name
+	review.CreatedAt = time.Now().UTC()
+	review.UpdatedAt = review.CreatedAt
+
+	err = h.reviews.Create(r.Context(), &review)
+	if errors.Is(err, ErrReviewExists) {
+		apierror.Write(w, r, errReviewExists)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("reviews.save_failed"))
+		return
+	}
+	h.refreshRating(r.Context(), courseID)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(review)
+}
+
+// UpdateReview maneja PUT /reviews/{id}; solo el autor puede editar su reseña. Una reseña oculta
+// sigue oculta después de editarla.
+func (h *Handler) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+	review, ok := h.reviewFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if review.UserID != claims.UserID {
+		apierror.Write(w, r, apierror.ErrForbidden.WithMessage("reviews.author_only"))
+		return
+	}
+
+	var input reviewInput
+	if !validate.DecodeJSON(w, r, &input) {
+		return
+	}
+	review, err := h.reviews.UpdateContent(r.Context(), review.ID.Hex(), input.Rating, strings.TrimSpace(input.Text), time.Now().UTC())
+	if errors.Is(err, ErrReviewNotFound) {
+		apierror.Write(w, r, errReviewNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("reviews.save_failed"))
+		return
+	}
+	h.refreshRating(r.Context(), review.CourseID)
+	json.NewEncoder(w).Encode(review)
+}
+
+// DeleteReview maneja DELETE /reviews/{id} para el autor o un moderador
+func (h *Handler) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+	review, ok := h.reviewFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if review.UserID != claims.UserID && !h.policy.Allows(claims.Role, auth.PermReviewModerate) {
+		apierror.Write(w, r, apierror.ErrForbidden.WithMessage("reviews.forbidden_delete"))
+		return
+	}
+
+	if err := h.reviews.Delete(r.Context(), review.ID.Hex()); err != nil && !errors.Is(err, ErrReviewNotFound) {
+		apierror.Write(w, r, apierror.Internal("reviews.delete_failed"))
+		return
+	}
+	h.refreshRating(r.Context(), review.CourseID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ModerateReview maneja PUT /reviews/{id}/moderation para ocultar o volver a mostrar una reseña
+func (h *Handler) ModerateReview(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+	review, ok := h.reviewFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var input moderationInput
+	if !validate.DecodeJSON(w, r, &input) {
+		return
+	}
+
+	review, err := h.reviews.Moderate(r.Context(), review.ID.Hex(), input.Status, claims.UserID, input.Note, time.Now().UTC())
+	if errors.Is(err, ErrReviewNotFound) {
+		apierror.Write(w, r, errReviewNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("reviews.save_failed"))
+		return
+	}
+	h.refreshRating(r.Context(), review.CourseID)
+	json.NewEncoder(w).Encode(review)
+}
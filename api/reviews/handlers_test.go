@@ -0,0 +1,193 @@
+package reviews
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/courses"
+	"github.com/hugodiazo/arq-soft-2/api/users"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/queue"
+)
+
+// testAPI monta las rutas de reseñas como en main.go sobre los repositorios en memoria
+type testAPI struct {
+	mux         *http.ServeMux
+	reviews     *MemoryRepository
+	courses     *courses.MemoryCourseRepository
+	enrollments *courses.MemoryEnrollmentRepository
+	users       *users.MemoryUserRepository
+	broker      *queue.MemoryBroker
+	courseID    string
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	api := &testAPI{
+		mux:         http.NewServeMux(),
+		reviews:     NewMemoryRepository(),
+		courses:     courses.NewMemoryCourseRepository(),
+		enrollments: courses.NewMemoryEnrollmentRepository(),
+		users:       users.NewMemoryUserRepository(),
+		broker:      queue.NewMemoryBroker(),
+	}
+	course := courses.Course{Title: "Go", Level: "beginner"}
+	if err := api.courses.Create(context.Background(), &course); err != nil {
+		t.Fatal(err)
+	}
+	api.courseID = course.ID.Hex()
+
+	h := NewHandler(api.reviews, api.courses, api.enrollments, api.users, api.broker, auth.DefaultPolicy)
+	api.mux.HandleFunc("GET /courses/{id}/reviews", h.ListCourseReviews)
+	api.mux.HandleFunc("POST /courses/{id}/reviews", h.CreateReview)
+	api.mux.HandleFunc("PUT /reviews/{id}", h.UpdateReview)
+	api.mux.HandleFunc("PUT /reviews/{id}/moderation", h.ModerateReview)
+	return api
+}
+
+// addUser crea un usuario con el rol dado y, si status no está vacío, lo inscribe en el curso
+func (api *testAPI) addUser(t *testing.T, name, role, status string) *auth.Claims {
+	t.Helper()
+	ctx := context.Background()
+	user := users.User{Name: name, Email: strings.ToLower(name) + "@example.com", Password: "secreto123", Role: role}
+	if err := api.users.Create(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+	if status != "" {
+		enrollment := courses.Enrollment{UserID: user.ID, CourseID: api.courseID, Status: status, CreatedAt: time.Now().UTC()}
+		if err := api.enrollments.Create(ctx, enrollment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &auth.Claims{UserID: user.ID, Email: user.Email, Role: role}
+}
+
+// do envía la solicitud como el usuario de claims
+func (api *testAPI) do(method, target, body string, claims *auth.Claims) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r = r.WithContext(auth.WithUser(r.Context(), claims))
+	w := httptest.NewRecorder()
+	api.mux.ServeHTTP(w, r)
+	return w
+}
+
+// review publica la reseña del cuerpo dado y devuelve la reseña guardada
+func (api *testAPI) review(t *testing.T, claims *auth.Claims, body string) Review {
+	t.Helper()
+	w := api.do(http.MethodPost, "/courses/"+api.courseID+"/reviews", body, claims)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+	var review Review
+	if err := json.NewDecoder(w.Body).Decode(&review); err != nil {
+		t.Fatal(err)
+	}
+	return review
+}
+
+// expectRating verifica la calificación guardada en el curso
+func (api *testAPI) expectRating(t *testing.T, average float64, count int) {
+	t.Helper()
+	course, err := api.courses.GetByID(context.Background(), api.courseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if course.RatingAverage != average || course.RatingCount != count {
+		t.Fatalf("calificación %.2f de %d reseñas, se esperaba %.2f de %d", course.RatingAverage, course.RatingCount, average, count)
+	}
+}
+
+// expectProblem verifica el estado y el código de una respuesta de error
+func expectProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("estado %d, se esperaba %d: %s", w.Code, status, w.Body)
+	}
+	var problem apierror.Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Code != code {
+		t.Fatalf("código %q, se esperaba %q", problem.Code, code)
+	}
+}
+
+func TestCreateReviewRequiresEnrollment(t *testing.T) {
+	api := newTestAPI(t)
+	target := "/courses/" + api.courseID + "/reviews"
+
+	stranger := api.addUser(t, "Ada", auth.RoleUser, "")
+	expectProblem(t, api.do(http.MethodPost, target, `{"rating": 5}`, stranger), http.StatusForbidden, "forbidden")
+	waiting := api.addUser(t, "Grace", auth.RoleUser, courses.EnrollmentPending)
+	expectProblem(t, api.do(http.MethodPost, target, `{"rating": 5}`, waiting), http.StatusForbidden, "forbidden")
+
+	student := api.addUser(t, "Alan", auth.RoleUser, courses.EnrollmentCompleted)
+	review := api.review(t, student, `{"rating": 4, "text": "  Muy bueno  "}`)
+	if review.UserName != "Alan" || review.Text != "Muy bueno" || review.Status != StatusVisible {
+		t.Errorf("reseña guardada: %+v", review)
+	}
+	expectProblem(t, api.do(http.MethodPost, target, `{"rating": 2}`, student), http.StatusConflict, "review_exists")
+}
+
+func TestReviewsUpdateCourseRating(t *testing.T) {
+	api := newTestAPI(t)
+	ada := api.addUser(t, "Ada", auth.RoleUser, courses.EnrollmentActive)
+	grace := api.addUser(t, "Grace", auth.RoleUser, courses.EnrollmentActive)
+
+	api.review(t, ada, `{"rating": 5}`)
+	review := api.review(t, grace, `{"rating": 2}`)
+	api.expectRating(t, 3.5, 2)
+	// Cada cambio de calificación publica el curso para reindexarlo
+	if pending := api.broker.Pending(courses.CourseEventsQueue); pending != 2 {
+		t.Errorf("%d eventos publicados, se esperaban 2", pending)
+	}
+
+	if w := api.do(http.MethodPut, "/reviews/"+review.ID.Hex(), `{"rating": 3}`, grace); w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+	api.expectRating(t, 4, 2)
+}
+
+func TestHiddenReviewsAreExcluded(t *testing.T) {
+	api := newTestAPI(t)
+	ada := api.addUser(t, "Ada", auth.RoleUser, courses.EnrollmentActive)
+	grace := api.addUser(t, "Grace", auth.RoleUser, courses.EnrollmentActive)
+	moderator := api.addUser(t, "Root", auth.RoleAdmin, "")
+
+	api.review(t, ada, `{"rating": 5}`)
+	review := api.review(t, grace, `{"rating": 1, "text": "spam"}`)
+
+	body := `{"status": "hidden", "note": "Publicidad"}`
+	if w := api.do(http.MethodPut, "/reviews/"+review.ID.Hex()+"/moderation", body, moderator); w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+	api.expectRating(t, 5, 1)
+
+	// Editar la reseña no la vuelve a mostrar ni pisa la decisión del moderador
+	if w := api.do(http.MethodPut, "/reviews/"+review.ID.Hex(), `{"rating": 2}`, grace); w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+	stored, err := api.reviews.GetByID(context.Background(), review.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != StatusHidden || stored.ModeratedBy != moderator.UserID || stored.Rating != 2 {
+		t.Errorf("reseña guardada: %+v", stored)
+	}
+	api.expectRating(t, 5, 1)
+
+	w := api.do(http.MethodGet, "/courses/"+api.courseID+"/reviews", "", ada)
+	var page ReviewPage
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || len(page.Reviews) != 1 || page.Reviews[0].UserID != ada.UserID {
+		t.Errorf("reseñas visibles: %+v", page)
+	}
+}
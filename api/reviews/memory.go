@@ -0,0 +1,131 @@
+package reviews
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryRepository implementa Repository en memoria para pruebas y desarrollo
+type MemoryRepository struct {
+	mu      sync.RWMutex
+	reviews []Review
+}
+
+// NewMemoryRepository crea un repositorio de reseñas vacío
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, review *Review) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.reviews {
+		if existing.CourseID == review.CourseID && existing.UserID == review.UserID {
+			return ErrReviewExists
+		}
+	}
+	review.ID = primitive.NewObjectID()
+	r.reviews = append(r.reviews, *review)
+	return nil
+}
+
+func (r *MemoryRepository) GetByID(ctx context.Context, id string) (Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, review := range r.reviews {
+		if review.ID.Hex() == id {
+			return review, nil
+		}
+	}
+	return Review{}, ErrReviewNotFound
+}
+
+func (r *MemoryRepository) List(ctx context.Context, filter Filter) ([]Review, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []Review
+	for _, review := range r.reviews {
+		if filter.CourseID != "" && review.CourseID != filter.CourseID {
+			continue
+		}
+		if filter.Status != "" && review.Status != filter.Status {
+			continue
+		}
+		matched = append(matched, review)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].ID.Hex() > matched[j].ID.Hex()
+	})
+
+	start := min(filter.Offset, len(matched))
+	end := min(start+filter.Limit, len(matched))
+	return append([]Review{}, matched[start:end]...), int64(len(matched)), nil
+}
+
+func (r *MemoryRepository) UpdateContent(ctx context.Context, id string, rating int, text string, at time.Time) (Review, error) {
+	return r.update(id, func(review *Review) {
+		review.Rating, review.Text, review.UpdatedAt = rating, text, at
+	})
+}
+
+func (r *MemoryRepository) Moderate(ctx context.Context, id, status string, moderatedBy int, note string, at time.Time) (Review, error) {
+	return r.update(id, func(review *Review) {
+		review.Status, review.ModeratedBy, review.ModerationNote = status, moderatedBy, note
+		review.ModeratedAt = &at
+	})
+}
+
+// update aplica change a la reseña bajo el lock
+func (r *MemoryRepository) update(id string, change func(*Review)) (Review, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.reviews {
+		if r.reviews[i].ID.Hex() == id {
+			change(&r.reviews[i])
+			return r.reviews[i], nil
+		}
+	}
+	return Review{}, ErrReviewNotFound
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, review := range r.reviews {
+		if review.ID.Hex() == id {
+			r.reviews = append(r.reviews[:i], r.reviews[i+1:]...)
+			return nil
+		}
+	}
+	return ErrReviewNotFound
+}
+
+func (r *MemoryRepository) Stats(ctx context.Context, courseID string) (Stats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var stats Stats
+	total := 0
+	for _, review := range r.reviews {
+		if review.CourseID == courseID && review.Status == StatusVisible {
+			total += review.Rating
+			stats.Count++
+		}
+	}
+	if stats.Count > 0 {
+		stats.Average = float64(total) / float64(stats.Count)
+	}
+	return stats.rounded(), nil
+}
@@ -0,0 +1,146 @@
+package reviews
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRepository implementa Repository sobre la colección reviews
+type MongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRepository crea un repositorio de reseñas sobre la base dada
+func NewMongoRepository(db *mongo.Database) *MongoRepository {
+	return &MongoRepository{collection: db.Collection("reviews")}
+}
+
+func (r *MongoRepository) Create(ctx context.Context, review *Review) error {
+	review.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, review)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrReviewExists
+	}
+	return err
+}
+
+func (r *MongoRepository) GetByID(ctx context.Context, id string) (Review, error) {
+	var review Review
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return review, ErrReviewNotFound
+	}
+
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&review)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return review, ErrReviewNotFound
+	}
+	return review, err
+}
+
+func (r *MongoRepository) List(ctx context.Context, filter Filter) ([]Review, int64, error) {
+	query := bson.M{}
+	if filter.CourseID != "" {
+		query["course_id"] = filter.CourseID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(filter.Offset)).
+		SetLimit(int64(filter.Limit))
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	reviews := []Review{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+func (r *MongoRepository) UpdateContent(ctx context.Context, id string, rating int, text string, at time.Time) (Review, error) {
+	return r.update(ctx, id, bson.M{"rating": rating, "text": text, "updated_at": at})
+}
+
+func (r *MongoRepository) Moderate(ctx context.Context, id, status string, moderatedBy int, note string, at time.Time) (Review, error) {
+	return r.update(ctx, id, bson.M{
+		"status":          status,
+		"moderated_by":    moderatedBy,
+		"moderated_at":    at,
+		"moderation_note": note,
+	})
+}
+
+// update aplica $set con los campos dados, sin pisar los que cambian otras solicitudes
+func (r *MongoRepository) update(ctx context.Context, id string, fields bson.M) (Review, error) {
+	var review Review
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return review, ErrReviewNotFound
+	}
+
+	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objectID}, bson.M{"$set": fields},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&review)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return review, ErrReviewNotFound
+	}
+	return review, err
+}
+
+func (r *MongoRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrReviewNotFound
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrReviewNotFound
+	}
+	return nil
+}
+
+func (r *MongoRepository) Stats(ctx context.Context, courseID string) (Stats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"course_id": courseID, "status": StatusVisible}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"average": bson.M{"$avg": "$rating"},
+			"count":   bson.M{"$sum": 1},
+		}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []Stats
+	if err := cursor.All(ctx, &results); err != nil {
+		return Stats{}, err
+	}
+	if len(results) == 0 {
+		return Stats{}, nil
+	}
+	return results[0].rounded(), nil
+}
@@ -0,0 +1,39 @@
+package reviews
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrReviewNotFound se devuelve cuando no existe la reseña buscada
+	ErrReviewNotFound = errors.New("reseña no encontrada")
+	// ErrReviewExists se devuelve cuando el usuario ya publicó una reseña del curso
+	ErrReviewExists = errors.New("ya publicaste una reseña de este curso")
+)
+
+// Filter selecciona reseñas; los campos vacíos no filtran
+type Filter struct {
+	CourseID string
+	Status   string
+	Limit    int
+	Offset   int
+}
+
+// Repository define el acceso a las reseñas de los cursos
+type Repository interface {
+	// Create guarda la reseña y completa su ID; devuelve ErrReviewExists si el usuario ya tiene una
+	Create(ctx context.Context, review *Review) error
+	GetByID(ctx context.Context, id string) (Review, error)
+	// List devuelve las reseñas del filtro de la más reciente a la más antigua y el total sin paginar
+	List(ctx context.Context, filter Filter) ([]Review, int64, error)
+	// UpdateContent cambia solo la calificación y el texto de la reseña y devuelve la reseña
+	// actualizada; no toca el estado de moderación
+	UpdateContent(ctx context.Context, id string, rating int, text string, at time.Time) (Review, error)
+	// Moderate cambia solo el estado y los datos de moderación y devuelve la reseña actualizada
+	Moderate(ctx context.Context, id, status string, moderatedBy int, note string, at time.Time) (Review, error)
+	Delete(ctx context.Context, id string) error
+	// Stats calcula el promedio y la cantidad de reseñas visibles del curso
+	Stats(ctx context.Context, courseID string) (Stats, error)
+}
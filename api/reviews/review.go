@@ -0,0 +1,45 @@
+package reviews
+
+import (
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Estados de moderación de una reseña
+const (
+	StatusVisible = "visible"
+	// StatusHidden es una reseña ocultada por un moderador; no cuenta para la calificación del curso
+	StatusHidden = "hidden"
+)
+
+// Review es la reseña de un usuario sobre un curso; cada usuario tiene a lo sumo una por curso
+type Review struct {
+	ID       primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	CourseID string             `json:"course_id" bson:"course_id"`
+	UserID   int                `json:"user_id" bson:"user_id"`
+	// UserName es una copia del nombre del autor al publicar la reseña
+	UserName  string    `json:"user_name" bson:"user_name"`
+	Rating    int       `json:"rating" bson:"rating"`
+	Text      string    `json:"text" bson:"text"`
+	Status    string    `json:"status" bson:"status"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+	// ModeratedBy y ModerationNote registran la última decisión de un moderador
+	ModeratedBy    int        `json:"moderated_by,omitempty" bson:"moderated_by,omitempty"`
+	ModeratedAt    *time.Time `json:"moderated_at,omitempty" bson:"moderated_at,omitempty"`
+	ModerationNote string     `json:"moderation_note,omitempty" bson:"moderation_note,omitempty"`
+}
+
+// Stats resume las reseñas visibles de un curso
+type Stats struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+// rounded devuelve el promedio con dos decimales
+func (s Stats) rounded() Stats {
+	s.Average = math.Round(s.Average*100) / 100
+	return s
+}
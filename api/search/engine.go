@@ -0,0 +1,152 @@
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Motores de búsqueda disponibles
+const (
+	EngineSolr   = "solr"
+	EngineMemory = "memory"
+)
+
+// durationBucket es un rango de duración (en horas) que se faceta; Max negativo indica sin límite
+type durationBucket struct {
+	Label    string
+	Min, Max int
+}
+
+var durationBuckets = []durationBucket{
+	{"0-5", 0, 5},
+	{"6-20", 6, 20},
+	{"21-50", 21, 50},
+	{"51+", 51, -1},
+}
+
+func (b durationBucket) contains(duration int) bool {
+	return duration >= b.Min && (b.Max < 0 || duration <= b.Max)
+}
+
+// Órdenes de los resultados
+const (
+	// SortRelevance ordena por puntaje de la búsqueda
+	SortRelevance = "relevance"
+	// SortRating ordena por calificación promedio y luego por cantidad de reseñas
+	SortRating = "rating"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 50
+)
+
+// Query describe una búsqueda de cursos. Category incluye las subcategorías y Tags exige todas
+// las etiquetas.
+type Query struct {
+	Text         string
+	Level        string
+	Availability *bool
+	MinDuration  *int
+	MaxDuration  *int
+	MinRating    *float64
+	Category     string
+	Tags         []string
+	Sort         string
+	Page         int
+	PageSize     int
+}
+
+// Hit es un curso encontrado junto con los fragmentos resaltados por campo
+type Hit struct {
+	Course
+	// Highlights son fragmentos HTML: el texto del curso viene escapado y las coincidencias entre <em>
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+// FacetValue es la cantidad de resultados para un valor de faceta
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets agrupa los conteos por nivel, disponibilidad, rango de duración, categoría y etiqueta.
+// Un curso cuenta también para los ancestros de su categoría.
+type Facets struct {
+	Level        []FacetValue `json:"level"`
+	Availability []FacetValue `json:"availability"`
+	Duration     []FacetValue `json:"duration"`
+	Categories   []FacetValue `json:"categories"`
+	Tags         []FacetValue `json:"tags"`
+}
+
+// Result es la respuesta estable de la API de búsqueda
+type Result struct {
+	Results    []Hit  `json:"results"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	TotalPages int    `json:"total_pages"`
+	Facets     Facets `json:"facets"`
+}
+
+// normalized completa la página y el tamaño por defecto
+func (q Query) normalized() Query {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = defaultPageSize
+	}
+	q.PageSize = min(q.PageSize, maxPageSize)
+	return q
+}
+
+// DocumentRef identifica un documento indexado y el hash del contenido con que se indexó
+type DocumentRef struct {
+	ID          string
+	ContentHash string
+}
+
+// WithContentHash devuelve el curso con ContentHash calculado sobre el resto de sus campos
+func (c Course) WithContentHash() Course {
+	c.ContentHash = ""
+	b, _ := json.Marshal(c)
+	sum := sha256.Sum256(b)
+	c.ContentHash = hex.EncodeToString(sum[:])
+	return c
+}
+
+// Engine es un motor de búsqueda de cursos
+type Engine interface {
+	// Index agrega o reemplaza un curso en el índice
+	Index(ctx context.Context, course Course) error
+	// Delete quita un curso del índice
+	Delete(ctx context.Context, id string) error
+	// Query busca cursos con filtros, facetas, resaltado y paginación
+	Query(ctx context.Context, q Query) (Result, error)
+	// Documents recorre los documentos indexados ordenados por ID, sin cargarlos todos en memoria
+	Documents(ctx context.Context, fn func(DocumentRef) error) error
+	// Reindex construye un índice nuevo con los cursos que entrega source, verifica la cantidad
+	// de documentos y recién entonces lo pone en uso; si falla el índice anterior sigue activo
+	Reindex(ctx context.Context, source Source) error
+}
+
+// Source entrega los cursos a indexar llamando a yield con cada uno, sin cargarlos todos en
+// memoria; si yield devuelve un error, Source debe detenerse y devolverlo
+type Source func(yield func(Course) error) error
+
+// NewEngine crea el motor indicado por la configuración
+func NewEngine(name, solrURL, solrConfigSet string) (Engine, error) {
+	switch name {
+	case EngineSolr:
+		return NewSolrClient(solrURL, solrConfigSet), nil
+	case EngineMemory:
+		return NewMemoryEngine(), nil
+	default:
+		return nil, fmt.Errorf("motor de búsqueda desconocido: %q", name)
+	}
+}
@@ -1,13 +1,17 @@
 package search
 
 import (
-	"io/ioutil"
+	"encoding/json"
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
 )
 
-// Course representa la estructura de un curso buscado en Solr
+// Course representa la estructura de un curso en el índice de búsqueda
 type Course struct {
 	ID           string `json:"id"`
 	Title        string `json:"title"`
@@ -16,39 +20,105 @@ type Course struct {
 	Duration     int    `json:"duration"`
 	Level        string `json:"level"`
 	Availability bool   `json:"availability"`
+	// Rating es el promedio de las reseñas y RatingCount su cantidad
+	Rating      float64 `json:"rating"`
+	RatingCount int     `json:"rating_count"`
+	// Categories son los IDs de la categoría del curso y sus ancestros; Tags los slugs de sus etiquetas
+	Categories []string `json:"categories,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	// ContentHash resume el contenido indexado; la reconciliación lo compara con MongoDB
+	ContentHash string `json:"content_hash,omitempty"`
 }
 
-// SearchCourses maneja la búsqueda de cursos utilizando Solr
-func SearchCourses(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
-		return
-	}
+// Handler agrupa los handlers HTTP de búsqueda
+type Handler struct {
+	engine Engine
+}
+
+// NewHandler crea los handlers de búsqueda sobre el motor dado
+func NewHandler(engine Engine) *Handler {
+	return &Handler{engine: engine}
+}
 
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		http.Error(w, "El parámetro 'q' es requerido", http.StatusBadRequest)
+// SearchCourses maneja la búsqueda de cursos en el motor configurado.
+// Parámetros: q, level, availability, min_duration, max_duration, min_rating, category, tag
+// (repetible), sort (relevance o rating), page y page_size.
+func (h *Handler) SearchCourses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		apierror.Write(w, r, apierror.ErrMethodNotAllowed)
 		return
 	}
 
-	solrQuery := "*" + query + "*"
-	solrURL := "http://localhost:8983/solr/courses/select?q=title:" + url.QueryEscape(solrQuery)
-
-	resp, err := http.Get(solrURL)
+	query, err := parseQuery(r.URL.Query())
 	if err != nil {
-		log.Println("Error al conectar con Solr:", err)
-		http.Error(w, "Error al conectar con el motor de búsqueda", http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.Validation(err))
 		return
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	result, err := h.engine.Query(r.Context(), query)
 	if err != nil {
-		log.Println("Error al leer la respuesta de Solr:", err)
-		http.Error(w, "Error al procesar la respuesta", http.StatusInternalServerError)
+		log.Println("Error al buscar cursos:", err)
+		apierror.Write(w, r, apierror.New(http.StatusBadGateway, "search_unavailable", "search.unavailable"))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Write(body)
+	json.NewEncoder(w).Encode(result)
+}
+
+// parseQuery interpreta y valida los parámetros de búsqueda
+func parseQuery(values url.Values) (Query, error) {
+	q := Query{
+		Text:     values.Get("q"),
+		Level:    values.Get("level"),
+		Category: values.Get("category"),
+	}
+	for _, tag := range values["tag"] {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			q.Tags = append(q.Tags, tag)
+		}
+	}
+
+	if v := values.Get("availability"); v != "" {
+		available, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, apierror.Field("availability", apierror.FieldInvalid, "validation.boolean", "availability")
+		}
+		q.Availability = &available
+	}
+
+	for name, target := range map[string]**int{"min_duration": &q.MinDuration, "max_duration": &q.MaxDuration} {
+		if v := values.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return q, apierror.Field(name, apierror.FieldInvalid, "validation.non_negative_integer", name)
+			}
+			*target = &n
+		}
+	}
+
+	if v := values.Get("min_rating"); v != "" {
+		rating, err := strconv.ParseFloat(v, 64)
+		if err != nil || rating < 0 || rating > 5 {
+			return q, apierror.Field("min_rating", apierror.FieldOutOfRange, "search.min_rating")
+		}
+		q.MinRating = &rating
+	}
+
+	switch q.Sort = values.Get("sort"); q.Sort {
+	case "", SortRelevance, SortRating:
+	default:
+		return q, apierror.Field("sort", apierror.FieldInvalid, "search.sort", SortRelevance, SortRating)
+	}
+
+	for name, target := range map[string]*int{"page": &q.Page, "page_size": &q.PageSize} {
+		if v := values.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return q, apierror.Field(name, apierror.FieldInvalid, "validation.positive_integer", name)
+			}
+			*target = n
+		}
+	}
+	return q, nil
 }
@@ -0,0 +1,379 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// memoryFields son los campos indexados y su peso, equivalentes a fieldBoosts
+var memoryFields = []struct {
+	Name  string
+	Boost float64
+	Value func(Course) string
+}{
+	{"title", 3, func(c Course) string { return c.Title }},
+	{"instructor", 2, func(c Course) string { return c.Instructor }},
+	{"description", 1, func(c Course) string { return c.Description }},
+}
+
+// MemoryEngine es un índice invertido en memoria, útil para desarrollo y pruebas sin Solr
+type MemoryEngine struct {
+	mu    sync.RWMutex
+	index *invertedIndex
+}
+
+// NewMemoryEngine crea un motor en memoria vacío
+func NewMemoryEngine() *MemoryEngine {
+	return &MemoryEngine{index: newInvertedIndex()}
+}
+
+// Index agrega o reemplaza el curso en el índice
+func (e *MemoryEngine) Index(ctx context.Context, course Course) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.index.remove(course.ID)
+	e.index.add(course)
+	return nil
+}
+
+// Delete quita el curso del índice
+func (e *MemoryEngine) Delete(ctx context.Context, id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.index.remove(id)
+	return nil
+}
+
+// Reindex construye un índice nuevo con los cursos de source y lo reemplaza de una vez
+func (e *MemoryEngine) Reindex(ctx context.Context, source Source) error {
+	index := newInvertedIndex()
+	count := 0
+	err := source(func(course Course) error {
+		index.remove(course.ID)
+		index.add(course)
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(index.docs) != count {
+		return fmt.Errorf("el índice nuevo tiene %d documentos y se esperaban %d", len(index.docs), count)
+	}
+
+	e.mu.Lock()
+	e.index = index
+	e.mu.Unlock()
+	return nil
+}
+
+// Documents recorre los cursos indexados ordenados por ID
+func (e *MemoryEngine) Documents(ctx context.Context, fn func(DocumentRef) error) error {
+	e.mu.RLock()
+	refs := make([]DocumentRef, 0, len(e.index.docs))
+	for id, course := range e.index.docs {
+		refs = append(refs, DocumentRef{ID: id, ContentHash: course.ContentHash})
+	}
+	e.mu.RUnlock()
+
+	sort.Slice(refs, func(i, j int) bool { return refs[i].ID < refs[j].ID })
+	for _, ref := range refs {
+		if err := fn(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query busca cursos con la misma semántica que la consulta de Solr: OR entre términos,
+// coincidencia por prefijo, filtros, facetas sobre los resultados filtrados y resaltado
+func (e *MemoryEngine) Query(ctx context.Context, q Query) (Result, error) {
+	q = q.normalized()
+	terms := tokenize(q.Text)
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var scores map[string]float64
+	if len(terms) == 0 {
+		scores = make(map[string]float64, len(e.index.docs))
+		for id := range e.index.docs {
+			scores[id] = 0
+		}
+	} else {
+		scores = e.index.score(terms)
+	}
+
+	hits := make([]Course, 0, len(scores))
+	for id := range scores {
+		if course := e.index.docs[id]; q.matches(course) {
+			hits = append(hits, course)
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if q.Sort == SortRating && a.Rating != b.Rating {
+			return a.Rating > b.Rating
+		}
+		if q.Sort == SortRating && a.RatingCount != b.RatingCount {
+			return a.RatingCount > b.RatingCount
+		}
+		if scores[a.ID] != scores[b.ID] {
+			return scores[a.ID] > scores[b.ID]
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.ID < b.ID
+	})
+
+	result := Result{
+		Results:    []Hit{},
+		Total:      len(hits),
+		Page:       q.Page,
+		PageSize:   q.PageSize,
+		TotalPages: (len(hits) + q.PageSize - 1) / q.PageSize,
+		Facets:     memoryFacets(hits),
+	}
+	start := min((q.Page-1)*q.PageSize, len(hits))
+	end := min(start+q.PageSize, len(hits))
+	for _, course := range hits[start:end] {
+		course.ContentHash = ""
+		result.Results = append(result.Results, Hit{Course: course, Highlights: highlight(course, terms)})
+	}
+	return result, nil
+}
+
+// matches aplica los filtros de nivel, disponibilidad, duración, calificación, categoría y etiquetas
+func (q Query) matches(course Course) bool {
+	if q.Level != "" && !strings.EqualFold(course.Level, q.Level) {
+		return false
+	}
+	if q.Availability != nil && course.Availability != *q.Availability {
+		return false
+	}
+	if q.MinDuration != nil && course.Duration < *q.MinDuration {
+		return false
+	}
+	if q.MaxDuration != nil && course.Duration > *q.MaxDuration {
+		return false
+	}
+	if q.MinRating != nil && course.Rating < *q.MinRating {
+		return false
+	}
+	if q.Category != "" && !slices.Contains(course.Categories, q.Category) {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !slices.Contains(course.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// invertedIndex relaciona cada término con los cursos y campos donde aparece
+type invertedIndex struct {
+	docs     map[string]Course
+	postings map[string]map[string]float64
+	// terms queda ordenado para resolver las búsquedas por prefijo
+	terms []string
+}
+
+func newInvertedIndex() *invertedIndex {
+	return &invertedIndex{
+		docs:     make(map[string]Course),
+		postings: make(map[string]map[string]float64),
+	}
+}
+
+func (idx *invertedIndex) add(course Course) {
+	idx.docs[course.ID] = course
+	for _, field := range memoryFields {
+		for _, term := range tokenize(field.Value(course)) {
+			docs, ok := idx.postings[term]
+			if !ok {
+				docs = make(map[string]float64)
+				idx.postings[term] = docs
+				i := sort.SearchStrings(idx.terms, term)
+				idx.terms = append(idx.terms, "")
+				copy(idx.terms[i+1:], idx.terms[i:])
+				idx.terms[i] = term
+			}
+			docs[course.ID] += field.Boost
+		}
+	}
+}
+
+func (idx *invertedIndex) remove(id string) {
+	course, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	delete(idx.docs, id)
+	for _, field := range memoryFields {
+		for _, term := range tokenize(field.Value(course)) {
+			docs := idx.postings[term]
+			delete(docs, id)
+			if len(docs) == 0 {
+				delete(idx.postings, term)
+				if i := sort.SearchStrings(idx.terms, term); i < len(idx.terms) && idx.terms[i] == term {
+					idx.terms = append(idx.terms[:i], idx.terms[i+1:]...)
+				}
+			}
+		}
+	}
+}
+
+// score suma el peso de cada término buscado; las coincidencias exactas valen el doble que las por prefijo
+func (idx *invertedIndex) score(terms []string) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, term := range terms {
+		for i := sort.SearchStrings(idx.terms, term); i < len(idx.terms) && strings.HasPrefix(idx.terms[i], term); i++ {
+			weight := 1.0
+			if idx.terms[i] == term {
+				weight = 2
+			}
+			for id, boost := range idx.postings[idx.terms[i]] {
+				scores[id] += boost * weight
+			}
+		}
+	}
+	return scores
+}
+
+// memoryFacets cuenta los resultados por nivel, disponibilidad, rango de duración, categoría y etiqueta
+func memoryFacets(courses []Course) Facets {
+	levels := map[string]int{}
+	categories := map[string]int{}
+	tags := map[string]int{}
+	availability := map[string]int{"true": 0, "false": 0}
+	durations := make([]int, len(durationBuckets))
+	for _, course := range courses {
+		if course.Level != "" {
+			levels[course.Level]++
+		}
+		availability[strconv.FormatBool(course.Availability)]++
+		for _, category := range course.Categories {
+			categories[category]++
+		}
+		for _, tag := range course.Tags {
+			tags[tag]++
+		}
+		for i, bucket := range durationBuckets {
+			if bucket.contains(course.Duration) {
+				durations[i]++
+			}
+		}
+	}
+
+	facets := Facets{
+		Level:        sortedFacetValues(levels),
+		Availability: sortedFacetValues(availability),
+		Duration:     make([]FacetValue, 0, len(durationBuckets)),
+		Categories:   sortedFacetValues(categories),
+		Tags:         sortedFacetValues(tags),
+	}
+	for i, bucket := range durationBuckets {
+		facets.Duration = append(facets.Duration, FacetValue{Value: bucket.Label, Count: durations[i]})
+	}
+	return facets
+}
+
+// sortedFacetValues ordena como Solr: por conteo descendente y luego por valor
+func sortedFacetValues(counts map[string]int) []FacetValue {
+	values := make([]FacetValue, 0, len(counts))
+	for value, count := range counts {
+		values = append(values, FacetValue{Value: value, Count: count})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	return values
+}
+
+// highlight envuelve en <em> las palabras de cada campo que coinciden con algún término; el resto
+// del texto se escapa como HTML, igual que con hl.encoder=html en Solr
+func highlight(course Course, terms []string) map[string][]string {
+	if len(terms) == 0 {
+		return nil
+	}
+	highlights := make(map[string][]string)
+	for _, field := range memoryFields {
+		text := field.Value(course)
+		var b strings.Builder
+		found := false
+		last := 0
+		for _, span := range wordSpans(text) {
+			word := fold(text[span[0]:span[1]])
+			for _, term := range terms {
+				if strings.HasPrefix(word, term) {
+					b.WriteString(html.EscapeString(text[last:span[0]]))
+					b.WriteString("<em>" + html.EscapeString(text[span[0]:span[1]]) + "</em>")
+					last = span[1]
+					found = true
+					break
+				}
+			}
+		}
+		if found {
+			b.WriteString(html.EscapeString(text[last:]))
+			highlights[field.Name] = []string{b.String()}
+		}
+	}
+	return highlights
+}
+
+// tokenize divide el texto en términos normalizados
+func tokenize(text string) []string {
+	spans := wordSpans(text)
+	terms := make([]string, 0, len(spans))
+	for _, span := range spans {
+		terms = append(terms, fold(text[span[0]:span[1]]))
+	}
+	return terms
+}
+
+// wordSpans devuelve las posiciones de inicio y fin de cada palabra del texto
+func wordSpans(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
+
+// fold pasa a minúsculas y quita los acentos para que "Programación" coincida con "programacion"
+func fold(word string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, word)
+	if err != nil {
+		folded = word
+	}
+	return strings.ToLower(folded)
+}
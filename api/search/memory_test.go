@@ -0,0 +1,201 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// testCourses son los cursos indexados por newTestEngine
+var testCourses = []Course{
+	{ID: "c1", Title: "Go desde cero", Instructor: "Ada", Description: "Primeros pasos con Go", Duration: 4, Level: "beginner", Availability: true, Rating: 4.5, RatingCount: 10, Categories: []string{"prog", "prog-go"}, Tags: []string{"go", "backend"}},
+	{ID: "c2", Title: "Concurrencia en Go", Instructor: "Grace", Description: "Goroutines y canales", Duration: 12, Level: "advanced", Availability: true, Rating: 4.9, RatingCount: 3, Categories: []string{"prog", "prog-go"}, Tags: []string{"go", "concurrencia"}},
+	{ID: "c3", Title: "Python para datos", Instructor: "Alan", Description: "Pandas y gráficos", Duration: 30, Level: "intermediate", Availability: false, Rating: 4.9, RatingCount: 20, Categories: []string{"prog", "prog-python"}, Tags: []string{"python", "datos"}},
+	{ID: "c4", Title: "Diseño de APIs", Instructor: "Ada", Description: "REST con ejemplos en Go", Duration: 60, Level: "intermediate", Availability: true, Rating: 3.8, RatingCount: 5, Categories: []string{"arq"}, Tags: []string{"backend"}},
+	{ID: "c5", Title: "Historia del arte", Instructor: "Frida", Description: "Del Renacimiento al presente", Duration: 8, Level: "beginner", Availability: true},
+}
+
+// newTestEngine crea un motor en memoria con testCourses
+func newTestEngine(t *testing.T) *MemoryEngine {
+	t.Helper()
+	engine := NewMemoryEngine()
+	for _, course := range testCourses {
+		if err := engine.Index(context.Background(), course); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return engine
+}
+
+// resultIDs devuelve los IDs de los resultados en orden
+func resultIDs(result Result) []string {
+	ids := make([]string, 0, len(result.Results))
+	for _, hit := range result.Results {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestMemoryQueryFilters(t *testing.T) {
+	engine := newTestEngine(t)
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{"sin filtros", Query{}, []string{"c1", "c2", "c3", "c4", "c5"}},
+		{"texto", Query{Text: "go"}, []string{"c1", "c2", "c4"}},
+		{"texto por prefijo", Query{Text: "concu"}, []string{"c2"}},
+		{"texto sin acentos", Query{Text: "diseno"}, []string{"c4"}},
+		{"nivel", Query{Level: "beginner"}, []string{"c1", "c5"}},
+		{"disponibles", Query{Availability: ptr(true)}, []string{"c1", "c2", "c4", "c5"}},
+		{"no disponibles", Query{Availability: ptr(false)}, []string{"c3"}},
+		{"duración mínima", Query{MinDuration: ptr(12)}, []string{"c2", "c3", "c4"}},
+		{"rango de duración", Query{MinDuration: ptr(5), MaxDuration: ptr(30)}, []string{"c2", "c3", "c5"}},
+		{"calificación mínima", Query{MinRating: ptr(4.5)}, []string{"c1", "c2", "c3"}},
+		{"categoría con subcategorías", Query{Category: "prog"}, []string{"c1", "c2", "c3"}},
+		{"subcategoría", Query{Category: "prog-go"}, []string{"c1", "c2"}},
+		{"todas las etiquetas", Query{Tags: []string{"go", "backend"}}, []string{"c1"}},
+		{"texto y filtros", Query{Text: "go", Level: "intermediate"}, []string{"c4"}},
+		{"sin resultados", Query{Text: "cocina"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Query(context.Background(), tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := resultIDs(result)
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) || result.Total != len(tt.want) {
+				t.Errorf("resultados %v (total %d), se esperaban %v", got, result.Total, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryQueryFacets(t *testing.T) {
+	engine := newTestEngine(t)
+	result, err := engine.Query(context.Background(), Query{Availability: ptr(true)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  []FacetValue
+		want []FacetValue
+	}{
+		{"nivel", result.Facets.Level, []FacetValue{{"beginner", 2}, {"advanced", 1}, {"intermediate", 1}}},
+		{"disponibilidad", result.Facets.Availability, []FacetValue{{"true", 4}, {"false", 0}}},
+		{"duración", result.Facets.Duration, []FacetValue{{"0-5", 1}, {"6-20", 2}, {"21-50", 0}, {"51+", 1}}},
+		{"categorías", result.Facets.Categories, []FacetValue{{"prog", 2}, {"prog-go", 2}, {"arq", 1}}},
+		{"etiquetas", result.Facets.Tags, []FacetValue{{"backend", 2}, {"go", 2}, {"concurrencia", 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !slices.Equal(tt.got, tt.want) {
+				t.Errorf("faceta %v, se esperaba %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryQuerySort(t *testing.T) {
+	engine := newTestEngine(t)
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		// c1 tiene "go" en el título y la descripción, c2 en el título y "goroutines" por prefijo, y
+		// c4 solo en la descripción
+		{"relevancia", Query{Text: "go"}, []string{"c1", "c2", "c4"}},
+		// Sin texto todos puntúan igual y se ordenan por título
+		{"sin texto por título", Query{}, []string{"c2", "c4", "c1", "c5", "c3"}},
+		// A igual calificación desempata la cantidad de reseñas
+		{"calificación", Query{Sort: SortRating}, []string{"c3", "c2", "c1", "c4", "c5"}},
+		{"calificación con texto", Query{Text: "go", Sort: SortRating}, []string{"c2", "c1", "c4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Query(context.Background(), tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resultIDs(result); !slices.Equal(got, tt.want) {
+				t.Errorf("orden %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryQueryPages(t *testing.T) {
+	engine := newTestEngine(t)
+	tests := []struct {
+		query      Query
+		want       []string
+		page, size int
+		totalPages int
+	}{
+		{Query{PageSize: 2}, []string{"c2", "c4"}, 1, 2, 3},
+		{Query{PageSize: 2, Page: 2}, []string{"c1", "c5"}, 2, 2, 3},
+		{Query{PageSize: 2, Page: 3}, []string{"c3"}, 3, 2, 3},
+		{Query{PageSize: 2, Page: 4}, []string{}, 4, 2, 3},
+		// Los valores fuera de rango usan la primera página, el tamaño por defecto y el máximo
+		{Query{Page: -1}, []string{"c2", "c4", "c1", "c5", "c3"}, 1, defaultPageSize, 1},
+		{Query{PageSize: 1000}, []string{"c2", "c4", "c1", "c5", "c3"}, 1, maxPageSize, 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("página %d de %d", tt.query.Page, tt.query.PageSize), func(t *testing.T) {
+			result, err := engine.Query(context.Background(), tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resultIDs(result); !slices.Equal(got, tt.want) {
+				t.Errorf("resultados %v, se esperaban %v", got, tt.want)
+			}
+			if result.Page != tt.page || result.PageSize != tt.size || result.TotalPages != tt.totalPages || result.Total != len(testCourses) {
+				t.Errorf("página %d, tamaño %d, %d páginas, total %d", result.Page, result.PageSize, result.TotalPages, result.Total)
+			}
+		})
+	}
+}
+
+func TestHighlightsEscapeHTML(t *testing.T) {
+	engine := NewMemoryEngine()
+	course := Course{
+		ID:          "c1",
+		Title:       `Go <script>alert("x")</script>`,
+		Description: "Aprende Go & <b>concurrencia</b>",
+	}
+	if err := engine.Index(context.Background(), course); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := engine.Query(context.Background(), Query{Text: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Results) != 1 {
+		t.Fatalf("%d resultados, se esperaba 1", len(result.Results))
+	}
+	want := map[string]string{
+		"title":       `<em>Go</em> &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;`,
+		"description": "Aprende <em>Go</em> &amp; &lt;b&gt;concurrencia&lt;/b&gt;",
+	}
+	for field, snippet := range want {
+		if got := result.Results[0].Highlights[field]; len(got) != 1 || got[0] != snippet {
+			t.Errorf("resaltado de %s: %q, se esperaba %q", field, got, snippet)
+		}
+	}
+
+	if encoder := (Query{Text: "go"}).solrParams().Get("hl.encoder"); encoder != "html" {
+		t.Errorf("hl.encoder = %q, se esperaba html", encoder)
+	}
+}
@@ -0,0 +1,390 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fieldBoosts define los campos consultados y su peso relativo
+const fieldBoosts = "title^3 instructor^2 description"
+
+// SolrClient consulta la colección de cursos de Solr
+type SolrClient struct {
+	baseURL string
+	http    *http.Client
+	// configSet es el conjunto de configuración usado al crear colecciones en Reindex
+	configSet string
+}
+
+// NewSolrClient crea un cliente para la colección indicada, por ejemplo http://localhost:8983/solr/courses.
+// Para reindexar sin cortes el último segmento de la URL debe ser un alias de SolrCloud; con Solr
+// sin SolrCloud puede ser un núcleo y Reindex lo actualiza en su lugar.
+func NewSolrClient(baseURL, configSet string) *SolrClient {
+	return &SolrClient{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		http:      &http.Client{Timeout: 10 * time.Second},
+		configSet: configSet,
+	}
+}
+
+// commitWithin es el plazo en milisegundos en que Solr hace visibles los cambios de cada evento;
+// deja que Solr agrupe los commits en lugar de forzar uno duro por cada curso
+const commitWithin = "1000"
+
+// Index agrega o reemplaza el curso en Solr
+func (c *SolrClient) Index(ctx context.Context, course Course) error {
+	return c.update(ctx, "/update/json/docs?commitWithin="+commitWithin, solrDocument(course))
+}
+
+// solrDocument arma el documento del curso. La calificación, las categorías y las etiquetas usan
+// los campos dinámicos *_d, *_i y *_ss del configset para que Solr no deduzca el tipo del primer
+// valor: así se puede ordenar por calificación y facetar los valores sin tokenizar.
+func solrDocument(course Course) map[string]interface{} {
+	doc := map[string]interface{}{}
+	if b, err := json.Marshal(course); err == nil {
+		json.Unmarshal(b, &doc)
+	}
+	for _, field := range []string{"rating", "rating_count", "categories", "tags"} {
+		delete(doc, field)
+	}
+	doc["rating_d"] = course.Rating
+	doc["rating_count_i"] = course.RatingCount
+	if len(course.Categories) > 0 {
+		doc["categories_ss"] = course.Categories
+	}
+	if len(course.Tags) > 0 {
+		doc["tags_ss"] = course.Tags
+	}
+	return doc
+}
+
+// Delete quita el curso de Solr
+func (c *SolrClient) Delete(ctx context.Context, id string) error {
+	return c.update(ctx, "/update?commitWithin="+commitWithin, map[string]interface{}{
+		"delete": map[string]string{"id": id},
+	})
+}
+
+// documentsPageSize es la cantidad de documentos pedidos por página al recorrer el índice
+const documentsPageSize = 1000
+
+// Documents recorre el índice con cursorMark, pidiendo solo el ID y el hash de contenido
+func (c *SolrClient) Documents(ctx context.Context, fn func(DocumentRef) error) error {
+	mark := "*"
+	for {
+		params := url.Values{}
+		params.Set("wt", "json")
+		params.Set("q", "*:*")
+		params.Set("fl", "id,content_hash")
+		params.Set("sort", "id asc")
+		params.Set("rows", strconv.Itoa(documentsPageSize))
+		params.Set("cursorMark", mark)
+
+		var body struct {
+			Response struct {
+				Docs []solrDoc `json:"docs"`
+			} `json:"response"`
+			NextCursorMark string `json:"nextCursorMark"`
+		}
+		if err := c.get(ctx, "/select?"+params.Encode(), &body); err != nil {
+			return err
+		}
+
+		for _, doc := range body.Response.Docs {
+			ref := DocumentRef{ID: doc.string("id"), ContentHash: doc.string("content_hash")}
+			if err := fn(ref); err != nil {
+				return err
+			}
+		}
+		if body.NextCursorMark == "" || body.NextCursorMark == mark {
+			return nil
+		}
+		mark = body.NextCursorMark
+	}
+}
+
+// update envía un documento JSON al handler de actualización de Solr y verifica la respuesta
+func (c *SolrClient) update(ctx context.Context, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error al crear el JSON para Solr: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("error al conectar con Solr: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error en la respuesta de Solr: %s", resp.Status)
+	}
+	return nil
+}
+
+// Query ejecuta la consulta en Solr y traduce la respuesta al formato de la API
+func (c *SolrClient) Query(ctx context.Context, q Query) (Result, error) {
+	q = q.normalized()
+
+	var body solrResponse
+	if err := c.get(ctx, "/select?"+q.solrParams().Encode(), &body); err != nil {
+		return Result{}, err
+	}
+	return body.toResult(q), nil
+}
+
+// get hace una consulta GET a la colección y decodifica la respuesta JSON en out
+func (c *SolrClient) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("error al conectar con Solr: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error en la respuesta de Solr: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error al leer la respuesta de Solr: %w", err)
+	}
+	return nil
+}
+
+// solrParams construye los parámetros de la consulta edismax con filtros, facetas y resaltado
+func (q Query) solrParams() url.Values {
+	params := url.Values{}
+	params.Set("wt", "json")
+	params.Set("defType", "edismax")
+	params.Set("qf", fieldBoosts)
+	params.Set("pf", "title^5")
+	params.Set("q.alt", "*:*")
+	if text := strings.TrimSpace(q.Text); text != "" {
+		params.Set("q", text)
+	}
+	params.Set("start", strconv.Itoa((q.Page-1)*q.PageSize))
+	params.Set("rows", strconv.Itoa(q.PageSize))
+
+	// Filtros
+	if q.Level != "" {
+		params.Add("fq", "level:"+quote(q.Level))
+	}
+	if q.Availability != nil {
+		params.Add("fq", "availability:"+strconv.FormatBool(*q.Availability))
+	}
+	if q.MinDuration != nil || q.MaxDuration != nil {
+		from, to := "*", "*"
+		if q.MinDuration != nil {
+			from = strconv.Itoa(*q.MinDuration)
+		}
+		if q.MaxDuration != nil {
+			to = strconv.Itoa(*q.MaxDuration)
+		}
+		params.Add("fq", "duration:["+from+" TO "+to+"]")
+	}
+	if q.MinRating != nil {
+		params.Add("fq", "rating_d:["+strconv.FormatFloat(*q.MinRating, 'f', -1, 64)+" TO *]")
+	}
+	if q.Category != "" {
+		params.Add("fq", "categories_ss:"+quote(q.Category))
+	}
+	for _, tag := range q.Tags {
+		params.Add("fq", "tags_ss:"+quote(tag))
+	}
+	if q.Sort == SortRating {
+		params.Set("sort", "rating_d desc,rating_count_i desc,score desc")
+	}
+
+	// Facetas
+	params.Set("facet", "true")
+	params.Set("facet.mincount", "0")
+	params.Add("facet.field", "level")
+	params.Add("facet.field", "availability")
+	params.Add("facet.field", "categories_ss")
+	params.Add("facet.field", "tags_ss")
+	// Las categorías y etiquetas sin resultados no se listan
+	params.Set("f.categories_ss.facet.mincount", "1")
+	params.Set("f.tags_ss.facet.mincount", "1")
+	for _, bucket := range durationBuckets {
+		params.Add("facet.query", bucket.solrQuery())
+	}
+
+	// Resaltado
+	params.Set("hl", "true")
+	params.Set("hl.fl", "title,description,instructor")
+	params.Set("hl.snippets", "2")
+	params.Set("hl.simple.pre", "<em>")
+	params.Set("hl.simple.post", "</em>")
+	// Escapar el HTML del texto para que los fragmentos solo contengan las marcas <em>
+	params.Set("hl.encoder", "html")
+	return params
+}
+
+// quote escapa un valor para usarlo como frase en una consulta de Solr
+func quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// solrQuery devuelve la consulta de faceta de Solr para el rango
+func (b durationBucket) solrQuery() string {
+	to := "*"
+	if b.Max >= 0 {
+		to = strconv.Itoa(b.Max)
+	}
+	return "duration:[" + strconv.Itoa(b.Min) + " TO " + to + "]"
+}
+
+// solrResponse es el subconjunto de la respuesta de Solr que usa la API
+type solrResponse struct {
+	Response struct {
+		NumFound int       `json:"numFound"`
+		Docs     []solrDoc `json:"docs"`
+	} `json:"response"`
+	FacetCounts struct {
+		FacetFields  map[string][]interface{} `json:"facet_fields"`
+		FacetQueries map[string]int           `json:"facet_queries"`
+	} `json:"facet_counts"`
+	Highlighting map[string]map[string][]string `json:"highlighting"`
+}
+
+func (r solrResponse) toResult(q Query) Result {
+	result := Result{
+		Results:  make([]Hit, 0, len(r.Response.Docs)),
+		Total:    r.Response.NumFound,
+		Page:     q.Page,
+		PageSize: q.PageSize,
+	}
+	result.TotalPages = (result.Total + q.PageSize - 1) / q.PageSize
+
+	for _, doc := range r.Response.Docs {
+		course := doc.course()
+		result.Results = append(result.Results, Hit{
+			Course:     course,
+			Highlights: r.Highlighting[course.ID],
+		})
+	}
+
+	result.Facets.Level = facetValues(r.FacetCounts.FacetFields["level"])
+	result.Facets.Availability = facetValues(r.FacetCounts.FacetFields["availability"])
+	result.Facets.Categories = facetValues(r.FacetCounts.FacetFields["categories_ss"])
+	result.Facets.Tags = facetValues(r.FacetCounts.FacetFields["tags_ss"])
+	result.Facets.Duration = make([]FacetValue, 0, len(durationBuckets))
+	for _, bucket := range durationBuckets {
+		result.Facets.Duration = append(result.Facets.Duration, FacetValue{
+			Value: bucket.Label,
+			Count: r.FacetCounts.FacetQueries[bucket.solrQuery()],
+		})
+	}
+	return result
+}
+
+// facetValues convierte la lista plana [valor, conteo, valor, conteo...] de Solr
+func facetValues(flat []interface{}) []FacetValue {
+	values := make([]FacetValue, 0, len(flat)/2)
+	for i := 0; i+1 < len(flat); i += 2 {
+		count, _ := flat[i+1].(float64)
+		values = append(values, FacetValue{Value: fmt.Sprint(flat[i]), Count: int(count)})
+	}
+	return values
+}
+
+// solrDoc es un documento de Solr; en modo sin esquema los campos pueden llegar como listas
+type solrDoc map[string]interface{}
+
+func (d solrDoc) first(field string) interface{} {
+	value := d[field]
+	if list, ok := value.([]interface{}); ok {
+		if len(list) == 0 {
+			return nil
+		}
+		return list[0]
+	}
+	return value
+}
+
+func (d solrDoc) string(field string) string {
+	if value := d.first(field); value != nil {
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+func (d solrDoc) strings(field string) []string {
+	var values []string
+	switch value := d[field].(type) {
+	case []interface{}:
+		for _, v := range value {
+			values = append(values, fmt.Sprint(v))
+		}
+	case string:
+		values = append(values, value)
+	}
+	return values
+}
+
+func (d solrDoc) int(field string) int {
+	switch value := d.first(field).(type) {
+	case float64:
+		return int(value)
+	case string:
+		n, _ := strconv.Atoi(value)
+		return n
+	}
+	return 0
+}
+
+func (d solrDoc) float(field string) float64 {
+	switch value := d.first(field).(type) {
+	case float64:
+		return value
+	case string:
+		f, _ := strconv.ParseFloat(value, 64)
+		return f
+	}
+	return 0
+}
+
+func (d solrDoc) bool(field string) bool {
+	switch value := d.first(field).(type) {
+	case bool:
+		return value
+	case string:
+		b, _ := strconv.ParseBool(value)
+		return b
+	}
+	return false
+}
+
+func (d solrDoc) course() Course {
+	return Course{
+		ID:           d.string("id"),
+		Title:        d.string("title"),
+		Description:  d.string("description"),
+		Instructor:   d.string("instructor"),
+		Duration:     d.int("duration"),
+		Level:        d.string("level"),
+		Availability: d.bool("availability"),
+		Rating:       d.float("rating_d"),
+		RatingCount:  d.int("rating_count_i"),
+		Categories:   d.strings("categories_ss"),
+		Tags:         d.strings("tags_ss"),
+	}
+}
@@ -0,0 +1,246 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// reindexBatchSize es la cantidad de documentos enviados por solicitud al reindexar
+const reindexBatchSize = 500
+
+// Reindex reconstruye el índice. En SolrCloud crea una colección nueva, la carga en lotes,
+// verifica la cantidad de documentos y cambia el alias de una sola vez; las colecciones a las que
+// apuntaba el alias se borran al final. En Solr sin SolrCloud reindexa el núcleo en su lugar.
+func (c *SolrClient) Reindex(ctx context.Context, source Source) error {
+	root, alias := c.collectionsRoot()
+	cloud, err := c.isCloud(ctx, root)
+	if err != nil {
+		return err
+	}
+	if !cloud {
+		return c.reindexInPlace(ctx, source)
+	}
+	collection := fmt.Sprintf("%s_%s", alias, time.Now().UTC().Format("20060102150405"))
+
+	previous, err := c.aliasCollections(ctx, root, alias)
+	if err != nil {
+		return err
+	}
+
+	err = c.collectionsAction(ctx, root, url.Values{
+		"action":                {"CREATE"},
+		"name":                  {collection},
+		"numShards":             {"1"},
+		"collection.configName": {c.configSet},
+	})
+	if err != nil {
+		return fmt.Errorf("error al crear la colección %s: %w", collection, err)
+	}
+
+	if err := c.loadCollection(ctx, root+"/"+collection, source); err != nil {
+		c.dropCollection(root, collection)
+		return err
+	}
+
+	err = c.collectionsAction(ctx, root, url.Values{
+		"action":      {"CREATEALIAS"},
+		"name":        {alias},
+		"collections": {collection},
+	})
+	if err != nil {
+		c.dropCollection(root, collection)
+		return fmt.Errorf("error al apuntar el alias %s a %s: %w", alias, collection, err)
+	}
+	log.Printf("Alias %s apunta ahora a %s", alias, collection)
+
+	for _, old := range previous {
+		if old != collection {
+			c.dropCollection(root, old)
+		}
+	}
+	return nil
+}
+
+// collectionsRoot separa la URL base en la raíz de Solr y el nombre del alias
+func (c *SolrClient) collectionsRoot() (root, alias string) {
+	i := strings.LastIndex(c.baseURL, "/")
+	return c.baseURL[:i], c.baseURL[i+1:]
+}
+
+// isCloud indica si Solr corre en modo SolrCloud, que es el único con Collections API
+func (c *SolrClient) isCloud(ctx context.Context, root string) (bool, error) {
+	var body struct {
+		Mode string `json:"mode"`
+	}
+	info := &SolrClient{baseURL: root, http: c.http}
+	if err := info.get(ctx, "/admin/info/system?wt=json", &body); err != nil {
+		return false, fmt.Errorf("error al consultar el modo de Solr: %w", err)
+	}
+	return body.Mode == "solrcloud", nil
+}
+
+// loadCollection envía los cursos en lotes sin confirmar, confirma una vez y compara la cantidad indexada
+func (c *SolrClient) loadCollection(ctx context.Context, collectionURL string, source Source) error {
+	target := &SolrClient{baseURL: collectionURL, http: c.http}
+	count, err := target.sendBatches(ctx, source, nil)
+	if err != nil {
+		return err
+	}
+	if err := target.commit(ctx); err != nil {
+		return err
+	}
+	return target.verifyCount(ctx, count, "la colección nueva")
+}
+
+// reindexInPlace reindexa un núcleo de Solr sin SolrCloud, donde no se puede cambiar de índice:
+// reemplaza todos los cursos, confirma y recién entonces borra los documentos que ya no existen.
+// Las búsquedas nunca ven el índice vacío, pero pueden ver parte de los cursos actualizados
+// mientras dura la carga.
+func (c *SolrClient) reindexInPlace(ctx context.Context, source Source) error {
+	log.Println("Solr no corre en modo SolrCloud: se reindexa el núcleo en su lugar")
+	seen := make(map[string]bool)
+	count, err := c.sendBatches(ctx, source, seen)
+	if err != nil {
+		return err
+	}
+	if err := c.commit(ctx); err != nil {
+		return err
+	}
+
+	var stale []string
+	err = c.Documents(ctx, func(ref DocumentRef) error {
+		if !seen[ref.ID] {
+			stale = append(stale, ref.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(stale); start += reindexBatchSize {
+		end := min(start+reindexBatchSize, len(stale))
+		if err := c.update(ctx, "/update", map[string]interface{}{"delete": stale[start:end]}); err != nil {
+			return fmt.Errorf("error al borrar los cursos que ya no existen: %w", err)
+		}
+	}
+	if len(stale) > 0 {
+		if err := c.commit(ctx); err != nil {
+			return err
+		}
+	}
+	return c.verifyCount(ctx, count, "el núcleo")
+}
+
+// sendBatches envía los cursos de source en lotes de reindexBatchSize sin confirmarlos y devuelve
+// cuántos envió; si seen no es nil registra en él los IDs enviados
+func (c *SolrClient) sendBatches(ctx context.Context, source Source, seen map[string]bool) (int, error) {
+	count := 0
+	docs := make([]map[string]interface{}, 0, reindexBatchSize)
+	flush := func() error {
+		if len(docs) == 0 {
+			return nil
+		}
+		if err := c.update(ctx, "/update", docs); err != nil {
+			return fmt.Errorf("error al cargar el lote %d-%d: %w", count-len(docs), count, err)
+		}
+		docs = docs[:0]
+		return nil
+	}
+
+	err := source(func(course Course) error {
+		docs = append(docs, solrDocument(course))
+		count++
+		if seen != nil {
+			seen[course.ID] = true
+		}
+		if len(docs) == reindexBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := flush(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (c *SolrClient) commit(ctx context.Context) error {
+	return c.update(ctx, "/update", map[string]interface{}{"commit": map[string]string{}})
+}
+
+// verifyCount compara la cantidad de documentos indexados con la esperada; what nombra el índice
+// en el mensaje de error
+func (c *SolrClient) verifyCount(ctx context.Context, expected int, what string) error {
+	result, err := c.Query(ctx, Query{PageSize: 1})
+	if err != nil {
+		return err
+	}
+	if result.Total != expected {
+		return fmt.Errorf("%s tiene %d documentos y se esperaban %d", what, result.Total, expected)
+	}
+	return nil
+}
+
+// aliasCollections devuelve las colecciones a las que apunta el alias, si existe
+func (c *SolrClient) aliasCollections(ctx context.Context, root, alias string) ([]string, error) {
+	var body struct {
+		Aliases map[string]string `json:"aliases"`
+	}
+	if err := c.collectionsRequest(ctx, root, url.Values{"action": {"LISTALIASES"}}, &body); err != nil {
+		return nil, fmt.Errorf("error al listar los alias de Solr: %w", err)
+	}
+	if body.Aliases[alias] == "" {
+		return nil, nil
+	}
+	return strings.Split(body.Aliases[alias], ","), nil
+}
+
+// dropCollection borra una colección; los errores solo se registran
+func (c *SolrClient) dropCollection(root, collection string) {
+	err := c.collectionsAction(context.Background(), root, url.Values{
+		"action": {"DELETE"},
+		"name":   {collection},
+	})
+	if err != nil {
+		log.Printf("Error al borrar la colección %s: %v", collection, err)
+	}
+}
+
+func (c *SolrClient) collectionsAction(ctx context.Context, root string, params url.Values) error {
+	return c.collectionsRequest(ctx, root, params, nil)
+}
+
+// collectionsRequest llama a la Collections API y decodifica la respuesta en out si no es nil
+func (c *SolrClient) collectionsRequest(ctx context.Context, root string, params url.Values, out interface{}) error {
+	params.Set("wt", "json")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, root+"/admin/collections?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	// Crear una colección puede tardar más que una consulta
+	client := *c.http
+	client.Timeout = time.Minute
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error al conectar con Solr: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error en la respuesta de Solr: %s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
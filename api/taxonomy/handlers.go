@@ -0,0 +1,489 @@
+package taxonomy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/courses"
+	"github.com/hugodiazo/arq-soft-2/api/validate"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/queue"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Errores de la API de taxonomía
+var (
+	errCourseNotFound   = apierror.New(http.StatusNotFound, "course_not_found", "courses.not_found")
+	errCourseArchived   = apierror.New(http.StatusConflict, "course_archived", "courses.archived")
+	errCategoryNotFound = apierror.New(http.StatusNotFound, "category_not_found", "taxonomy.category_not_found")
+	errCategoryExists   = apierror.New(http.StatusConflict, "category_exists", "taxonomy.category_exists")
+	errCategoryInUse    = apierror.New(http.StatusConflict, "category_in_use", "taxonomy.category_has_children")
+	errTagNotFound      = apierror.New(http.StatusNotFound, "tag_not_found", "taxonomy.tag_not_found")
+	errTagExists        = apierror.New(http.StatusConflict, "tag_exists", "taxonomy.tag_exists")
+)
+
+// Handler agrupa los handlers HTTP de categorías, etiquetas y clasificación de cursos
+type Handler struct {
+	categories CategoryRepository
+	tags       TagRepository
+	courses    courses.CourseRepository
+	events     queue.Broker
+	policy     auth.Policy
+}
+
+// NewHandler crea los handlers de taxonomía; events recibe los cursos cuya clasificación cambió
+// para reindexarlos
+func NewHandler(categories CategoryRepository, tags TagRepository, courseRepo courses.CourseRepository, events queue.Broker, policy auth.Policy) *Handler {
+	return &Handler{
+		categories: categories,
+		tags:       tags,
+		courses:    courseRepo,
+		events:     events,
+		policy:     policy,
+	}
+}
+
+// categoryInput son los campos editables de una categoría
+type categoryInput struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	ParentID string `json:"parent_id" validate:"omitempty,objectid"`
+}
+
+// tagInput son los campos editables de una etiqueta
+type tagInput struct {
+	Name    string   `json:"name" validate:"required,max=100"`
+	Aliases []string `json:"aliases"`
+}
+
+// mergeInput indica la etiqueta que absorbe a la fusionada
+type mergeInput struct {
+	Into string `json:"into" validate:"required,objectid"`
+}
+
+// Classification es la categoría y las etiquetas de un curso. Al asignarla, Tags acepta nombres,
+// slugs o alias y se responde con los slugs de las etiquetas.
+type Classification struct {
+	CategoryID   string   `json:"category_id" validate:"omitempty,objectid"`
+	CategoryPath []string `json:"category_path,omitempty"`
+	Tags         []string `json:"tags"`
+}
+
+// publishCourses publica los cursos modificados para reindexarlos; los errores solo se registran
+func (h *Handler) publishCourses(ctx context.Context, ids []string) {
+	for _, id := range ids {
+		courses.PublishStoredCourse(ctx, h.courses, h.events, id)
+	}
+}
+
+// categoryFromRequest obtiene la categoría {id}; si falla responde el error y devuelve false
+func (h *Handler) categoryFromRequest(w http.ResponseWriter, r *http.Request) (Category, bool) {
+	category, err := h.categories.GetByID(r.Context(), r.PathValue("id"))
+	if errors.Is(err, ErrCategoryNotFound) {
+		apierror.Write(w, r, errCategoryNotFound)
+		return category, false
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.category_fetch_failed"))
+		return category, false
+	}
+	return category, true
+}
+
+// tagFromRequest obtiene la etiqueta {id}; si falla responde el error y devuelve false
+func (h *Handler) tagFromRequest(w http.ResponseWriter, r *http.Request) (Tag, bool) {
+	tag, err := h.tags.GetByID(r.Context(), r.PathValue("id"))
+	if errors.Is(err, ErrTagNotFound) {
+		apierror.Write(w, r, errTagNotFound)
+		return tag, false
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.tag_fetch_failed"))
+		return tag, false
+	}
+	return tag, true
+}
+
+// GetCategories maneja GET /categories con el árbol completo de categorías
+func (h *Handler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.categories.List(r.Context())
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.categories_failed"))
+		return
+	}
+	json.NewEncoder(w).Encode(buildTree(categories))
+}
+
+// CreateCategory maneja POST /categories; parent_id vacío crea una categoría raíz
+func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var input categoryInput
+	if !validate.DecodeJSON(w, r, &input) {
+		return
+	}
+	category := Category{Name: input.Name, ParentID: input.ParentID}
+	slug, err := cleanName(&category.Name)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation(err))
+		return
+	}
+	category.Slug = slug
+
+	if category.ParentID != "" {
+		parent, err := h.categories.GetByID(r.Context(), category.ParentID)
+		if errors.Is(err, ErrCategoryNotFound) {
+			apierror.Write(w, r, apierror.Validation(apierror.Field("parent_id", apierror.FieldInvalid, "taxonomy.category_unknown", "parent_id")))
+			return
+		} else if err != nil {
+			apierror.Write(w, r, apierror.Internal("taxonomy.category_fetch_failed"))
+			return
+		}
+		category.Path = parent.coursePath()
+	}
+
+	category.CreatedAt = time.Now().UTC()
+	category.UpdatedAt = category.CreatedAt
+	err = h.categories.Create(r.Context(), &category)
+	if errors.Is(err, ErrCategoryExists) {
+		apierror.Write(w, r, errCategoryExists)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.category_create_failed"))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+// UpdateCategory maneja PUT /categories/{id} para renombrar la categoría. No se puede mover a
+// otro padre porque los cursos guardan la ruta de su categoría.
+func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	category, ok := h.categoryFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var input categoryInput
+	if !validate.DecodeJSON(w, r, &input) {
+		return
+	}
+	if input.ParentID != "" && input.ParentID != category.ParentID {
+		apierror.Write(w, r, apierror.Validation(apierror.Field("parent_id", apierror.FieldInvalid, "taxonomy.category_move")))
+		return
+	}
+	category.Name = input.Name
+	slug, err := cleanName(&category.Name)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation(err))
+		return
+	}
+	category.Slug = slug
+	category.UpdatedAt = time.Now().UTC()
+
+	err = h.categories.Update(r.Context(), category)
+	if errors.Is(err, ErrCategoryExists) {
+		apierror.Write(w, r, errCategoryExists)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.category_update_failed"))
+		return
+	}
+	json.NewEncoder(w).Encode(category)
+}
+
+// DeleteCategory maneja DELETE /categories/{id}; solo se pueden eliminar las categorías sin
+// subcategorías ni cursos
+func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	category, ok := h.categoryFromRequest(w, r)
+	if !ok {
+		return
+	}
+	id := category.ID.Hex()
+
+	hasChildren, err := h.categories.HasChildren(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.categories_failed"))
+		return
+	}
+	if hasChildren {
+		apierror.Write(w, r, errCategoryInUse)
+		return
+	}
+	page, err := h.courses.Find(r.Context(), courses.CourseQuery{Category: id, Sort: courses.SortByCreated, Limit: 1})
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.list_failed"))
+		return
+	}
+	if page.Total > 0 {
+		apierror.Write(w, r, errCategoryInUse.WithMessage("taxonomy.category_has_courses", page.Total))
+		return
+	}
+
+	if err := h.categories.Delete(r.Context(), id); err != nil && !errors.Is(err, ErrCategoryNotFound) {
+		apierror.Write(w, r, apierror.Internal("taxonomy.category_delete_failed"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTags maneja GET /tags con todas las etiquetas ordenadas por slug
+func (h *Handler) GetTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.tags.List(r.Context())
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.tags_failed"))
+		return
+	}
+	if tags == nil {
+		tags = []Tag{}
+	}
+	json.NewEncoder(w).Encode(tags)
+}
+
+// decodeTag decodifica la etiqueta del cuerpo sobre tag y normaliza su slug y sus alias; si
+// aliases se omite se conservan los actuales
+func decodeTag(w http.ResponseWriter, r *http.Request, tag *Tag) bool {
+	var input tagInput
+	if !validate.DecodeJSON(w, r, &input) {
+		return false
+	}
+	tag.Name = input.Name
+	slug, err := cleanName(&tag.Name)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation(err))
+		return false
+	}
+	tag.Slug = slug
+	if input.Aliases != nil {
+		tag.Aliases = input.Aliases
+	}
+	tag.Aliases = cleanAliases(tag.Aliases, slug)
+	return true
+}
+
+// checkKeys verifica que el slug y los alias de la etiqueta no pertenezcan a otra; si no responde
+// el error y devuelve false
+func (h *Handler) checkKeys(w http.ResponseWriter, r *http.Request, tag Tag) bool {
+	for _, key := range tag.keys() {
+		existing, err := h.tags.Resolve(r.Context(), key)
+		if errors.Is(err, ErrTagNotFound) {
+			continue
+		} else if err != nil {
+			apierror.Write(w, r, apierror.Internal("taxonomy.tags_failed"))
+			return false
+		}
+		if existing.ID != tag.ID {
+			apierror.Write(w, r, errTagExists.WithMessage("taxonomy.tag_key_taken", key, existing.Name))
+			return false
+		}
+	}
+	return true
+}
+
+// CreateTag maneja POST /tags
+func (h *Handler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var tag Tag
+	if !decodeTag(w, r, &tag) || !h.checkKeys(w, r, tag) {
+		return
+	}
+
+	tag.CreatedAt = time.Now().UTC()
+	tag.UpdatedAt = tag.CreatedAt
+	err := h.tags.Create(r.Context(), &tag)
+	if errors.Is(err, ErrTagExists) {
+		apierror.Write(w, r, errTagExists)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.tag_create_failed"))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tag)
+}
+
+// UpdateTag maneja PUT /tags/{id}. Si cambia el slug, el anterior queda como alias y los cursos
+// pasan a usar el nuevo.
+func (h *Handler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	tag, ok := h.tagFromRequest(w, r)
+	if !ok {
+		return
+	}
+	previous := tag.Slug
+	if !decodeTag(w, r, &tag) {
+		return
+	}
+	if tag.Slug != previous {
+		tag.Aliases = cleanAliases(append(tag.Aliases, previous), tag.Slug)
+	}
+	if !h.checkKeys(w, r, tag) {
+		return
+	}
+
+	tag.UpdatedAt = time.Now().UTC()
+	err := h.tags.Update(r.Context(), tag)
+	if errors.Is(err, ErrTagExists) {
+		apierror.Write(w, r, errTagExists)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.tag_update_failed"))
+		return
+	}
+
+	if tag.Slug != previous {
+		ids, err := h.courses.ReplaceTag(r.Context(), previous, tag.Slug)
+		if err != nil {
+			log.Println("Error al renombrar la etiqueta en los cursos:", previous, err)
+			apierror.Write(w, r, apierror.Internal("taxonomy.course_tags_failed"))
+			return
+		}
+		h.publishCourses(r.Context(), ids)
+	}
+	json.NewEncoder(w).Encode(tag)
+}
+
+// DeleteTag maneja DELETE /tags/{id} y quita la etiqueta de los cursos
+func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	tag, ok := h.tagFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ids, err := h.courses.ReplaceTag(r.Context(), tag.Slug, "")
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.course_tags_failed"))
+		return
+	}
+	h.publishCourses(r.Context(), ids)
+
+	if err := h.tags.Delete(r.Context(), tag.ID.Hex()); err != nil && !errors.Is(err, ErrTagNotFound) {
+		apierror.Write(w, r, apierror.Internal("taxonomy.tag_delete_failed"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MergeTag maneja POST /tags/{id}/merge: la etiqueta {id} se fusiona en into, que suma su slug y
+// sus alias; los cursos pasan a usar into y la etiqueta fusionada se elimina
+func (h *Handler) MergeTag(w http.ResponseWriter, r *http.Request) {
+	source, ok := h.tagFromRequest(w, r)
+	if !ok {
+		return
+	}
+	var input mergeInput
+	if !validate.DecodeJSON(w, r, &input) {
+		return
+	}
+	target, err := h.tags.GetByID(r.Context(), input.Into)
+	if errors.Is(err, ErrTagNotFound) {
+		apierror.Write(w, r, apierror.Validation(apierror.Field("into", apierror.FieldInvalid, "taxonomy.tag_unknown", "into")))
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.tag_fetch_failed"))
+		return
+	}
+	if target.ID == source.ID {
+		apierror.Write(w, r, apierror.Validation(apierror.Field("into", apierror.FieldInvalid, "taxonomy.tag_merge_self")))
+		return
+	}
+
+	target.Aliases = cleanAliases(append(target.Aliases, source.keys()...), target.Slug)
+	target.UpdatedAt = time.Now().UTC()
+	if err := h.tags.Update(r.Context(), target); err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.tag_update_failed"))
+		return
+	}
+
+	ids, err := h.courses.ReplaceTag(r.Context(), source.Slug, target.Slug)
+	if err != nil {
+		log.Println("Error al fusionar la etiqueta en los cursos:", source.Slug, target.Slug, err)
+		apierror.Write(w, r, apierror.Internal("taxonomy.course_tags_failed"))
+		return
+	}
+	h.publishCourses(r.Context(), ids)
+
+	// La fusionada se elimina al final para poder reintentar si algo falla antes
+	if err := h.tags.Delete(r.Context(), source.ID.Hex()); err != nil && !errors.Is(err, ErrTagNotFound) {
+		apierror.Write(w, r, apierror.Internal("taxonomy.tag_delete_failed"))
+		return
+	}
+	json.NewEncoder(w).Encode(target)
+}
+
+// SetCourseClassification maneja POST /courses/{id}/classification con la categoría y las
+// etiquetas del curso; category_id vacío quita la categoría y las etiquetas desconocidas se rechazan
+func (h *Handler) SetCourseClassification(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+	courseID := r.PathValue("id")
+	if !primitive.IsValidObjectID(courseID) {
+		apierror.Write(w, r, apierror.ErrInvalidID.WithMessage("courses.invalid_id"))
+		return
+	}
+	course, err := h.courses.GetByID(r.Context(), courseID)
+	if errors.Is(err, courses.ErrCourseNotFound) {
+		apierror.Write(w, r, errCourseNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("courses.fetch_failed"))
+		return
+	}
+	if !h.policy.AllowsOwned(claims, auth.PermCourseWrite, course.InstructorID) {
+		apierror.Write(w, r, apierror.ErrForbidden.WithMessage("courses.forbidden_edit"))
+		return
+	}
+	if course.ArchivedAt != nil {
+		apierror.Write(w, r, errCourseArchived)
+		return
+	}
+
+	var input Classification
+	if !validate.DecodeJSON(w, r, &input) {
+		return
+	}
+	classification := Classification{CategoryID: input.CategoryID, Tags: []string{}}
+	if input.CategoryID != "" {
+		category, err := h.categories.GetByID(r.Context(), input.CategoryID)
+		if errors.Is(err, ErrCategoryNotFound) {
+			apierror.Write(w, r, apierror.Validation(apierror.Field("category_id", apierror.FieldInvalid, "taxonomy.category_unknown", "category_id")))
+			return
+		} else if err != nil {
+			apierror.Write(w, r, apierror.Internal("taxonomy.category_fetch_failed"))
+			return
+		}
+		classification.CategoryPath = category.coursePath()
+	}
+
+	var unknown []string
+	for _, name := range input.Tags {
+		tag, err := h.tags.Resolve(r.Context(), Slug(name))
+		if errors.Is(err, ErrTagNotFound) {
+			unknown = append(unknown, name)
+			continue
+		} else if err != nil {
+			apierror.Write(w, r, apierror.Internal("taxonomy.tags_failed"))
+			return
+		}
+		if !slices.Contains(classification.Tags, tag.Slug) {
+			classification.Tags = append(classification.Tags, tag.Slug)
+		}
+	}
+	if len(unknown) > 0 {
+		apierror.Write(w, r, apierror.Validation(apierror.Field("tags", apierror.FieldInvalid, "taxonomy.tags_unknown", strings.Join(unknown, ", "))))
+		return
+	}
+
+	err = h.courses.SetClassification(r.Context(), courseID, classification.CategoryID, classification.CategoryPath, classification.Tags)
+	if err != nil {
+		apierror.Write(w, r, apierror.Internal("taxonomy.classification_failed"))
+		return
+	}
+	h.publishCourses(r.Context(), []string{courseID})
+	json.NewEncoder(w).Encode(classification)
+}
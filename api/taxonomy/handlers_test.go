@@ -0,0 +1,252 @@
+package taxonomy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/courses"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/queue"
+)
+
+// testAPI monta las rutas de taxonomía como en main.go sobre los repositorios en memoria
+type testAPI struct {
+	mux     *http.ServeMux
+	tags    *MemoryTagRepository
+	courses *courses.MemoryCourseRepository
+	broker  *queue.MemoryBroker
+	admin   *auth.Claims
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	api := &testAPI{
+		mux:     http.NewServeMux(),
+		tags:    NewMemoryTagRepository(),
+		courses: courses.NewMemoryCourseRepository(),
+		broker:  queue.NewMemoryBroker(),
+		admin:   &auth.Claims{UserID: 1, Role: auth.RoleAdmin},
+	}
+	h := NewHandler(NewMemoryCategoryRepository(), api.tags, api.courses, api.broker, auth.DefaultPolicy)
+	api.mux.HandleFunc("POST /categories", h.CreateCategory)
+	api.mux.HandleFunc("PUT /categories/{id}", h.UpdateCategory)
+	api.mux.HandleFunc("DELETE /categories/{id}", h.DeleteCategory)
+	api.mux.HandleFunc("POST /tags", h.CreateTag)
+	api.mux.HandleFunc("PUT /tags/{id}", h.UpdateTag)
+	api.mux.HandleFunc("POST /tags/{id}/merge", h.MergeTag)
+	api.mux.HandleFunc("POST /courses/{id}/classification", h.SetCourseClassification)
+	return api
+}
+
+// do envía la solicitud como administrador
+func (api *testAPI) do(method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r = r.WithContext(auth.WithUser(r.Context(), api.admin))
+	w := httptest.NewRecorder()
+	api.mux.ServeHTTP(w, r)
+	return w
+}
+
+// create envía el cuerpo a target, espera 201 y decodifica la respuesta en v
+func (api *testAPI) create(t *testing.T, target, body string, v any) {
+	t.Helper()
+	w := api.do(http.MethodPost, target, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+	if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// addCourse crea un curso con las etiquetas dadas y devuelve su ID
+func (api *testAPI) addCourse(t *testing.T, title string, tags ...string) string {
+	t.Helper()
+	course := courses.Course{Title: title, Level: "beginner"}
+	if err := api.courses.Create(context.Background(), &course); err != nil {
+		t.Fatal(err)
+	}
+	id := course.ID.Hex()
+	if err := api.courses.SetClassification(context.Background(), id, "", nil, tags); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// expectProblem verifica el estado y el código de una respuesta de error
+func expectProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("estado %d, se esperaba %d: %s", w.Code, status, w.Body)
+	}
+	var problem apierror.Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Code != code {
+		t.Fatalf("código %q, se esperaba %q", problem.Code, code)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Go", "go"},
+		{"Programación Web", "programacion-web"},
+		{"  programacion   web  ", "programacion-web"},
+		{"Diseño/UX", "diseno-ux"},
+		{"C++", "c++"},
+		{"C#", "c#"},
+		{"Año 2026", "ano-2026"},
+		{"¡¿?!", ""},
+	}
+	for _, tt := range tests {
+		if got := Slug(tt.name); got != tt.want {
+			t.Errorf("Slug(%q) = %q, se esperaba %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreateTagNormalizesKeys(t *testing.T) {
+	api := newTestAPI(t)
+
+	var tag Tag
+	api.create(t, "/tags", `{"name": "  Programación Web ", "aliases": ["Web Dev", "web-dev", "programacion web", ""]}`, &tag)
+	if tag.Name != "Programación Web" || tag.Slug != "programacion-web" || !slices.Equal(tag.Aliases, []string{"web-dev"}) {
+		t.Fatalf("etiqueta creada: %+v", tag)
+	}
+
+	// El slug y los alias de una etiqueta no se pueden repetir en otra, escritos de cualquier forma
+	expectProblem(t, api.do(http.MethodPost, "/tags", `{"name": "programacion-web"}`), http.StatusConflict, "tag_exists")
+	expectProblem(t, api.do(http.MethodPost, "/tags", `{"name": "Web DEV"}`), http.StatusConflict, "tag_exists")
+	expectProblem(t, api.do(http.MethodPost, "/tags", `{"name": "Frontend", "aliases": ["web dev"]}`), http.StatusConflict, "tag_exists")
+	expectProblem(t, api.do(http.MethodPost, "/tags", `{"name": "!!!"}`), http.StatusBadRequest, "validation_failed")
+}
+
+func TestUpdateTagRenamesCourses(t *testing.T) {
+	api := newTestAPI(t)
+	var tag Tag
+	api.create(t, "/tags", `{"name": "Golang"}`, &tag)
+	id := api.addCourse(t, "Go", "golang")
+
+	if w := api.do(http.MethodPut, "/tags/"+tag.ID.Hex(), `{"name": "Go"}`); w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+	updated, err := api.tags.Resolve(context.Background(), "golang")
+	if err != nil || updated.Slug != "go" {
+		t.Fatalf("el slug anterior no quedó como alias: %+v, %v", updated, err)
+	}
+	course, err := api.courses.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(course.Tags, []string{"go"}) {
+		t.Errorf("etiquetas del curso: %v", course.Tags)
+	}
+}
+
+func TestMergeTagReassignsCourses(t *testing.T) {
+	api := newTestAPI(t)
+	var golang, target, other Tag
+	api.create(t, "/tags", `{"name": "Golang", "aliases": ["go-lang"]}`, &golang)
+	api.create(t, "/tags", `{"name": "Go"}`, &target)
+	api.create(t, "/tags", `{"name": "Backend"}`, &other)
+
+	onlySource := api.addCourse(t, "Go desde cero", "golang", "backend")
+	both := api.addCourse(t, "Concurrencia", "go", "golang")
+	untouched := api.addCourse(t, "APIs", "backend")
+
+	expectProblem(t, api.do(http.MethodPost, "/tags/"+golang.ID.Hex()+"/merge", fmt.Sprintf(`{"into": %q}`, golang.ID.Hex())), http.StatusBadRequest, "validation_failed")
+
+	w := api.do(http.MethodPost, "/tags/"+golang.ID.Hex()+"/merge", fmt.Sprintf(`{"into": %q}`, target.ID.Hex()))
+	if w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+	var merged Tag
+	if err := json.NewDecoder(w.Body).Decode(&merged); err != nil {
+		t.Fatal(err)
+	}
+	if merged.Slug != "go" || !slices.Equal(merged.Aliases, []string{"golang", "go-lang"}) {
+		t.Errorf("etiqueta fusionada: %+v", merged)
+	}
+	if _, err := api.tags.GetByID(context.Background(), golang.ID.Hex()); err != ErrTagNotFound {
+		t.Errorf("la etiqueta fusionada sigue existiendo: %v", err)
+	}
+
+	want := map[string][]string{
+		onlySource: {"backend", "go"},
+		both:       {"go"},
+		untouched:  {"backend"},
+	}
+	for id, tags := range want {
+		course, err := api.courses.GetByID(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(course.Tags, tags) {
+			t.Errorf("etiquetas de %s: %v, se esperaban %v", course.Title, course.Tags, tags)
+		}
+	}
+	// Solo se publican para reindexar los cursos modificados
+	if n := api.broker.Pending(courses.CourseEventsQueue); n != 2 {
+		t.Errorf("%d eventos publicados, se esperaban 2", n)
+	}
+
+	// Los alias de la etiqueta fusionada se resuelven a la nueva al clasificar un curso
+	w = api.do(http.MethodPost, "/courses/"+untouched+"/classification", `{"tags": ["Go Lang", "backend"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+	var classification Classification
+	if err := json.NewDecoder(w.Body).Decode(&classification); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(classification.Tags, []string{"go", "backend"}) {
+		t.Errorf("etiquetas asignadas: %v", classification.Tags)
+	}
+}
+
+func TestCategoryTreeRules(t *testing.T) {
+	api := newTestAPI(t)
+	var root, child, other Category
+	api.create(t, "/categories", `{"name": "Programación"}`, &root)
+	api.create(t, "/categories", fmt.Sprintf(`{"name": "Go", "parent_id": %q}`, root.ID.Hex()), &child)
+	api.create(t, "/categories", `{"name": "Diseño"}`, &other)
+	if !slices.Equal(child.Path, []string{root.ID.Hex()}) {
+		t.Fatalf("ruta de la subcategoría: %v", child.Path)
+	}
+
+	expectProblem(t, api.do(http.MethodPost, "/categories", fmt.Sprintf(`{"name": "GO", "parent_id": %q}`, root.ID.Hex())), http.StatusConflict, "category_exists")
+	expectProblem(t, api.do(http.MethodPost, "/categories", `{"name": "Huérfana", "parent_id": "000000000000000000000000"}`), http.StatusBadRequest, "validation_failed")
+
+	// Una categoría no se puede mover, así que tampoco colgar de su propia subcategoría
+	target := "/categories/" + root.ID.Hex()
+	expectProblem(t, api.do(http.MethodPut, target, fmt.Sprintf(`{"name": "Programación", "parent_id": %q}`, child.ID.Hex())), http.StatusBadRequest, "validation_failed")
+	expectProblem(t, api.do(http.MethodPut, target, fmt.Sprintf(`{"name": "Programación", "parent_id": %q}`, root.ID.Hex())), http.StatusBadRequest, "validation_failed")
+	expectProblem(t, api.do(http.MethodPut, "/categories/"+child.ID.Hex(), fmt.Sprintf(`{"name": "Go", "parent_id": %q}`, other.ID.Hex())), http.StatusBadRequest, "validation_failed")
+	if w := api.do(http.MethodPut, "/categories/"+child.ID.Hex(), `{"name": "Golang"}`); w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+
+	// No se elimina una categoría con subcategorías ni una con cursos
+	expectProblem(t, api.do(http.MethodDelete, target, ""), http.StatusConflict, "category_in_use")
+	id := api.addCourse(t, "Go desde cero")
+	body := fmt.Sprintf(`{"category_id": %q}`, child.ID.Hex())
+	if w := api.do(http.MethodPost, "/courses/"+id+"/classification", body); w.Code != http.StatusOK {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+	expectProblem(t, api.do(http.MethodDelete, "/categories/"+child.ID.Hex(), ""), http.StatusConflict, "category_in_use")
+
+	if w := api.do(http.MethodDelete, "/categories/"+other.ID.Hex(), ""); w.Code != http.StatusNoContent {
+		t.Fatalf("estado %d: %s", w.Code, w.Body)
+	}
+}
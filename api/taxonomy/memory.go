@@ -0,0 +1,200 @@
+package taxonomy
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryCategoryRepository implementa CategoryRepository en memoria para pruebas y desarrollo
+type MemoryCategoryRepository struct {
+	mu         sync.RWMutex
+	categories []Category
+}
+
+// NewMemoryCategoryRepository crea un repositorio de categorías vacío
+func NewMemoryCategoryRepository() *MemoryCategoryRepository {
+	return &MemoryCategoryRepository{}
+}
+
+// sibling indica si otra categoría con el mismo padre ya usa el slug
+func (r *MemoryCategoryRepository) sibling(category Category) bool {
+	for _, existing := range r.categories {
+		if existing.ID != category.ID && existing.ParentID == category.ParentID && existing.Slug == category.Slug {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryCategoryRepository) Create(ctx context.Context, category *Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sibling(*category) {
+		return ErrCategoryExists
+	}
+	category.ID = primitive.NewObjectID()
+	r.categories = append(r.categories, *category)
+	return nil
+}
+
+func (r *MemoryCategoryRepository) GetByID(ctx context.Context, id string) (Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, category := range r.categories {
+		if category.ID.Hex() == id {
+			return category, nil
+		}
+	}
+	return Category{}, ErrCategoryNotFound
+}
+
+func (r *MemoryCategoryRepository) List(ctx context.Context) ([]Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := append([]Category(nil), r.categories...)
+	sort.SliceStable(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	return categories, nil
+}
+
+func (r *MemoryCategoryRepository) Update(ctx context.Context, category Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.categories {
+		if existing.ID == category.ID {
+			if r.sibling(category) {
+				return ErrCategoryExists
+			}
+			r.categories[i] = category
+			return nil
+		}
+	}
+	return ErrCategoryNotFound
+}
+
+func (r *MemoryCategoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, category := range r.categories {
+		if category.ID.Hex() == id {
+			r.categories = append(r.categories[:i], r.categories[i+1:]...)
+			return nil
+		}
+	}
+	return ErrCategoryNotFound
+}
+
+func (r *MemoryCategoryRepository) HasChildren(ctx context.Context, id string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, category := range r.categories {
+		if category.ParentID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MemoryTagRepository implementa TagRepository en memoria para pruebas y desarrollo
+type MemoryTagRepository struct {
+	mu   sync.RWMutex
+	tags []Tag
+}
+
+// NewMemoryTagRepository crea un repositorio de etiquetas vacío
+func NewMemoryTagRepository() *MemoryTagRepository {
+	return &MemoryTagRepository{}
+}
+
+// taken indica si otra etiqueta ya usa el slug, igual que el índice único de MongoDB
+func (r *MemoryTagRepository) taken(tag Tag) bool {
+	for _, existing := range r.tags {
+		if existing.ID != tag.ID && existing.Slug == tag.Slug {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryTagRepository) Create(ctx context.Context, tag *Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.taken(*tag) {
+		return ErrTagExists
+	}
+	tag.ID = primitive.NewObjectID()
+	r.tags = append(r.tags, *tag)
+	return nil
+}
+
+func (r *MemoryTagRepository) GetByID(ctx context.Context, id string) (Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, tag := range r.tags {
+		if tag.ID.Hex() == id {
+			return tag, nil
+		}
+	}
+	return Tag{}, ErrTagNotFound
+}
+
+func (r *MemoryTagRepository) Resolve(ctx context.Context, slug string) (Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, tag := range r.tags {
+		if tag.Slug == slug || slices.Contains(tag.Aliases, slug) {
+			return tag, nil
+		}
+	}
+	return Tag{}, ErrTagNotFound
+}
+
+func (r *MemoryTagRepository) List(ctx context.Context) ([]Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tags := append([]Tag(nil), r.tags...)
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Slug < tags[j].Slug })
+	return tags, nil
+}
+
+func (r *MemoryTagRepository) Update(ctx context.Context, tag Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.tags {
+		if existing.ID == tag.ID {
+			if r.taken(tag) {
+				return ErrTagExists
+			}
+			r.tags[i] = tag
+			return nil
+		}
+	}
+	return ErrTagNotFound
+}
+
+func (r *MemoryTagRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, tag := range r.tags {
+		if tag.ID.Hex() == id {
+			r.tags = append(r.tags[:i], r.tags[i+1:]...)
+			return nil
+		}
+	}
+	return ErrTagNotFound
+}
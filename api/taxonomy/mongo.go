@@ -0,0 +1,178 @@
+package taxonomy
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCategoryRepository implementa CategoryRepository sobre la colección categories
+type MongoCategoryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoCategoryRepository crea un repositorio de categorías sobre la base dada
+func NewMongoCategoryRepository(db *mongo.Database) *MongoCategoryRepository {
+	return &MongoCategoryRepository{collection: db.Collection("categories")}
+}
+
+func (r *MongoCategoryRepository) Create(ctx context.Context, category *Category) error {
+	category.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, category)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrCategoryExists
+	}
+	return err
+}
+
+func (r *MongoCategoryRepository) GetByID(ctx context.Context, id string) (Category, error) {
+	var category Category
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return category, ErrCategoryNotFound
+	}
+
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&category)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return category, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+func (r *MongoCategoryRepository) List(ctx context.Context) ([]Category, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var categories []Category
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *MongoCategoryRepository) Update(ctx context.Context, category Category) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": category.ID}, category)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrCategoryExists
+	} else if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+func (r *MongoCategoryRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrCategoryNotFound
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+func (r *MongoCategoryRepository) HasChildren(ctx context.Context, id string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"parent_id": id}, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+// MongoTagRepository implementa TagRepository sobre la colección tags
+type MongoTagRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoTagRepository crea un repositorio de etiquetas sobre la base dada
+func NewMongoTagRepository(db *mongo.Database) *MongoTagRepository {
+	return &MongoTagRepository{collection: db.Collection("tags")}
+}
+
+func (r *MongoTagRepository) Create(ctx context.Context, tag *Tag) error {
+	tag.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, tag)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrTagExists
+	}
+	return err
+}
+
+func (r *MongoTagRepository) GetByID(ctx context.Context, id string) (Tag, error) {
+	var tag Tag
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return tag, ErrTagNotFound
+	}
+
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&tag)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return tag, ErrTagNotFound
+	}
+	return tag, err
+}
+
+func (r *MongoTagRepository) Resolve(ctx context.Context, slug string) (Tag, error) {
+	var tag Tag
+	filter := bson.M{"$or": bson.A{bson.M{"slug": slug}, bson.M{"aliases": slug}}}
+	err := r.collection.FindOne(ctx, filter).Decode(&tag)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return tag, ErrTagNotFound
+	}
+	return tag, err
+}
+
+func (r *MongoTagRepository) List(ctx context.Context) ([]Tag, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "slug", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tags []Tag
+	if err := cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *MongoTagRepository) Update(ctx context.Context, tag Tag) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": tag.ID}, tag)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrTagExists
+	} else if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+func (r *MongoTagRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrTagNotFound
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
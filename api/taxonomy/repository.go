@@ -0,0 +1,46 @@
+package taxonomy
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrCategoryNotFound se devuelve cuando no existe la categoría buscada
+	ErrCategoryNotFound = errors.New("categoría no encontrada")
+	// ErrCategoryExists se devuelve cuando ya hay una categoría con el mismo slug en el mismo nivel
+	ErrCategoryExists = errors.New("ya existe una categoría con ese nombre en el mismo nivel")
+	// ErrTagNotFound se devuelve cuando no existe la etiqueta buscada
+	ErrTagNotFound = errors.New("etiqueta no encontrada")
+	// ErrTagExists se devuelve cuando el slug o un alias ya pertenece a otra etiqueta
+	ErrTagExists = errors.New("ya existe una etiqueta con ese nombre o alias")
+)
+
+// CategoryRepository define el acceso al árbol de categorías
+type CategoryRepository interface {
+	// Create guarda la categoría y completa su ID; devuelve ErrCategoryExists si el slug se repite
+	// entre sus hermanas
+	Create(ctx context.Context, category *Category) error
+	GetByID(ctx context.Context, id string) (Category, error)
+	// List devuelve todas las categorías ordenadas por nombre
+	List(ctx context.Context) ([]Category, error)
+	// Update guarda la categoría; devuelve ErrCategoryExists si el slug se repite entre sus hermanas
+	Update(ctx context.Context, category Category) error
+	Delete(ctx context.Context, id string) error
+	// HasChildren indica si la categoría tiene subcategorías
+	HasChildren(ctx context.Context, id string) (bool, error)
+}
+
+// TagRepository define el acceso a las etiquetas
+type TagRepository interface {
+	// Create guarda la etiqueta y completa su ID; devuelve ErrTagExists si el slug ya existe
+	Create(ctx context.Context, tag *Tag) error
+	GetByID(ctx context.Context, id string) (Tag, error)
+	// Resolve busca la etiqueta cuyo slug o alguno de sus alias es slug
+	Resolve(ctx context.Context, slug string) (Tag, error)
+	// List devuelve todas las etiquetas ordenadas por slug
+	List(ctx context.Context) ([]Tag, error)
+	// Update guarda la etiqueta; devuelve ErrTagExists si el slug ya es de otra
+	Update(ctx context.Context, tag Tag) error
+	Delete(ctx context.Context, id string) error
+}
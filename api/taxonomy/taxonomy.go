@@ -0,0 +1,118 @@
+package taxonomy
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Category es un nodo del árbol de categorías de cursos
+type Category struct {
+	ID   primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name string             `json:"name" bson:"name"`
+	Slug string             `json:"slug" bson:"slug"`
+	// ParentID vacío indica una categoría raíz; Path son los IDs de sus ancestros desde la raíz
+	ParentID  string    `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	Path      []string  `json:"path,omitempty" bson:"path,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// coursePath devuelve los IDs desde la raíz hasta la categoría inclusive, como se guardan en el curso
+func (c Category) coursePath() []string {
+	return append(append([]string{}, c.Path...), c.ID.Hex())
+}
+
+// CategoryNode es una categoría con sus subcategorías, para devolver el árbol completo
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
+
+// buildTree arma el árbol a partir de la lista de categorías, ordenando cada nivel por nombre
+func buildTree(categories []Category) []CategoryNode {
+	children := make(map[string][]Category)
+	for _, category := range categories {
+		children[category.ParentID] = append(children[category.ParentID], category)
+	}
+
+	var build func(parentID string) []CategoryNode
+	build = func(parentID string) []CategoryNode {
+		nodes := []CategoryNode{}
+		for _, category := range children[parentID] {
+			nodes = append(nodes, CategoryNode{Category: category, Children: build(category.ID.Hex())})
+		}
+		return nodes
+	}
+	return build("")
+}
+
+// Tag es una etiqueta libre de cursos. Los cursos guardan el Slug; Aliases son otros slugs que
+// se resuelven a esta etiqueta, por ejemplo los de etiquetas fusionadas.
+type Tag struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name      string             `json:"name" bson:"name"`
+	Slug      string             `json:"slug" bson:"slug"`
+	Aliases   []string           `json:"aliases,omitempty" bson:"aliases,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// keys devuelve el slug y los alias de la etiqueta
+func (t Tag) keys() []string {
+	return append([]string{t.Slug}, t.Aliases...)
+}
+
+// Slug normaliza un nombre: minúsculas, sin acentos y con guiones entre palabras, de modo que
+// "Programación Web" y "programacion-web" coincidan. Conserva + y # para distinguir C, C++ y C#.
+func Slug(name string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, name)
+	if err != nil {
+		folded = name
+	}
+
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(folded) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '#' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// cleanName valida el nombre y devuelve su slug
+func cleanName(name *string) (string, error) {
+	*name = strings.TrimSpace(*name)
+	slug := Slug(*name)
+	if slug == "" {
+		return "", apierror.Field("name", apierror.FieldInvalid, "taxonomy.name_invalid")
+	}
+	return slug, nil
+}
+
+// cleanAliases normaliza los alias, quitando los vacíos, los repetidos y el propio slug
+func cleanAliases(aliases []string, slug string) []string {
+	var cleaned []string
+	seen := map[string]bool{slug: true}
+	for _, alias := range aliases {
+		if alias = Slug(alias); alias != "" && !seen[alias] {
+			seen[alias] = true
+			cleaned = append(cleaned, alias)
+		}
+	}
+	return cleaned
+}
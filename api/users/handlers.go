@@ -1,25 +1,30 @@
 package users
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
-	"regexp"
-	"strings"
-	"time"
+	"strconv"
 
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/hugodiazo/arq-soft-2/db"
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/validate"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/i18n"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtKey = []byte("my_secret_key")
+var (
+	// errUserNotFound es la respuesta cuando el usuario pedido no existe
+	errUserNotFound = apierror.New(http.StatusNotFound, "user_not_found", "users.not_found")
+	// errEmailTaken es la respuesta cuando el correo ya pertenece a otro usuario
+	errEmailTaken = apierror.New(http.StatusConflict, "email_taken", "users.email_taken")
+)
 
 type Credentials struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
 // User representa la estructura del usuario
@@ -31,215 +36,327 @@ type Users struct {
 	Role     string `json:"role"`
 }
 
-// GetUserByID obtiene un usuario desde la base de datos por su ID
-func GetUserByID(userID int) (User, error) {
-	var user User
-	err := db.DB.QueryRow("SELECT id, name, email, role FROM users WHERE id = ?", userID).
-		Scan(&user.ID, &user.Name, &user.Email, &user.Role)
-	if err == sql.ErrNoRows {
-		return user, err // Usuario no encontrado
-	} else if err != nil {
-		log.Println("Error al obtener usuario:", err)
-		return user, err // Otro error
-	}
+// Handler agrupa los handlers HTTP de usuarios y sus dependencias
+type Handler struct {
+	users UserRepository
+	auth  *auth.Service
+}
 
-	return user, nil
+// NewHandler crea los handlers de usuarios sobre el repositorio y el servicio de autenticación dados
+func NewHandler(users UserRepository, authService *auth.Service) *Handler {
+	return &Handler{users: users, auth: authService}
 }
 
-func RegisterUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		apierror.Write(w, r, apierror.ErrMethodNotAllowed)
 		return
 	}
+	// El rol no se elige al registrarse: toda cuenta nueva es de usuario y solo un administrador
+	// puede cambiarlo con ChangeRole
 	var user User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
-		return
-	}
-
-	// Validar que todos los campos estén completos
-	if user.Name == "" || user.Email == "" || user.Password == "" {
-		http.Error(w, "Todos los campos son obligatorios", http.StatusBadRequest)
-		return
-	}
-
-	// Validar el formato del correo electrónico
-	emailRegex := `^[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}$`
-	if !regexp.MustCompile(emailRegex).MatchString(user.Email) {
-		http.Error(w, "Formato de correo electrónico inválido", http.StatusBadRequest)
+	if !validate.DecodeJSON(w, r, &user, "role") {
 		return
 	}
-
-	// Asignar un rol por defecto si no se proporciona
-	if user.Role == "" {
-		user.Role = "user"
-	}
+	user.Role = auth.RoleUser
 
 	// Encriptar la contraseña
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
-		http.Error(w, "Error al encriptar la contraseña", http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.Internal("users.hash_failed"))
 		return
 	}
 
 	// Guarda la contraseña encriptada en la base de datos
-	_, err = db.DB.Exec("INSERT INTO users (name, email, password, role) VALUES (?, ?, ?, ?)",
-		user.Name, user.Email, string(hashedPassword), user.Role)
-	if err != nil {
+	user.Password = string(hashedPassword)
+	err = h.users.Create(r.Context(), &user)
+	if errors.Is(err, ErrEmailTaken) {
+		apierror.Write(w, r, errEmailTaken)
+		return
+	} else if err != nil {
 		log.Println("Error al registrar usuario:", err)
-		http.Error(w, "Error al registrar usuario", http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.Internal("users.register_failed"))
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Usuario registrado con éxito"})
+	json.NewEncoder(w).Encode(map[string]string{"message": i18n.Tr(r.Context(), "users.registered")})
+}
+
+// identity son los datos del usuario que se firman en su token de acceso
+func identity(user User) auth.Identity {
+	return auth.Identity{UserID: user.ID, Email: user.Email, Role: user.Role, Locale: user.Locale}
 }
 
-func Login(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var creds Credentials
-	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !validate.DecodeJSON(w, r, &creds) {
 		return
 	}
 
-	var userID int
-	var storedPassword, userRole string // Agrega userRole aquí para obtener el rol
-	err := db.DB.QueryRow("SELECT id, password, role FROM users WHERE email = ?", creds.Email).Scan(&userID, &storedPassword, &userRole)
-	if err == sql.ErrNoRows {
-		http.Error(w, "Usuario no encontrado", http.StatusUnauthorized)
+	user, err := h.users.GetByEmail(r.Context(), creds.Email)
+	if errors.Is(err, ErrUserNotFound) {
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, errUserNotFound.Code, errUserNotFound.MessageID))
 		return
 	} else if err != nil {
-		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.ErrInternal)
 		return
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(creds.Password)); err != nil {
-		http.Error(w, "Credenciales incorrectas", http.StatusUnauthorized)
+	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(creds.Password)); err != nil {
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "invalid_credentials", "users.invalid_credentials"))
 		return
 	}
 
-	// Generar el token JWT con el userID y el rol
-	expirationTime := time.Now().Add(24 * time.Hour)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userID, // Incluye el user_id en las reclamaciones
-		"email":   creds.Email,
-		"role":    userRole, // Ahora incluye el rol del usuario
-		"exp":     expirationTime.Unix(),
+	// Generar el token de acceso con el userID y el rol, y el token de refresco
+	pair, err := h.auth.IssueTokenPair(r.Context(), identity(user))
+	if err != nil {
+		log.Println("Error al generar tokens:", err)
+		apierror.Write(w, r, apierror.Internal("users.token_failed"))
+		return
+	}
+
+	writeTokenPair(w, pair)
+}
+
+// RefreshRequest es el cuerpo de /users/refresh y /users/logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// refreshTokenFromRequest obtiene el token de refresco del cuerpo o de la cookie
+func refreshTokenFromRequest(r *http.Request) string {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err == nil && req.RefreshToken != "" {
+		return req.RefreshToken
+	}
+	if cookie, err := r.Cookie("refresh_token"); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// Refresh rota el token de refresco y emite un nuevo token de acceso
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.Write(w, r, apierror.ErrMethodNotAllowed)
+		return
+	}
+
+	refreshToken := refreshTokenFromRequest(r)
+	if refreshToken == "" {
+		apierror.Write(w, r, apierror.Validation(apierror.Field("refresh_token", apierror.FieldRequired, "users.refresh_token_missing")))
+		return
+	}
+
+	// El nuevo token de acceso usa los datos actuales del usuario
+	pair, err := h.auth.RotateRefreshToken(r.Context(), refreshToken, func(ctx context.Context, userID int) (auth.Identity, error) {
+		user, err := h.users.GetByID(ctx, userID)
+		return identity(user), err
 	})
+	switch {
+	case errors.Is(err, auth.ErrRefreshTokenReused):
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "refresh_token_reused", "users.refresh_token_reused"))
+		return
+	case errors.Is(err, auth.ErrRefreshTokenNotFound), errors.Is(err, auth.ErrInvalidToken), errors.Is(err, ErrUserNotFound):
+		apierror.Write(w, r, apierror.New(http.StatusUnauthorized, "invalid_refresh_token", "users.refresh_token_invalid"))
+		return
+	case err != nil:
+		log.Println("Error al refrescar token:", err)
+		apierror.Write(w, r, apierror.ErrInternal)
+		return
+	}
 
-	tokenString, err := token.SignedString(jwtKey)
-	if err != nil {
-		http.Error(w, "Error al generar token", http.StatusInternalServerError)
+	writeTokenPair(w, pair)
+}
+
+// Logout revoca el token de acceso actual y la sesión del token de refresco
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		apierror.Write(w, r, apierror.ErrMethodNotAllowed)
 		return
 	}
 
-	log.Println("Token generado:", tokenString) // Imprime el token para depuración
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
 
+	if err := h.auth.Revoke(r.Context(), claims, refreshTokenFromRequest(r)); err != nil {
+		log.Println("Error al cerrar sesión:", err)
+		apierror.Write(w, r, apierror.Internal("users.logout_failed"))
+		return
+	}
+
+	// Borrar las cookies de la sesión
+	http.SetCookie(w, &http.Cookie{Name: "token", Value: "", MaxAge: -1})
+	http.SetCookie(w, &http.Cookie{Name: "refresh_token", Value: "", Path: "/users", MaxAge: -1})
+
+	json.NewEncoder(w).Encode(map[string]string{"message": i18n.Tr(r.Context(), "users.logged_out")})
+}
+
+// writeTokenPair envía el par de tokens en el cuerpo y en cookies
+func writeTokenPair(w http.ResponseWriter, pair auth.TokenPair) {
 	http.SetCookie(w, &http.Cookie{
 		Name:    "token",
-		Value:   tokenString,
-		Expires: expirationTime,
+		Value:   pair.AccessToken,
+		Expires: pair.Claims.ExpiresAt.Time,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    pair.RefreshToken,
+		Path:     "/users",
+		Expires:  pair.RefreshExpiresAt,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
 	})
 
-	json.NewEncoder(w).Encode(map[string]string{"token": tokenString})
+	json.NewEncoder(w).Encode(pair)
 }
 
-func GetAllUsers(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		apierror.Write(w, r, apierror.ErrMethodNotAllowed)
 		return
 	}
 
-	rows, err := db.DB.Query("SELECT id, name, email, role FROM users")
+	users, err := h.users.List(r.Context())
 	if err != nil {
 		log.Println("Error al obtener usuarios:", err)
-		http.Error(w, "Error al obtener usuarios", http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.Internal("users.list_failed"))
 		return
 	}
-	defer rows.Close()
-
-	var users []User
-	for rows.Next() {
-		var user User
-		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Role); err != nil {
-			log.Println("Error al escanear usuario:", err)
-			continue
-		}
-		users = append(users, user)
-	}
 
 	if len(users) == 0 {
-		json.NewEncoder(w).Encode(map[string]string{"message": "No se encontraron usuarios"})
+		json.NewEncoder(w).Encode(map[string]string{"message": i18n.Tr(r.Context(), "users.none")})
 		return
 	}
 
 	json.NewEncoder(w).Encode(users)
 }
 
-// UpdateUser maneja la actualización de un usuario
-func UpdateUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+// GetMe maneja GET /users/me con los datos del usuario autenticado
+func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
 		return
 	}
 
-	var user User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	user, err := h.users.GetByID(r.Context(), claims.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		apierror.Write(w, r, errUserNotFound)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, apierror.Internal("users.fetch_failed"))
 		return
 	}
 
-	_, err := db.DB.Exec("UPDATE users SET name = ?, email = ?, role = ? WHERE id = ?",
-		user.Name, user.Email, user.Role, user.ID)
-	if err != nil {
+	json.NewEncoder(w).Encode(user)
+}
+
+// UpdateMe maneja PUT /users/me; cada usuario solo puede modificar su nombre, su correo y su
+// idioma. El token de acceso los refleja a partir del próximo refresco.
+func (h *Handler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+
+	var profile Profile
+	if !validate.DecodeJSON(w, r, &profile) {
+		return
+	}
+
+	user := User{ID: claims.UserID, Name: profile.Name, Email: profile.Email, Locale: profile.Locale}
+	err := h.users.Update(r.Context(), user)
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		apierror.Write(w, r, errUserNotFound)
+		return
+	case errors.Is(err, ErrEmailTaken):
+		apierror.Write(w, r, errEmailTaken)
+		return
+	case err != nil:
 		log.Println("Error al actualizar usuario:", err)
-		http.Error(w, "Error al actualizar usuario", http.StatusInternalServerError)
+		apierror.Write(w, r, apierror.Internal("users.update_failed"))
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{"message": "Usuario actualizado con éxito"})
+	json.NewEncoder(w).Encode(map[string]string{"message": i18n.Tr(r.Context(), "users.updated")})
 }
 
-func GetUserIDFromToken(r *http.Request) (int, error) {
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		return 0, fmt.Errorf("token no proporcionado")
-	}
+// roleRequest es el cuerpo de PUT /users/{id}/role
+type roleRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin instructor user"`
+}
 
-	// Extraer el token
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+// ChangeRole maneja PUT /users/{id}/role, la única ruta que cambia roles; cada cambio queda en la
+// auditoría. Se revocan los tokens de refresco del usuario para que inicie sesión con el rol
+// nuevo; su token de acceso conserva el rol anterior hasta expirar. Las sesiones se revocan
+// aunque el rol no cambie, así un reintento después de un error también las cierra.
+func (h *Handler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, r, apierror.ErrUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.ErrInvalidID)
+		return
+	}
+	// Un administrador no puede quitarse el rol por error y quedarse sin acceso
+	if id == claims.UserID {
+		apierror.Write(w, r, apierror.ErrForbidden.WithMessage("users.own_role"))
+		return
+	}
 
-	// Parsear el token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("método de firma inesperado: %v", token.Header["alg"])
-		}
-		return jwtKey, nil
-	})
-	if err != nil || !token.Valid {
-		return 0, fmt.Errorf("token inválido")
+	var req roleRequest
+	if !validate.DecodeJSON(w, r, &req) {
+		return
 	}
 
-	// Extraer las reclamaciones
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID, ok := claims["user_id"].(float64)
-		if !ok {
-			return 0, fmt.Errorf("ID de usuario no encontrado en el token")
-		}
-		return int(userID), nil
+	change, err := h.users.ChangeRole(r.Context(), id, req.Role, claims.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		apierror.Write(w, r, errUserNotFound)
+		return
+	} else if err != nil {
+		log.Println("Error al cambiar el rol:", err)
+		apierror.Write(w, r, apierror.Internal("users.role_change_failed"))
+		return
+	}
+	if change.ID != 0 {
+		log.Printf("Rol del usuario %d cambiado de %s a %s por el usuario %d", change.UserID, change.OldRole, change.NewRole, change.ChangedBy)
 	}
-	return 0, fmt.Errorf("no se pudieron obtener las reclamaciones del token")
+	if err := h.auth.RevokeUserSessions(r.Context(), id); err != nil {
+		log.Println("Error al cerrar las sesiones tras cambiar el rol:", err)
+		apierror.Write(w, r, apierror.Internal("users.sessions_revoke_failed"))
+		return
+	}
+
+	json.NewEncoder(w).Encode(change)
 }
 
-// Obtener un usuario por ID desde la base de datos
-func GetUserByIDFromDB(userID int) (User, error) {
-	var user User
-	err := db.DB.QueryRow("SELECT id, name, email, role FROM users WHERE id = ?", userID).
-		Scan(&user.ID, &user.Name, &user.Email, &user.Role)
-	if err == sql.ErrNoRows {
-		return user, fmt.Errorf("usuario no encontrado")
-	} else if err != nil {
-		return user, err
+// ListRoleChanges maneja GET /users/{id}/role-changes con la auditoría de roles del usuario
+func (h *Handler) ListRoleChanges(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		apierror.Write(w, r, apierror.ErrInvalidID)
+		return
 	}
-	return user, nil
+
+	changes, err := h.users.ListRoleChanges(r.Context(), id)
+	if err != nil {
+		log.Println("Error al obtener los cambios de rol:", err)
+		apierror.Write(w, r, apierror.Internal("users.role_changes_failed"))
+		return
+	}
+	if changes == nil {
+		changes = []RoleChange{}
+	}
+
+	json.NewEncoder(w).Encode(changes)
 }
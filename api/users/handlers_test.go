@@ -0,0 +1,300 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/middleware"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/i18n"
+)
+
+// newTestAPI monta las rutas de usuarios como en main.go sobre el repositorio y los tokens en memoria
+func newTestAPI(t *testing.T) (http.Handler, *MemoryUserRepository) {
+	t.Helper()
+	repo := NewMemoryUserRepository()
+	service := auth.NewService(auth.Options{
+		Key:        []byte("clave-de-prueba"),
+		Issuer:     "test",
+		Audience:   "test",
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+	}, auth.NewMemoryTokenStore())
+	h := NewHandler(repo, service)
+
+	protect := func(next http.HandlerFunc, perms ...auth.Permission) http.HandlerFunc {
+		return service.Authenticate(middleware.RequirePermission(auth.DefaultPolicy, perms...)(next))
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users", protect(h.GetAllUsers, auth.PermUserRead))
+	mux.HandleFunc("/users/login", h.Login)
+	mux.HandleFunc("/users/refresh", h.Refresh)
+	mux.HandleFunc("/users/logout", service.Authenticate(h.Logout))
+	mux.HandleFunc("/users/register", h.RegisterUser)
+	mux.HandleFunc("GET /users/me", service.Authenticate(h.GetMe))
+	mux.HandleFunc("PUT /users/me", service.Authenticate(h.UpdateMe))
+	mux.HandleFunc("PUT /users/{id}/role", protect(h.ChangeRole, auth.PermUserWrite))
+	mux.HandleFunc("GET /users/{id}/role-changes", protect(h.ListRoleChanges, auth.PermUserRead))
+	return i18n.Middleware(mux), repo
+}
+
+// do envía la solicitud con el token de acceso dado, si no está vacío
+func do(api http.Handler, method, target, body, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, r)
+	return w
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+		t.Fatalf("respuesta inválida: %v", err)
+	}
+}
+
+// expectStatus verifica el estado de la respuesta
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("estado %d, se esperaba %d: %s", w.Code, status, w.Body)
+	}
+}
+
+// expectProblem verifica el estado y el código de una respuesta de error y devuelve su cuerpo
+func expectProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) apierror.Problem {
+	t.Helper()
+	expectStatus(t, w, status)
+	if ct := w.Header().Get("Content-Type"); ct != apierror.ContentType {
+		t.Fatalf("Content-Type %q, se esperaba %q", ct, apierror.ContentType)
+	}
+	var problem apierror.Problem
+	decodeBody(t, w, &problem)
+	if problem.Code != code {
+		t.Fatalf("código %q, se esperaba %q", problem.Code, code)
+	}
+	return problem
+}
+
+// register crea la cuenta con la API y devuelve el usuario guardado
+func register(t *testing.T, api http.Handler, repo *MemoryUserRepository, name, email string) User {
+	t.Helper()
+	body := fmt.Sprintf(`{"name": %q, "email": %q, "password": "secreto123"}`, name, email)
+	expectStatus(t, do(api, http.MethodPost, "/users/register", body, ""), http.StatusCreated)
+	user, err := repo.GetByEmail(context.Background(), email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// login inicia sesión y devuelve el par de tokens
+func login(t *testing.T, api http.Handler, email string) auth.TokenPair {
+	t.Helper()
+	w := do(api, http.MethodPost, "/users/login", fmt.Sprintf(`{"email": %q, "password": "secreto123"}`, email), "")
+	expectStatus(t, w, http.StatusOK)
+	var pair auth.TokenPair
+	decodeBody(t, w, &pair)
+	return pair
+}
+
+// addAdmin crea un administrador directamente en el repositorio y devuelve su token de acceso
+func addAdmin(t *testing.T, api http.Handler, repo *MemoryUserRepository) (User, string) {
+	t.Helper()
+	admin := register(t, api, repo, "Root", "root@example.com")
+	if _, err := repo.ChangeRole(context.Background(), admin.ID, auth.RoleAdmin, admin.ID); err != nil {
+		t.Fatal(err)
+	}
+	return admin, login(t, api, admin.Email).AccessToken
+}
+
+func TestRegisterUser(t *testing.T) {
+	api, repo := newTestAPI(t)
+
+	// El rol del cuerpo se ignora: toda cuenta nueva es de usuario
+	body := `{"name": "Ada", "email": "ada@example.com", "password": "secreto123", "role": "admin"}`
+	expectStatus(t, do(api, http.MethodPost, "/users/register", body, ""), http.StatusCreated)
+
+	user, err := repo.GetByEmail(context.Background(), "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != auth.RoleUser {
+		t.Errorf("rol %q, se esperaba %q", user.Role, auth.RoleUser)
+	}
+	if user.Password == "secreto123" {
+		t.Error("la contraseña se guardó sin encriptar")
+	}
+
+	expectProblem(t, do(api, http.MethodPost, "/users/register", body, ""), http.StatusConflict, "email_taken")
+}
+
+func TestRegisterUserValidation(t *testing.T) {
+	api, _ := newTestAPI(t)
+
+	body := `{"name": "", "email": "no-es-un-correo", "password": "corta"}`
+	problem := expectProblem(t, do(api, http.MethodPost, "/users/register", body, ""), http.StatusBadRequest, "validation_failed")
+	fields := make(map[string]string)
+	for _, field := range problem.Errors {
+		fields[field.Field] = field.Code
+	}
+	want := map[string]string{
+		"name":     apierror.FieldRequired,
+		"email":    apierror.FieldInvalid,
+		"password": apierror.FieldOutOfRange,
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("errores %v, se esperaban %v", fields, want)
+	}
+	for field, code := range want {
+		if fields[field] != code {
+			t.Errorf("campo %s: código %q, se esperaba %q", field, fields[field], code)
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	api, repo := newTestAPI(t)
+	user := register(t, api, repo, "Ada", "ada@example.com")
+
+	pair := login(t, api, user.Email)
+	if pair.AccessToken == "" || pair.RefreshToken == "" {
+		t.Fatalf("tokens vacíos: %+v", pair)
+	}
+
+	w := do(api, http.MethodGet, "/users/me", "", pair.AccessToken)
+	expectStatus(t, w, http.StatusOK)
+	var me User
+	decodeBody(t, w, &me)
+	if me.ID != user.ID || me.Email != user.Email || me.Password != "" {
+		t.Errorf("GET /users/me devolvió %+v", me)
+	}
+
+	wrong := `{"email": "ada@example.com", "password": "otra-clave"}`
+	expectProblem(t, do(api, http.MethodPost, "/users/login", wrong, ""), http.StatusUnauthorized, "invalid_credentials")
+	unknown := `{"email": "nadie@example.com", "password": "secreto123"}`
+	expectProblem(t, do(api, http.MethodPost, "/users/login", unknown, ""), http.StatusUnauthorized, "user_not_found")
+	expectProblem(t, do(api, http.MethodGet, "/users/me", "", ""), http.StatusUnauthorized, "unauthorized")
+}
+
+func TestLogoutRevokesTokens(t *testing.T) {
+	api, repo := newTestAPI(t)
+	user := register(t, api, repo, "Ada", "ada@example.com")
+	pair := login(t, api, user.Email)
+
+	body := fmt.Sprintf(`{"refresh_token": %q}`, pair.RefreshToken)
+	expectStatus(t, do(api, http.MethodPost, "/users/logout", body, pair.AccessToken), http.StatusOK)
+
+	expectProblem(t, do(api, http.MethodGet, "/users/me", "", pair.AccessToken), http.StatusUnauthorized, "unauthorized")
+	expectProblem(t, do(api, http.MethodPost, "/users/refresh", body, ""), http.StatusUnauthorized, "refresh_token_reused")
+}
+
+func TestUpdateMe(t *testing.T) {
+	api, repo := newTestAPI(t)
+	register(t, api, repo, "Grace", "grace@example.com")
+	user := register(t, api, repo, "Ada", "ada@example.com")
+	token := login(t, api, user.Email).AccessToken
+
+	body := `{"name": "Ada Lovelace", "email": "ada@example.org", "locale": "en"}`
+	expectStatus(t, do(api, http.MethodPut, "/users/me", body, token), http.StatusOK)
+	updated, err := repo.GetByID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Ada Lovelace" || updated.Email != "ada@example.org" || updated.Locale != "en" || updated.Role != auth.RoleUser {
+		t.Errorf("usuario actualizado: %+v", updated)
+	}
+
+	taken := `{"name": "Ada", "email": "grace@example.com"}`
+	expectProblem(t, do(api, http.MethodPut, "/users/me", taken, token), http.StatusConflict, "email_taken")
+	// El rol no se puede cambiar desde el perfil
+	role := `{"name": "Ada", "email": "ada@example.org", "role": "admin"}`
+	expectProblem(t, do(api, http.MethodPut, "/users/me", role, token), http.StatusBadRequest, "validation_failed")
+}
+
+func TestChangeRole(t *testing.T) {
+	api, repo := newTestAPI(t)
+	admin, adminToken := addAdmin(t, api, repo)
+	user := register(t, api, repo, "Ada", "ada@example.com")
+	pair := login(t, api, user.Email)
+	userToken := pair.AccessToken
+
+	target := fmt.Sprintf("/users/%d/role", user.ID)
+	expectProblem(t, do(api, http.MethodPut, target, `{"role": "admin"}`, userToken), http.StatusForbidden, "forbidden")
+	expectProblem(t, do(api, http.MethodPut, fmt.Sprintf("/users/%d/role", admin.ID), `{"role": "user"}`, adminToken), http.StatusForbidden, "forbidden")
+	expectProblem(t, do(api, http.MethodPut, target, `{"role": "owner"}`, adminToken), http.StatusBadRequest, "validation_failed")
+	expectProblem(t, do(api, http.MethodPut, "/users/999/role", `{"role": "instructor"}`, adminToken), http.StatusNotFound, "user_not_found")
+
+	w := do(api, http.MethodPut, target, `{"role": "instructor"}`, adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var change RoleChange
+	decodeBody(t, w, &change)
+	if change.OldRole != auth.RoleUser || change.NewRole != auth.RoleInstructor || change.ChangedBy != admin.ID {
+		t.Errorf("cambio de rol: %+v", change)
+	}
+
+	// El cambio de rol cierra las sesiones del usuario, también los tokens de acceso ya emitidos
+	expectProblem(t, do(api, http.MethodGet, "/users/me", "", userToken), http.StatusUnauthorized, "unauthorized")
+	w = do(api, http.MethodGet, "/users/me", "", login(t, api, user.Email).AccessToken)
+	expectStatus(t, w, http.StatusOK)
+	var me User
+	decodeBody(t, w, &me)
+	if me.Role != auth.RoleInstructor {
+		t.Errorf("rol tras volver a iniciar sesión: %q", me.Role)
+	}
+	refresh := fmt.Sprintf(`{"refresh_token": %q}`, pair.RefreshToken)
+	expectProblem(t, do(api, http.MethodPost, "/users/refresh", refresh, ""), http.StatusUnauthorized, "refresh_token_reused")
+
+	w = do(api, http.MethodGet, fmt.Sprintf("/users/%d/role-changes", user.ID), "", adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var changes []RoleChange
+	decodeBody(t, w, &changes)
+	if len(changes) != 1 || changes[0].ID != change.ID {
+		t.Errorf("auditoría: %+v", changes)
+	}
+}
+
+func TestGetAllUsers(t *testing.T) {
+	api, repo := newTestAPI(t)
+	_, adminToken := addAdmin(t, api, repo)
+	user := register(t, api, repo, "Ada", "ada@example.com")
+
+	w := do(api, http.MethodGet, "/users", "", adminToken)
+	expectStatus(t, w, http.StatusOK)
+	var list []User
+	decodeBody(t, w, &list)
+	if len(list) != 2 || list[1].ID != user.ID {
+		t.Fatalf("se obtuvo %+v", list)
+	}
+	for _, u := range list {
+		if u.Password != "" {
+			t.Errorf("el usuario %d expone su contraseña", u.ID)
+		}
+	}
+
+	expectProblem(t, do(api, http.MethodGet, "/users", "", login(t, api, user.Email).AccessToken), http.StatusForbidden, "forbidden")
+}
+
+func TestErrorsFollowAcceptLanguage(t *testing.T) {
+	api, _ := newTestAPI(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+	r.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, r)
+
+	problem := expectProblem(t, w, http.StatusUnauthorized, "unauthorized")
+	if want := i18n.T(i18n.English, "errors.unauthorized"); problem.Detail != want {
+		t.Errorf("detalle %q, se esperaba %q", problem.Detail, want)
+	}
+}
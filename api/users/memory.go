@@ -0,0 +1,138 @@
+package users
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository implementa UserRepository en memoria para pruebas y desarrollo
+type MemoryUserRepository struct {
+	mu          sync.RWMutex
+	users       map[int]User
+	nextID      int
+	roleChanges []RoleChange
+}
+
+// NewMemoryUserRepository crea un repositorio de usuarios vacío
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[int]User), nextID: 1}
+}
+
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return User{}, ErrUserNotFound
+	}
+	user.Password = ""
+	return user, nil
+}
+
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (r *MemoryUserRepository) List(ctx context.Context) ([]User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]User, 0, len(r.users))
+	for _, user := range r.users {
+		user.Password = ""
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.emailTaken(user.Email, 0) {
+		return ErrEmailTaken
+	}
+	user.ID = r.nextID
+	r.nextID++
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *MemoryUserRepository) Update(ctx context.Context, user User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.users[user.ID]
+	if !ok {
+		return ErrUserNotFound
+	}
+	if r.emailTaken(user.Email, user.ID) {
+		return ErrEmailTaken
+	}
+	existing.Name = user.Name
+	existing.Email = user.Email
+	existing.Locale = user.Locale
+	r.users[user.ID] = existing
+	return nil
+}
+
+// emailTaken indica si el correo pertenece a un usuario distinto de exceptID
+func (r *MemoryUserRepository) emailTaken(email string, exceptID int) bool {
+	for _, existing := range r.users {
+		if existing.Email == email && existing.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryUserRepository) ChangeRole(ctx context.Context, userID int, role string, changedBy int) (RoleChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return RoleChange{}, ErrUserNotFound
+	}
+	change := RoleChange{
+		UserID:    userID,
+		ChangedBy: changedBy,
+		OldRole:   user.Role,
+		NewRole:   role,
+		ChangedAt: time.Now().UTC(),
+	}
+	if user.Role == role {
+		return change, nil
+	}
+
+	user.Role = role
+	r.users[userID] = user
+	change.ID = len(r.roleChanges) + 1
+	r.roleChanges = append(r.roleChanges, change)
+	return change, nil
+}
+
+func (r *MemoryUserRepository) ListRoleChanges(ctx context.Context, userID int) ([]RoleChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var changes []RoleChange
+	for _, change := range r.roleChanges {
+		if change.UserID == userID {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
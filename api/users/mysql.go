@@ -0,0 +1,157 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// errDuplicateEntry es el código de error de MySQL al violar un índice único
+const errDuplicateEntry = 1062
+
+// MySQLUserRepository implementa UserRepository sobre las tablas users y role_changes de MySQL.
+// La conexión debe usar parseTime=true.
+type MySQLUserRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLUserRepository crea un repositorio de usuarios sobre la conexión dada
+func NewMySQLUserRepository(db *sql.DB) *MySQLUserRepository {
+	return &MySQLUserRepository{db: db}
+}
+
+func (r *MySQLUserRepository) GetByID(ctx context.Context, id int) (User, error) {
+	var user User
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, email, role, locale FROM users WHERE id = ?", id).
+		Scan(&user.ID, &user.Name, &user.Email, &user.Role, &user.Locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *MySQLUserRepository) GetByEmail(ctx context.Context, email string) (User, error) {
+	var user User
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, email, password, role, locale FROM users WHERE email = ?", email).
+		Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role, &user.Locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *MySQLUserRepository) List(ctx context.Context) ([]User, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, email, role, locale FROM users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Role, &user.Locale); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r *MySQLUserRepository) Create(ctx context.Context, user *User) error {
+	result, err := r.db.ExecContext(ctx, "INSERT INTO users (name, email, password, role, locale) VALUES (?, ?, ?, ?, ?)",
+		user.Name, user.Email, user.Password, user.Role, user.Locale)
+	if isDuplicateEntry(err) {
+		return ErrEmailTaken
+	} else if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	user.ID = int(id)
+	return nil
+}
+
+func (r *MySQLUserRepository) Update(ctx context.Context, user User) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET name = ?, email = ?, locale = ? WHERE id = ?",
+		user.Name, user.Email, user.Locale, user.ID)
+	if isDuplicateEntry(err) {
+		return ErrEmailTaken
+	} else if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		// MySQL informa 0 filas también cuando los valores no cambian
+		if _, err := r.GetByID(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MySQLUserRepository) ChangeRole(ctx context.Context, userID int, role string, changedBy int) (RoleChange, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return RoleChange{}, err
+	}
+	defer tx.Rollback()
+
+	change := RoleChange{UserID: userID, ChangedBy: changedBy, NewRole: role, ChangedAt: time.Now().UTC().Truncate(time.Second)}
+	// El bloqueo de la fila evita que dos cambios simultáneos registren el mismo rol anterior
+	err = tx.QueryRowContext(ctx, "SELECT role FROM users WHERE id = ? FOR UPDATE", userID).Scan(&change.OldRole)
+	if errors.Is(err, sql.ErrNoRows) {
+		return change, ErrUserNotFound
+	} else if err != nil {
+		return change, err
+	}
+	if change.OldRole == role {
+		return change, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET role = ? WHERE id = ?", role, userID); err != nil {
+		return change, err
+	}
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO role_changes (user_id, changed_by, old_role, new_role, changed_at) VALUES (?, ?, ?, ?, ?)",
+		change.UserID, change.ChangedBy, change.OldRole, change.NewRole, change.ChangedAt)
+	if err != nil {
+		return change, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return change, err
+	}
+	change.ID = int(id)
+	return change, tx.Commit()
+}
+
+func (r *MySQLUserRepository) ListRoleChanges(ctx context.Context, userID int) ([]RoleChange, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, user_id, changed_by, old_role, new_role, changed_at FROM role_changes WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []RoleChange
+	for rows.Next() {
+		var change RoleChange
+		if err := rows.Scan(&change.ID, &change.UserID, &change.ChangedBy, &change.OldRole, &change.NewRole, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// isDuplicateEntry indica si err es una violación del índice único del correo
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry
+}
@@ -0,0 +1,31 @@
+package users
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrUserNotFound se devuelve cuando no existe el usuario buscado
+	ErrUserNotFound = errors.New("usuario no encontrado")
+	// ErrEmailTaken se devuelve cuando el correo ya pertenece a otro usuario
+	ErrEmailTaken = errors.New("el correo ya está registrado")
+)
+
+// UserRepository define el acceso a los usuarios persistidos
+type UserRepository interface {
+	// GetByID obtiene un usuario sin su contraseña
+	GetByID(ctx context.Context, id int) (User, error)
+	// GetByEmail obtiene un usuario incluyendo el hash de su contraseña
+	GetByEmail(ctx context.Context, email string) (User, error)
+	List(ctx context.Context) ([]User, error)
+	// Create guarda el usuario y completa su ID
+	Create(ctx context.Context, user *User) error
+	// Update guarda el nombre, el correo y el idioma del usuario; el rol solo cambia con ChangeRole
+	Update(ctx context.Context, user User) error
+	// ChangeRole cambia el rol del usuario y registra el cambio en la auditoría en una sola
+	// operación. Si el usuario ya tenía ese rol no se registra nada y el cambio tiene ID 0.
+	ChangeRole(ctx context.Context, userID int, role string, changedBy int) (RoleChange, error)
+	// ListRoleChanges devuelve los cambios de rol del usuario, del más antiguo al más reciente
+	ListRoleChanges(ctx context.Context, userID int) ([]RoleChange, error)
+}
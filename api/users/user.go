@@ -1,9 +1,30 @@
 package users
 
+import "time"
+
 type User struct {
 	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Role     string `json:"role"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Role     string `json:"role" validate:"omitempty,oneof=admin instructor user"`
+	// Locale es el idioma preferido para los mensajes de la API; vacío usa Accept-Language
+	Locale string `json:"locale,omitempty" validate:"omitempty,oneof=es en"`
+}
+
+// Profile son los datos que el usuario puede modificar de su propia cuenta
+type Profile struct {
+	Name   string `json:"name" validate:"required,max=100"`
+	Email  string `json:"email" validate:"required,email,max=254"`
+	Locale string `json:"locale" validate:"omitempty,oneof=es en"`
+}
+
+// RoleChange es el registro de auditoría de un cambio de rol hecho por el administrador ChangedBy
+type RoleChange struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	ChangedBy int       `json:"changed_by"`
+	OldRole   string    `json:"old_role"`
+	NewRole   string    `json:"new_role"`
+	ChangedAt time.Time `json:"changed_at"`
 }
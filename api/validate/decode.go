@@ -0,0 +1,98 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+)
+
+// MaxBodyBytes es el tamaño máximo del cuerpo JSON de una solicitud
+const MaxBodyBytes = 1 << 20
+
+var errBodyTooLarge = apierror.New(http.StatusRequestEntityTooLarge, "body_too_large", "errors.body_too_large", MaxBodyBytes)
+
+// DecodeJSON decodifica el cuerpo en dst y verifica sus reglas con Struct; los campos de except
+// no se verifican. Rechaza los cuerpos de más de MaxBodyBytes, los campos desconocidos y los de
+// tipo inválido. Si falla responde el error con todos los campos inválidos y devuelve false.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any, except ...string) bool {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		apierror.Write(w, r, errBodyTooLarge)
+		return false
+	} else if err != nil {
+		apierror.Write(w, r, apierror.ErrInvalidBody)
+		return false
+	}
+
+	// Los campos desconocidos y los de tipo inválido no detienen la decodificación, así que dst
+	// queda completo para verificar también el resto de los campos
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(dst)
+	var problems []error
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		if decoder.Decode(&struct{}{}) != io.EOF {
+			apierror.Write(w, r, apierror.ErrInvalidBody)
+			return false
+		}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		problems = append(problems, apierror.Field(typeErr.Field, apierror.FieldInvalid, "validation.type", typeErr.Field))
+		except = append(except, typeErr.Field)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		for _, field := range unknownFields(body, dst, err) {
+			problems = append(problems, apierror.Field(field, apierror.FieldUnknown, "validation.unknown_field", field))
+		}
+	default:
+		apierror.Write(w, r, apierror.ErrInvalidBody)
+		return false
+	}
+
+	if err := Struct(dst, except...); err != nil {
+		problems = append(problems, err)
+	}
+	if len(problems) > 0 {
+		apierror.Write(w, r, apierror.Validation(errors.Join(problems...)))
+		return false
+	}
+	return true
+}
+
+// unknownFields devuelve los campos del primer nivel del cuerpo que no existen en dst. El
+// decodificador solo informa el primero, que se usa si está dentro de un objeto anidado.
+func unknownFields(body []byte, dst any, err error) []string {
+	var object map[string]json.RawMessage
+	json.Unmarshal(body, &object)
+
+	// encoding/json compara los nombres sin distinguir mayúsculas
+	known := make(map[string]bool)
+	t := reflect.TypeOf(dst).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		known[strings.ToLower(jsonName(t.Field(i)))] = true
+	}
+	var unknown []string
+	for key := range object {
+		if !known[strings.ToLower(key)] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		quoted := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		if field, err := strconv.Unquote(quoted); err == nil {
+			return []string{field}
+		}
+		return []string{quoted}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,177 @@
+// Package validate decodifica los cuerpos JSON de las solicitudes y verifica las reglas
+// declaradas en la etiqueta validate de los campos, por ejemplo:
+//
+//	Title string `json:"title" validate:"required,max=200"`
+//
+// Reglas admitidas:
+//   - required: el campo no puede faltar; los textos no pueden estar en blanco
+//   - omitempty: si el campo está vacío no se verifican las demás reglas
+//   - min=N, max=N: límites del valor para los números y de la cantidad de caracteres para los textos
+//   - oneof=a b c: el texto debe ser uno de los valores dados
+//   - email: el texto debe ser un correo electrónico
+//   - objectid: el texto debe ser un ObjectID de MongoDB
+//   - dive: las reglas que siguen se aplican a cada elemento de la lista
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var emailRegex = regexp.MustCompile(`^[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}$`)
+
+// Struct verifica las reglas de los campos de v, que debe ser un struct o un puntero a uno, y
+// devuelve todos los errores unidos con errors.Join. Los campos de except no se verifican.
+func Struct(v any, except ...string) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: se esperaba un struct y se recibió %s", value.Kind()))
+	}
+
+	var problems []error
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		rules, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		if slices.Contains(except, name) {
+			continue
+		}
+		if err := check(name, value.Field(i), rules); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// check aplica las reglas al valor del campo y devuelve el primer error
+func check(name string, value reflect.Value, rules string) error {
+	empty := isEmpty(value)
+	list := strings.Split(rules, ",")
+	for i, rule := range list {
+		rule, arg, _ := strings.Cut(rule, "=")
+		switch rule {
+		case "required":
+			if empty {
+				return apierror.Field(name, apierror.FieldRequired, "validation.required", name)
+			}
+		case "omitempty":
+			if empty {
+				return nil
+			}
+		case "dive":
+			return dive(name, value, strings.Join(list[i+1:], ","))
+		default:
+			if value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					return nil
+				}
+				value = value.Elem()
+			}
+			if err := checkRule(name, value, rule, arg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dive aplica las reglas a cada elemento de la lista, que se nombra en los errores como name[i]
+func dive(name string, value reflect.Value, rules string) error {
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		panic(fmt.Sprintf("validate: dive no se aplica a %s de tipo %s", name, value.Kind()))
+	}
+	var problems []error
+	for i := 0; i < value.Len(); i++ {
+		if err := check(fmt.Sprintf("%s[%d]", name, i), value.Index(i), rules); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// checkRule aplica una regla distinta de required y omitempty
+func checkRule(name string, value reflect.Value, rule, arg string) error {
+	switch rule {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validate: límite %q inválido en %s", arg, name))
+		}
+		if value.Kind() == reflect.String {
+			length := float64(utf8.RuneCountInString(value.String()))
+			if rule == "min" && length < limit {
+				return apierror.Field(name, apierror.FieldOutOfRange, "validation.min_length", name, int(limit))
+			} else if rule == "max" && length > limit {
+				return apierror.Field(name, apierror.FieldOutOfRange, "validation.max_length", name, int(limit))
+			}
+			return nil
+		}
+		number := toFloat(name, value)
+		if rule == "min" && number < limit {
+			return apierror.Field(name, apierror.FieldOutOfRange, "validation.min", name, limit)
+		} else if rule == "max" && number > limit {
+			return apierror.Field(name, apierror.FieldOutOfRange, "validation.max", name, limit)
+		}
+	case "oneof":
+		options := strings.Fields(arg)
+		if !slices.Contains(options, value.String()) {
+			return apierror.Field(name, apierror.FieldInvalid, "validation.one_of", name, strings.Join(options, ", "))
+		}
+	case "email":
+		if !emailRegex.MatchString(value.String()) {
+			return apierror.Field(name, apierror.FieldInvalid, "validation.email")
+		}
+	case "objectid":
+		if !primitive.IsValidObjectID(value.String()) {
+			return apierror.Field(name, apierror.FieldInvalid, "validation.object_id", name)
+		}
+	default:
+		panic(fmt.Sprintf("validate: regla desconocida %q en %s", rule, name))
+	}
+	return nil
+}
+
+// isEmpty indica si el valor falta: un puntero nil, un texto en blanco o el valor cero
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return value.IsNil()
+	default:
+		return value.IsZero()
+	}
+}
+
+func toFloat(name string, value reflect.Value) float64 {
+	switch {
+	case value.CanInt():
+		return float64(value.Int())
+	case value.CanUint():
+		return float64(value.Uint())
+	case value.CanFloat():
+		return value.Float()
+	}
+	panic(fmt.Sprintf("validate: min y max no se aplican a %s de tipo %s", name, value.Kind()))
+}
+
+// jsonName devuelve el nombre del campo en el JSON, que es el que ve el cliente en los errores
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,159 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+)
+
+type course struct {
+	Title    string   `json:"title" validate:"required,max=10"`
+	Level    string   `json:"level" validate:"required,oneof=beginner advanced"`
+	Price    *float64 `json:"price" validate:"omitempty,min=0,max=100"`
+	Seats    int      `json:"seats" validate:"min=1"`
+	Contact  string   `json:"contact" validate:"omitempty,email"`
+	Category string   `json:"category_id" validate:"omitempty,objectid"`
+	Tags     []string `json:"tags" validate:"omitempty,dive,required,max=5"`
+}
+
+// valid devuelve un curso que cumple todas las reglas
+func valid() course {
+	return course{Title: "Go", Level: "beginner", Seats: 1}
+}
+
+// fieldCodes devuelve el campo y el código de cada error de campo de err
+func fieldCodes(err error) []string {
+	var codes []string
+	for _, field := range apierror.Validation(err).Fields {
+		codes = append(codes, field.Field+":"+field.Code)
+	}
+	return codes
+}
+
+func TestStruct(t *testing.T) {
+	price := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name   string
+		modify func(*course)
+		want   []string
+	}{
+		{"válido", func(c *course) {}, nil},
+		{"required vacío", func(c *course) { c.Title = "" }, []string{"title:required"}},
+		{"required en blanco", func(c *course) { c.Title = "   " }, []string{"title:required"}},
+		{"max cuenta caracteres", func(c *course) { c.Title = "Programación" }, []string{"title:out_of_range"}},
+		{"max en el límite", func(c *course) { c.Title = "Diseño Web" }, nil},
+		{"min de número", func(c *course) { c.Seats = 0 }, []string{"seats:out_of_range"}},
+		{"omitempty con nil", func(c *course) { c.Price = nil }, nil},
+		{"max de puntero", func(c *course) { c.Price = price(100.5) }, []string{"price:out_of_range"}},
+		{"min de puntero", func(c *course) { c.Price = price(-1) }, []string{"price:out_of_range"}},
+		{"oneof", func(c *course) { c.Level = "expert" }, []string{"level:invalid"}},
+		{"email", func(c *course) { c.Contact = "ada@example" }, []string{"contact:invalid"}},
+		{"email válido", func(c *course) { c.Contact = "ada@example.com" }, nil},
+		{"objectid", func(c *course) { c.Category = "123" }, []string{"category_id:invalid"}},
+		{"objectid válido", func(c *course) { c.Category = "65a1b2c3d4e5f6a7b8c9d0e1" }, nil},
+		{"dive", func(c *course) { c.Tags = []string{"go", "", "backend"} }, []string{"tags[1]:required", "tags[2]:out_of_range"}},
+		{"dive válido", func(c *course) { c.Tags = []string{"go", "web"} }, nil},
+		{"varios campos", func(c *course) { c.Title, c.Level = "", "" }, []string{"title:required", "level:required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.modify(&c)
+			err := Struct(c)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("error inesperado: %v", err)
+				}
+				return
+			}
+			if got := fieldCodes(err); !slices.Equal(got, tt.want) {
+				t.Errorf("errores %v, se esperaban %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructExcept(t *testing.T) {
+	c := valid()
+	c.Title = ""
+	if err := Struct(&c, "title"); err != nil {
+		t.Errorf("se verificó un campo excluido: %v", err)
+	}
+}
+
+func TestStructFirstErrorPerField(t *testing.T) {
+	// Un campo vacío solo informa required aunque tampoco cumpla oneof
+	c := valid()
+	c.Level = ""
+	var field apierror.FieldError
+	if err := Struct(c); !errors.As(err, &field) || field.Code != apierror.FieldRequired {
+		t.Errorf("error %v, se esperaba required", err)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+		fields []string
+	}{
+		{"válido", `{"title": "Go", "level": "beginner", "seats": 3}`, http.StatusOK, "", nil},
+		{"reglas", `{"title": "", "level": "expert", "seats": 0}`, http.StatusBadRequest, "validation_failed",
+			[]string{"title:required", "level:invalid", "seats:out_of_range"}},
+		{"campos desconocidos", `{"title": "Go", "level": "beginner", "seats": 1, "zeta": 1, "alfa": 2}`, http.StatusBadRequest, "validation_failed",
+			[]string{"alfa:unknown", "zeta:unknown"}},
+		{"tipo inválido", `{"title": "Go", "level": "beginner", "seats": "tres"}`, http.StatusBadRequest, "validation_failed",
+			[]string{"seats:invalid"}},
+		{"tipo inválido y reglas", `{"title": 5, "level": "expert", "seats": 1}`, http.StatusBadRequest, "validation_failed",
+			[]string{"title:invalid", "level:invalid"}},
+		{"elemento de la lista", `{"title": "Go", "level": "beginner", "seats": 1, "tags": ["go", " "]}`, http.StatusBadRequest, "validation_failed",
+			[]string{"tags[1]:required"}},
+		{"JSON inválido", `{"title": `, http.StatusBadRequest, "invalid_body", nil},
+		{"varios valores", `{"title": "Go", "level": "beginner", "seats": 1} {}`, http.StatusBadRequest, "invalid_body", nil},
+		{"demasiado grande", `{"title": "` + strings.Repeat("a", MaxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, "body_too_large", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/courses", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			var c course
+			if ok := DecodeJSON(w, r, &c); ok != (tt.status == http.StatusOK) {
+				t.Fatalf("DecodeJSON devolvió %v: %s", ok, w.Body)
+			}
+			if tt.status == http.StatusOK {
+				return
+			}
+			if w.Code != tt.status {
+				t.Fatalf("estado %d, se esperaba %d: %s", w.Code, tt.status, w.Body)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != apierror.ContentType {
+				t.Errorf("Content-Type %q, se esperaba %q", ct, apierror.ContentType)
+			}
+			var problem apierror.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Code != tt.code || problem.Status != tt.status {
+				t.Errorf("código %q y estado %d, se esperaba %q y %d", problem.Code, problem.Status, tt.code, tt.status)
+			}
+			var fields []string
+			for _, field := range problem.Errors {
+				if field.Message == "" {
+					t.Errorf("el error de %s no tiene mensaje", field.Field)
+				}
+				fields = append(fields, field.Field+":"+field.Code)
+			}
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("errores %v, se esperaban %v", fields, tt.fields)
+			}
+		})
+	}
+}
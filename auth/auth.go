@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/i18n"
+)
+
+var (
+	// ErrMissingToken se devuelve cuando la solicitud no trae token
+	ErrMissingToken = errors.New("token no proporcionado")
+	// ErrInvalidToken se devuelve cuando el token no es válido o expiró
+	ErrInvalidToken = errors.New("token inválido")
+)
+
+// Options configura la emisión de tokens
+type Options struct {
+	// Key es la clave HS256 usada para firmar los tokens de acceso
+	Key      []byte
+	Issuer   string
+	Audience string
+	// AccessTTL es la duración de los tokens de acceso
+	AccessTTL time.Duration
+	// RefreshTTL es la duración de los tokens de refresco
+	RefreshTTL time.Duration
+}
+
+// Service emite, verifica y revoca los tokens de acceso y de refresco
+type Service struct {
+	key        []byte
+	issuer     string
+	audience   string
+	ttl        time.Duration
+	refreshTTL time.Duration
+	store      TokenStore
+}
+
+// NewService crea el servicio de autenticación con las opciones y el almacén de tokens dados
+func NewService(opts Options, store TokenStore) *Service {
+	return &Service{
+		key:        opts.Key,
+		issuer:     opts.Issuer,
+		audience:   opts.Audience,
+		ttl:        opts.AccessTTL,
+		refreshTTL: opts.RefreshTTL,
+		store:      store,
+	}
+}
+
+// IssueToken genera un token de acceso firmado para el usuario
+func (s *Service) IssueToken(identity Identity) (string, *Claims, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		UserID: identity.UserID,
+		Email:  identity.Email,
+		Role:   identity.Role,
+		Locale: identity.Locale,
+		// Versión actual de los tokens del usuario
+		TokenVersion: identity.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.Itoa(identity.UserID),
+			Issuer:    s.issuer,
+			Audience:  jwt.ClaimStrings{s.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+	if err != nil {
+		return "", nil, fmt.Errorf("error al firmar el token: %w", err)
+	}
+	return token, claims, nil
+}
+
+// ParseToken verifica la firma, el emisor, la audiencia y la expiración del token
+func (s *Service) ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.UserID == 0 {
+		return nil, fmt.Errorf("%w: ID de usuario no encontrado en el token", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// TokenFromRequest extrae el token del encabezado Authorization o, si no está, de la cookie "token"
+func TokenFromRequest(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			return "", ErrInvalidToken
+		}
+		return token, nil
+	}
+	if cookie, err := r.Cookie("token"); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+	return "", ErrMissingToken
+}
+
+// Authenticate verifica el token de la solicitud y deja las reclamaciones en el contexto. Si el
+// usuario eligió un idioma, reemplaza al de Accept-Language.
+func (s *Service) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenString, err := TokenFromRequest(r)
+		if err != nil {
+			apierror.Write(w, r, apierror.ErrUnauthorized)
+			return
+		}
+
+		claims, err := s.ParseToken(tokenString)
+		if err != nil {
+			apierror.Write(w, r, apierror.ErrUnauthorized)
+			return
+		}
+
+		// Rechazar los tokens revocados con logout
+		denied, err := s.store.IsTokenIDDenied(r.Context(), claims.ID)
+		if err != nil {
+			log.Println("Error al consultar tokens revocados:", err)
+			apierror.Write(w, r, apierror.ErrInternal)
+			return
+		} else if denied {
+			apierror.Write(w, r, apierror.ErrUnauthorized)
+			return
+		}
+
+		// Rechazar los tokens emitidos antes del último cambio de rol
+		version, err := s.store.UserTokenVersion(r.Context(), claims.UserID)
+		if err != nil {
+			log.Println("Error al consultar la versión de los tokens:", err)
+			apierror.Write(w, r, apierror.ErrInternal)
+			return
+		} else if claims.TokenVersion != version {
+			apierror.Write(w, r, apierror.ErrUnauthorized)
+			return
+		}
+
+		if i18n.Supports(claims.Locale) {
+			r = i18n.Use(w, r, claims.Locale)
+		}
+		next(w, r.WithContext(WithUser(r.Context(), claims)))
+	}
+}
+
+// newTokenID genera un identificador aleatorio para el claim jti
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error al generar el ID del token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims son las reclamaciones tipadas de los tokens de acceso emitidos por el servidor
+type Claims struct {
+	UserID int    `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	// Locale es el idioma preferido del usuario; vacío usa Accept-Language
+	Locale string `json:"locale,omitempty"`
+	// TokenVersion es la versión de los tokens del usuario al emitir este; si cambió, el token ya no vale
+	TokenVersion int `json:"tv,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Identity son los datos del usuario que se firman en el token de acceso
+type Identity struct {
+	UserID int
+	Email  string
+	Role   string
+	Locale string
+	// TokenVersion la completa el servicio al emitir el token con la versión del almacén
+	TokenVersion int
+}
+
+type contextKey struct{}
+
+// WithUser devuelve un contexto que transporta las reclamaciones verificadas del usuario
+func WithUser(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// UserFromContext obtiene las reclamaciones del usuario autenticado, si las hay
+func UserFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
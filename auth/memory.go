@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryTokenStore implementa TokenStore en memoria para pruebas y desarrollo
+type MemoryTokenStore struct {
+	mu      sync.Mutex
+	refresh map[string]RefreshToken
+	denied  map[string]time.Time
+	// versions guarda la versión de los tokens de cada usuario
+	versions map[int]int
+}
+
+// NewMemoryTokenStore crea un almacén de tokens vacío
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		refresh:  make(map[string]RefreshToken),
+		denied:   make(map[string]time.Time),
+		versions: make(map[int]int),
+	}
+}
+
+func (s *MemoryTokenStore) SaveRefreshToken(ctx context.Context, token RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh[token.Hash] = token
+	return nil
+}
+
+func (s *MemoryTokenStore) GetRefreshToken(ctx context.Context, hash string) (RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.refresh[hash]
+	if !ok {
+		return token, ErrRefreshTokenNotFound
+	}
+	return token, nil
+}
+
+func (s *MemoryTokenStore) MarkRefreshTokenReplaced(ctx context.Context, hash, replacedBy string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.refresh[hash]
+	if !ok {
+		return ErrRefreshTokenNotFound
+	}
+	if token.ReplacedBy != "" || token.RevokedAt != nil {
+		return ErrRefreshTokenReused
+	}
+	token.ReplacedBy = replacedBy
+	s.refresh[hash] = token
+	return nil
+}
+
+func (s *MemoryTokenStore) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for hash, token := range s.refresh {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			s.refresh[hash] = token
+		}
+	}
+	return nil
+}
+
+func (s *MemoryTokenStore) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for hash, token := range s.refresh {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			s.refresh[hash] = token
+		}
+	}
+	return nil
+}
+
+func (s *MemoryTokenStore) DenyTokenID(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.denied[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryTokenStore) IsTokenIDDenied(ctx context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.denied[jti]
+	if ok && time.Now().After(expiresAt) {
+		delete(s.denied, jti)
+		return false, nil
+	}
+	return ok, nil
+}
+
+func (s *MemoryTokenStore) BumpUserTokenVersion(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[userID]++
+	return nil
+}
+
+func (s *MemoryTokenStore) UserTokenVersion(ctx context.Context, userID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versions[userID], nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// MySQLTokenStore implementa TokenStore sobre las tablas refresh_tokens, revoked_tokens y
+// user_token_versions, que crean las migraciones de db. La conexión debe usar parseTime=true.
+type MySQLTokenStore struct {
+	db *sql.DB
+}
+
+// NewMySQLTokenStore crea el almacén de tokens sobre la conexión dada
+func NewMySQLTokenStore(db *sql.DB) *MySQLTokenStore {
+	return &MySQLTokenStore{db: db}
+}
+
+func (s *MySQLTokenStore) SaveRefreshToken(ctx context.Context, token RefreshToken) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (token_hash, family_id, user_id, expires_at, created_at) VALUES (?, ?, ?, ?, ?)",
+		token.Hash, token.FamilyID, token.UserID, token.ExpiresAt.UTC(), token.CreatedAt.UTC())
+	return err
+}
+
+func (s *MySQLTokenStore) GetRefreshToken(ctx context.Context, hash string) (RefreshToken, error) {
+	var token RefreshToken
+	var replacedBy sql.NullString
+	var revokedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx,
+		"SELECT token_hash, family_id, user_id, expires_at, created_at, replaced_by, revoked_at FROM refresh_tokens WHERE token_hash = ?",
+		hash).Scan(&token.Hash, &token.FamilyID, &token.UserID, &token.ExpiresAt, &token.CreatedAt, &replacedBy, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return token, ErrRefreshTokenNotFound
+	} else if err != nil {
+		return token, err
+	}
+
+	token.ReplacedBy = replacedBy.String
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+func (s *MySQLTokenStore) MarkRefreshTokenReplaced(ctx context.Context, hash, replacedBy string) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET replaced_by = ? WHERE token_hash = ? AND replaced_by IS NULL AND revoked_at IS NULL",
+		replacedBy, hash)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRefreshTokenReused
+	}
+	return nil
+}
+
+func (s *MySQLTokenStore) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL",
+		time.Now().UTC(), familyID)
+	return err
+}
+
+func (s *MySQLTokenStore) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		time.Now().UTC(), userID)
+	return err
+}
+
+func (s *MySQLTokenStore) DenyTokenID(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT IGNORE INTO revoked_tokens (jti, expires_at) VALUES (?, ?)", jti, expiresAt.UTC())
+	return err
+}
+
+func (s *MySQLTokenStore) IsTokenIDDenied(ctx context.Context, jti string) (bool, error) {
+	var exists int
+	err := s.db.QueryRowContext(ctx,
+		"SELECT 1 FROM revoked_tokens WHERE jti = ? AND expires_at > ?", jti, time.Now().UTC()).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *MySQLTokenStore) BumpUserTokenVersion(ctx context.Context, userID int) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO user_token_versions (user_id, version) VALUES (?, 1) ON DUPLICATE KEY UPDATE version = version + 1",
+		userID)
+	return err
+}
+
+func (s *MySQLTokenStore) UserTokenVersion(ctx context.Context, userID int) (int, error) {
+	var version int
+	err := s.db.QueryRowContext(ctx,
+		"SELECT version FROM user_token_versions WHERE user_id = ?", userID).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return version, err
+}
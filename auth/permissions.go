@@ -0,0 +1,93 @@
+package auth
+
+// Permission es una acción que un rol puede realizar, con la forma recurso:acción.
+// El sufijo ":own" limita la acción a los recursos de los que el usuario es dueño.
+type Permission string
+
+const (
+	PermCourseCreate     Permission = "course:create"
+	PermCourseWrite      Permission = "course:write"
+	PermCourseWriteOwn   Permission = "course:write:own"
+	PermCourseDelete     Permission = "course:delete"
+	PermCourseDeleteOwn  Permission = "course:delete:own"
+	PermUserRead         Permission = "user:read"
+	PermUserWrite        Permission = "user:write"
+	PermEnrollmentSelf   Permission = "enrollment:self"
+	PermEnrollmentManage Permission = "enrollment:manage"
+	PermSearchReindex    Permission = "search:reindex"
+	PermSearchReconcile  Permission = "search:reconcile"
+	PermReviewModerate   Permission = "review:moderate"
+	PermTaxonomyManage   Permission = "taxonomy:manage"
+)
+
+// Roles conocidos
+const (
+	RoleAdmin      = "admin"
+	RoleInstructor = "instructor"
+	RoleUser       = "user"
+)
+
+// Policy asocia cada rol con los permisos que otorga
+type Policy map[string][]Permission
+
+// DefaultPolicy es la política de permisos del servidor
+var DefaultPolicy = Policy{
+	RoleAdmin: {
+		PermCourseCreate,
+		PermCourseWrite,
+		PermCourseWriteOwn,
+		PermCourseDelete,
+		PermCourseDeleteOwn,
+		PermUserRead,
+		PermUserWrite,
+		PermEnrollmentSelf,
+		PermEnrollmentManage,
+		PermSearchReindex,
+		PermSearchReconcile,
+		PermReviewModerate,
+		PermTaxonomyManage,
+	},
+	RoleInstructor: {
+		PermCourseCreate,
+		PermCourseWriteOwn,
+		PermCourseDeleteOwn,
+		PermEnrollmentSelf,
+	},
+	RoleUser: {
+		PermEnrollmentSelf,
+	},
+}
+
+// Allows indica si el rol tiene todos los permisos pedidos; un rol desconocido no tiene ninguno
+func (p Policy) Allows(role string, perms ...Permission) bool {
+	granted := p[role]
+	for _, perm := range perms {
+		if !containsPermission(granted, perm) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowsOwned indica si el usuario puede aplicar perm sobre un recurso del dueño ownerID:
+// con perm puede sobre cualquiera, y con su variante ":own" solo sobre los propios
+func (p Policy) AllowsOwned(claims *Claims, perm Permission, ownerID int) bool {
+	if p.Allows(claims.Role, perm) {
+		return true
+	}
+	return ownerID != 0 && ownerID == claims.UserID && p.Allows(claims.Role, perm+":own")
+}
+
+// Permissions devuelve los permisos otorgados al rol
+func (p Policy) Permissions(role string) []Permission {
+	return append([]Permission(nil), p[role]...)
+}
+
+func containsPermission(perms []Permission, perm Permission) bool {
+	for _, p := range perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package auth
+
+import "testing"
+
+var allPermissions = []Permission{
+	PermCourseCreate,
+	PermCourseWrite,
+	PermCourseWriteOwn,
+	PermCourseDelete,
+	PermCourseDeleteOwn,
+	PermUserRead,
+	PermUserWrite,
+	PermEnrollmentSelf,
+	PermEnrollmentManage,
+	PermSearchReindex,
+	PermSearchReconcile,
+	PermReviewModerate,
+	PermTaxonomyManage,
+}
+
+func TestPolicyAllows(t *testing.T) {
+	granted := map[string][]Permission{
+		RoleAdmin:      allPermissions,
+		RoleInstructor: {PermCourseCreate, PermCourseWriteOwn, PermCourseDeleteOwn, PermEnrollmentSelf},
+		RoleUser:       {PermEnrollmentSelf},
+		"guest":        nil,
+		"":             nil,
+	}
+
+	for role, perms := range granted {
+		for _, perm := range allPermissions {
+			want := containsPermission(perms, perm)
+			if got := DefaultPolicy.Allows(role, perm); got != want {
+				t.Errorf("Allows(%q, %s) = %v, se esperaba %v", role, perm, got, want)
+			}
+		}
+	}
+}
+
+func TestPolicyAllowsSeveral(t *testing.T) {
+	tests := []struct {
+		name  string
+		role  string
+		perms []Permission
+		want  bool
+	}{
+		{"todos otorgados", RoleInstructor, []Permission{PermCourseCreate, PermCourseWriteOwn}, true},
+		{"uno sin otorgar", RoleInstructor, []Permission{PermCourseCreate, PermCourseWrite}, false},
+		{"admin con varios", RoleAdmin, []Permission{PermUserRead, PermUserWrite, PermSearchReindex}, true},
+		{"sin permisos pedidos", RoleUser, nil, true},
+		{"rol desconocido", "guest", []Permission{PermEnrollmentSelf, PermCourseCreate}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultPolicy.Allows(tt.role, tt.perms...); got != tt.want {
+				t.Errorf("Allows(%q, %v) = %v, se esperaba %v", tt.role, tt.perms, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyAllowsOwned(t *testing.T) {
+	const self, other = 7, 8
+	tests := []struct {
+		name    string
+		role    string
+		perm    Permission
+		ownerID int
+		want    bool
+	}{
+		{"admin edita cualquier curso", RoleAdmin, PermCourseWrite, other, true},
+		{"admin borra cualquier curso", RoleAdmin, PermCourseDelete, other, true},
+		{"admin sin dueño", RoleAdmin, PermCourseWrite, 0, true},
+		{"instructor edita su curso", RoleInstructor, PermCourseWrite, self, true},
+		{"instructor no edita ajenos", RoleInstructor, PermCourseWrite, other, false},
+		{"instructor borra su curso", RoleInstructor, PermCourseDelete, self, true},
+		{"instructor no borra ajenos", RoleInstructor, PermCourseDelete, other, false},
+		{"instructor sin dueño", RoleInstructor, PermCourseWrite, 0, false},
+		{"instructor sin variante own", RoleInstructor, PermUserWrite, self, false},
+		{"usuario no edita aunque sea dueño", RoleUser, PermCourseWrite, self, false},
+		{"usuario no borra aunque sea dueño", RoleUser, PermCourseDelete, self, false},
+		{"rol desconocido", "guest", PermCourseWrite, self, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &Claims{UserID: self, Role: tt.role}
+			if got := DefaultPolicy.AllowsOwned(claims, tt.perm, tt.ownerID); got != tt.want {
+				t.Errorf("AllowsOwned(%q, %s, %d) = %v, se esperaba %v", tt.role, tt.perm, tt.ownerID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyPermissionsReturnsCopy(t *testing.T) {
+	perms := DefaultPolicy.Permissions(RoleUser)
+	perms[0] = PermUserWrite
+	if DefaultPolicy.Allows(RoleUser, PermUserWrite) {
+		t.Fatal("modificar el resultado de Permissions cambió la política")
+	}
+}
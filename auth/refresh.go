@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	// ErrRefreshTokenNotFound se devuelve cuando el token de refresco no existe
+	ErrRefreshTokenNotFound = errors.New("token de refresco no encontrado")
+	// ErrRefreshTokenReused se devuelve cuando se presenta un token de refresco ya rotado o revocado
+	ErrRefreshTokenReused = errors.New("token de refresco reutilizado")
+)
+
+// RefreshToken es el registro del servidor para un token de refresco; solo se guarda su hash
+type RefreshToken struct {
+	Hash string
+	// FamilyID agrupa todos los tokens obtenidos rotando el token emitido en el login
+	FamilyID   string
+	UserID     int
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	ReplacedBy string
+	RevokedAt  *time.Time
+}
+
+// TokenStore persiste los tokens de refresco y la lista de tokens de acceso revocados
+type TokenStore interface {
+	SaveRefreshToken(ctx context.Context, token RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (RefreshToken, error)
+	// MarkRefreshTokenReplaced marca el token como rotado solo si seguía activo;
+	// si ya estaba rotado o revocado devuelve ErrRefreshTokenReused
+	MarkRefreshTokenReplaced(ctx context.Context, hash, replacedBy string) error
+	RevokeRefreshFamily(ctx context.Context, familyID string) error
+	// RevokeUserRefreshTokens revoca todas las familias de tokens de refresco del usuario
+	RevokeUserRefreshTokens(ctx context.Context, userID int) error
+	// DenyTokenID agrega el jti a la lista de revocados hasta que el token expire
+	DenyTokenID(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenIDDenied(ctx context.Context, jti string) (bool, error)
+	// BumpUserTokenVersion invalida todos los tokens de acceso emitidos al usuario
+	BumpUserTokenVersion(ctx context.Context, userID int) error
+	// UserTokenVersion devuelve la versión actual de los tokens del usuario; 0 si nunca cambió
+	UserTokenVersion(ctx context.Context, userID int) (int, error)
+}
+
+// TokenPair es el resultado de un login o de un refresco
+type TokenPair struct {
+	AccessToken  string  `json:"token"`
+	RefreshToken string  `json:"refresh_token"`
+	Claims       *Claims `json:"-"`
+	// RefreshExpiresAt es la expiración del token de refresco
+	RefreshExpiresAt time.Time `json:"-"`
+}
+
+// IssueTokenPair emite un token de acceso y un token de refresco de una nueva familia
+func (s *Service) IssueTokenPair(ctx context.Context, identity Identity) (TokenPair, error) {
+	familyID, err := newTokenID()
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return s.issuePair(ctx, familyID, identity)
+}
+
+func (s *Service) issuePair(ctx context.Context, familyID string, identity Identity) (TokenPair, error) {
+	version, err := s.store.UserTokenVersion(ctx, identity.UserID)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("error al obtener la versión de los tokens: %w", err)
+	}
+	identity.TokenVersion = version
+
+	access, claims, err := s.IssueToken(identity)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refresh, record, err := s.newRefreshToken(familyID, identity.UserID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if err := s.store.SaveRefreshToken(ctx, record); err != nil {
+		return TokenPair{}, fmt.Errorf("error al guardar el token de refresco: %w", err)
+	}
+
+	return TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		Claims:           claims,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// UserLookup obtiene los datos actuales del usuario para emitir el nuevo token de acceso
+type UserLookup func(ctx context.Context, userID int) (Identity, error)
+
+// RotateRefreshToken invalida el token de refresco presentado y emite un par nuevo en la misma
+// familia con los datos actuales del usuario. Si el token ya había sido usado se revoca toda la familia.
+func (s *Service) RotateRefreshToken(ctx context.Context, refreshToken string, lookup UserLookup) (TokenPair, error) {
+	record, err := s.activeRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	identity, err := lookup(ctx, record.UserID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	pair, err := s.issuePair(ctx, record.FamilyID, identity)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	// La marca es condicional: si dos solicitudes usan el mismo token solo una gana
+	err = s.store.MarkRefreshTokenReplaced(ctx, record.Hash, hashToken(pair.RefreshToken))
+	if errors.Is(err, ErrRefreshTokenReused) {
+		s.revokeFamily(ctx, record.FamilyID)
+		return TokenPair{}, err
+	} else if err != nil {
+		return TokenPair{}, err
+	}
+	return pair, nil
+}
+
+// activeRefreshToken busca el token y detecta su reutilización
+func (s *Service) activeRefreshToken(ctx context.Context, refreshToken string) (RefreshToken, error) {
+	record, err := s.store.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return record, err
+	}
+
+	if record.ReplacedBy != "" || record.RevokedAt != nil {
+		// Un token ya rotado que vuelve a aparecer indica que fue robado
+		log.Println("Reutilización de token de refresco detectada para el usuario", record.UserID)
+		s.revokeFamily(ctx, record.FamilyID)
+		return record, ErrRefreshTokenReused
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return record, ErrInvalidToken
+	}
+	return record, nil
+}
+
+// Revoke invalida el token de acceso actual y la familia del token de refresco, si se informa
+func (s *Service) Revoke(ctx context.Context, claims *Claims, refreshToken string) error {
+	if claims != nil {
+		if err := s.store.DenyTokenID(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			return fmt.Errorf("error al revocar el token de acceso: %w", err)
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	record, err := s.store.GetRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, ErrRefreshTokenNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// Solo el dueño puede cerrar la sesión de su familia de tokens
+	if claims != nil && record.UserID != claims.UserID {
+		return nil
+	}
+	return s.store.RevokeRefreshFamily(ctx, record.FamilyID)
+}
+
+// RevokeUserSessions cierra todas las sesiones del usuario: invalida sus tokens de acceso ya
+// emitidos y revoca sus tokens de refresco
+func (s *Service) RevokeUserSessions(ctx context.Context, userID int) error {
+	if err := s.store.BumpUserTokenVersion(ctx, userID); err != nil {
+		return fmt.Errorf("error al invalidar los tokens de acceso del usuario %d: %w", userID, err)
+	}
+	if err := s.store.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return fmt.Errorf("error al revocar los tokens de refresco del usuario %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (s *Service) revokeFamily(ctx context.Context, familyID string) {
+	if err := s.store.RevokeRefreshFamily(ctx, familyID); err != nil {
+		log.Println("Error al revocar la familia de tokens:", err)
+	}
+}
+
+// newRefreshToken genera un token opaco y su registro
+func (s *Service) newRefreshToken(familyID string, userID int) (string, RefreshToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", RefreshToken{}, fmt.Errorf("error al generar el token de refresco: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now().UTC()
+	return token, RefreshToken{
+		Hash:      hashToken(token),
+		FamilyID:  familyID,
+		UserID:    userID,
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	}, nil
+}
+
+// hashToken calcula el hash con el que se guarda un token de refresco
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/hugodiazo/arq-soft-2/api/courses"
+	"github.com/hugodiazo/arq-soft-2/api/search"
+	"github.com/hugodiazo/arq-soft-2/config"
+	"github.com/hugodiazo/arq-soft-2/db"
+)
+
+// searchCommandDeps conecta MongoDB y el motor de búsqueda para los subcomandos
+func searchCommandDeps(cfg config.Config) (courses.CourseRepository, search.Engine) {
+	if cfg.SearchEngine == search.EngineMemory {
+		log.Fatal("El motor en memoria solo existe dentro del servidor")
+	}
+
+	db.ConnectMongoDB(cfg.MongoURI, cfg.MongoDatabase)
+	engine, err := search.NewEngine(cfg.SearchEngine, cfg.SolrURL, cfg.SolrConfigSet)
+	if err != nil {
+		log.Fatal("Error al crear el motor de búsqueda:", err)
+	}
+	return courses.NewMongoCourseRepository(db.MongoDB), engine
+}
+
+// runReindex reconstruye el índice de búsqueda a partir de MongoDB y termina
+func runReindex(cfg config.Config) {
+	repo, engine := searchCommandDeps(cfg)
+	count, err := courses.NewReindexer(repo, engine).Run(context.Background())
+	if err != nil {
+		log.Fatal("La reindexación falló:", err)
+	}
+	log.Printf("Reindexación completa: %d cursos", count)
+}
+
+// runReconcile compara MongoDB con el índice, imprime el reporte en JSON y termina
+func runReconcile(cfg config.Config, args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	repair := flags.Bool("repair", false, "corregir las diferencias encontradas")
+	flags.Parse(args)
+
+	repo, engine := searchCommandDeps(cfg)
+	report, err := courses.NewReconciler(repo, engine).Run(context.Background(), *repair)
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	out.Encode(report)
+	if err != nil {
+		log.Fatal("La reconciliación falló:", err)
+	}
+}
+
+// migrate aplica las migraciones pendientes de MySQL y la configuración de MongoDB
+func migrate(ctx context.Context) error {
+	migrator, err := db.NewMigrator(db.DB)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		log.Printf("Migración aplicada: %d_%s", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	return db.MigrateMongo(ctx, db.MongoDB)
+}
+
+// runMigrate aplica (up), revierte (down) o lista (status) las migraciones y termina
+func runMigrate(cfg config.Config, args []string) {
+	action := "up"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	steps := flags.Int("steps", 1, "cantidad de migraciones a revertir con down")
+	flags.Parse(args)
+	if action != "up" && action != "down" && action != "status" {
+		log.Fatalf("Acción de migrate desconocida: %s (usar up, down o status)", action)
+	}
+
+	db.ConnectDB(cfg.MySQLDSN)
+	ctx := context.Background()
+	switch action {
+	case "up":
+		db.ConnectMongoDB(cfg.MongoURI, cfg.MongoDatabase)
+		if err := migrate(ctx); err != nil {
+			log.Fatal("La migración falló:", err)
+		}
+		log.Println("Migraciones al día")
+	case "down":
+		if *steps < 1 {
+			log.Fatal("-steps debe ser al menos 1")
+		}
+		migrator, err := db.NewMigrator(db.DB)
+		if err != nil {
+			log.Fatal(err)
+		}
+		reverted, err := migrator.Down(ctx, *steps)
+		for _, migration := range reverted {
+			log.Printf("Migración revertida: %d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal("La reversión falló:", err)
+		}
+	case "status":
+		migrator, err := db.NewMigrator(db.DB)
+		if err != nil {
+			log.Fatal(err)
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal("Error al obtener el estado de las migraciones:", err)
+		}
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		out.Encode(statuses)
+	}
+}
@@ -0,0 +1,3 @@
+-- Destructivo: borra la tabla de usuarios con todos sus datos, aunque se haya adoptado una tabla
+-- creada a mano antes de las migraciones. Solo revertir esta versión en bases descartables.
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,9 @@
+-- Tabla de usuarios; IF NOT EXISTS permite adoptar bases creadas a mano antes de las migraciones
+CREATE TABLE IF NOT EXISTS users (
+	id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	password VARCHAR(255) NOT NULL,
+	role VARCHAR(32) NOT NULL DEFAULT 'user',
+	UNIQUE KEY uq_users_email (email)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,17 @@
+-- Tokens de refresco rotados por familia y lista de tokens de acceso revocados
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	token_hash CHAR(64) NOT NULL PRIMARY KEY,
+	family_id CHAR(32) NOT NULL,
+	user_id INT NOT NULL,
+	expires_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL,
+	replaced_by CHAR(64) NULL,
+	revoked_at DATETIME NULL,
+	INDEX idx_refresh_tokens_family (family_id),
+	INDEX idx_refresh_tokens_user (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti CHAR(32) NOT NULL PRIMARY KEY,
+	expires_at DATETIME NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Idioma preferido del usuario para los mensajes de la API; vacío usa Accept-Language
+ALTER TABLE users ADD COLUMN locale VARCHAR(8) NOT NULL DEFAULT '';
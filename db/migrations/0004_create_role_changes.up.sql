@@ -0,0 +1,10 @@
+-- Auditoría de los cambios de rol; changed_by es el administrador que hizo el cambio
+CREATE TABLE IF NOT EXISTS role_changes (
+	id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	changed_by INT NOT NULL,
+	old_role VARCHAR(32) NOT NULL,
+	new_role VARCHAR(32) NOT NULL,
+	changed_at DATETIME NOT NULL,
+	INDEX idx_role_changes_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
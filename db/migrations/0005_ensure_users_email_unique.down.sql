@@ -0,0 +1,2 @@
+-- No se revierte: el índice puede venir de 0001 o de la tabla adoptada, y 0001 lo necesita
+DO 0;
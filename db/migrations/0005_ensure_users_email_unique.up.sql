@@ -0,0 +1,18 @@
+-- Asegura el índice único de email en las tablas de usuarios adoptadas por 0001, que pueden no
+-- tenerlo. Se crea solo si no hay un índice único cuya única columna sea email; si hay correos
+-- repetidos la migración falla y hay que resolverlos a mano antes de reintentar.
+SET @has_unique_email = (
+	SELECT COUNT(*) FROM information_schema.statistics s
+	WHERE s.table_schema = DATABASE() AND s.table_name = 'users' AND s.column_name = 'email' AND s.non_unique = 0
+		AND NOT EXISTS (
+			SELECT 1 FROM information_schema.statistics o
+			WHERE o.table_schema = s.table_schema AND o.table_name = s.table_name
+				AND o.index_name = s.index_name AND o.column_name <> 'email'
+		)
+);
+SET @ensure_unique_email = IF(@has_unique_email = 0,
+	'ALTER TABLE users ADD UNIQUE KEY uq_users_email (email)',
+	'DO 0');
+PREPARE ensure_unique_email FROM @ensure_unique_email;
+EXECUTE ensure_unique_email;
+DEALLOCATE PREPARE ensure_unique_email;
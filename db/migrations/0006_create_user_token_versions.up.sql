@@ -0,0 +1,6 @@
+-- Versión de los tokens de acceso de cada usuario; al cambiar el rol se incrementa y los tokens
+-- firmados con una versión anterior dejan de ser válidos. Sin fila la versión es 0.
+CREATE TABLE IF NOT EXISTS user_token_versions (
+	user_id INT NOT NULL PRIMARY KEY,
+	version INT NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
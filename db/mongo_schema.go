@@ -0,0 +1,249 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionSpec describe el validador y los índices de una colección de MongoDB
+type collectionSpec struct {
+	Name string
+	// Schema es el $jsonSchema del validador; solo exige los campos que la aplicación siempre escribe
+	Schema  bson.M
+	Indexes []mongo.IndexModel
+}
+
+// Tipos del esquema; integer acepta los enteros de Go, que el driver guarda como int o long
+var (
+	integer     = bson.M{"bsonType": bson.A{"int", "long"}}
+	text        = bson.M{"bsonType": "string"}
+	timestamp   = bson.M{"bsonType": "date"}
+	stringArray = bson.M{"bsonType": "array", "items": text}
+)
+
+func oneOf(values ...string) bson.M {
+	return bson.M{"bsonType": "string", "enum": values}
+}
+
+func object(required []string, properties bson.M) bson.M {
+	return bson.M{"bsonType": "object", "required": required, "properties": properties}
+}
+
+// mongoCollections es el esquema de todas las colecciones de la aplicación
+var mongoCollections = []collectionSpec{
+	{
+		Name: "courses",
+		Schema: object([]string{"title"}, bson.M{
+			"title":         text,
+			"instructor_id": integer,
+			"duration":      integer,
+			"availability":  bson.M{"bsonType": "bool"},
+			"capacity":      integer,
+			"enrolled":      integer,
+			"archived_at":   timestamp,
+			"category_path": stringArray,
+			"tags":          stringArray,
+		}),
+		Indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "instructor_id", Value: 1}}},
+			{Keys: bson.D{{Key: "category_path", Value: 1}}},
+			{Keys: bson.D{{Key: "tags", Value: 1}}},
+		},
+	},
+	{
+		Name: "course_deletions",
+		Schema: object([]string{"course_id", "mode", "deleted_at"}, bson.M{
+			"course_id":  text,
+			"mode":       text,
+			"deleted_at": timestamp,
+		}),
+		Indexes: []mongo.IndexModel{{Keys: bson.D{{Key: "course_id", Value: 1}}}},
+	},
+	{
+		Name: "enrollments",
+		Schema: object([]string{"user_id", "course_id", "status", "created_at"}, bson.M{
+			"user_id":    integer,
+			"course_id":  text,
+			"status":     oneOf("pending", "active", "completed", "dropped", "expired"),
+			"progress":   bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0, "maximum": 100},
+			"created_at": timestamp,
+		}),
+		Indexes: []mongo.IndexModel{
+			{
+				// Impide inscribir dos veces al mismo usuario en un curso
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "course_id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				// Lista de espera de cada curso en orden de llegada
+				Keys: bson.D{{Key: "course_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+			},
+		},
+	},
+	{
+		Name: "quizzes",
+		Schema: object([]string{"course_id", "title", "questions"}, bson.M{
+			"course_id": text,
+			"title":     text,
+			"questions": bson.M{"bsonType": "array"},
+		}),
+		Indexes: []mongo.IndexModel{{Keys: bson.D{{Key: "course_id", Value: 1}, {Key: "created_at", Value: 1}}}},
+	},
+	{
+		Name: "quiz_attempts",
+		Schema: object([]string{"quiz_id", "user_id", "number", "status"}, bson.M{
+			"quiz_id": text,
+			"user_id": integer,
+			"number":  integer,
+			"status":  oneOf("in_progress", "submitted", "expired"),
+		}),
+		Indexes: []mongo.IndexModel{{
+			// Impide superar el límite de intentos con solicitudes simultáneas
+			Keys:    bson.D{{Key: "quiz_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "number", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+	},
+	{
+		Name: "certificates",
+		Schema: object([]string{"user_id", "course_id", "key_id", "signature"}, bson.M{
+			"user_id":   integer,
+			"course_id": text,
+			"key_id":    text,
+			"signature": text,
+		}),
+		Indexes: []mongo.IndexModel{{
+			// Impide emitir dos certificados para la misma inscripción
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "course_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+	},
+	{
+		Name: "reviews",
+		Schema: object([]string{"course_id", "user_id", "rating", "status"}, bson.M{
+			"course_id": text,
+			"user_id":   integer,
+			"rating":    bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 1, "maximum": 5},
+			"status":    oneOf("visible", "hidden"),
+		}),
+		Indexes: []mongo.IndexModel{
+			{
+				// Una reseña por usuario y curso
+				Keys:    bson.D{{Key: "course_id", Value: 1}, {Key: "user_id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{Keys: bson.D{{Key: "course_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+	},
+	{
+		Name: "categories",
+		Schema: object([]string{"name", "slug"}, bson.M{
+			"name":      text,
+			"slug":      text,
+			"parent_id": text,
+			"path":      stringArray,
+		}),
+		Indexes: []mongo.IndexModel{{
+			// Slug único entre categorías hermanas
+			Keys:    bson.D{{Key: "parent_id", Value: 1}, {Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+	},
+	{
+		Name: "tags",
+		Schema: object([]string{"name", "slug"}, bson.M{
+			"name":    text,
+			"slug":    text,
+			"aliases": stringArray,
+		}),
+		Indexes: []mongo.IndexModel{
+			{Keys: bson.D{{Key: "slug", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "aliases", Value: 1}}},
+		},
+	},
+}
+
+// MigrateMongo crea las colecciones que falten con su validador, actualiza el validador de las
+// existentes y crea los índices. Se puede ejecutar cualquier cantidad de veces. Los validadores
+// usan el nivel moderate: los documentos anteriores que no cumplen el esquema solo se validan
+// cuando se corrigen.
+func MigrateMongo(ctx context.Context, database *mongo.Database) error {
+	existing, err := database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range mongoCollections {
+		validator := bson.M{"$jsonSchema": spec.Schema}
+		if slices.Contains(existing, spec.Name) {
+			err = database.RunCommand(ctx, bson.D{
+				{Key: "collMod", Value: spec.Name},
+				{Key: "validator", Value: validator},
+				{Key: "validationLevel", Value: "moderate"},
+				{Key: "validationAction", Value: "error"},
+			}).Err()
+		} else {
+			err = database.CreateCollection(ctx, spec.Name, options.CreateCollection().
+				SetValidator(validator).
+				SetValidationLevel("moderate").
+				SetValidationAction("error"))
+		}
+		if err != nil {
+			return fmt.Errorf("error al configurar la colección %s: %w", spec.Name, err)
+		}
+
+		collection := database.Collection(spec.Name)
+		if _, err := collection.Indexes().CreateMany(ctx, spec.Indexes); mongo.IsDuplicateKeyError(err) {
+			return duplicatesError(ctx, collection, spec.Indexes, err)
+		} else if err != nil {
+			return fmt.Errorf("error al crear los índices de %s: %w", spec.Name, err)
+		}
+	}
+	return nil
+}
+
+// duplicatesError explica qué documentos anteriores impiden crear un índice único para que se
+// puedan corregir a mano; no se borran solos porque no se sabe cuál de los repetidos conservar
+func duplicatesError(ctx context.Context, collection *mongo.Collection, indexes []mongo.IndexModel, cause error) error {
+	for _, index := range indexes {
+		keys, ok := index.Keys.(bson.D)
+		if !ok || index.Options == nil || index.Options.Unique == nil || !*index.Options.Unique {
+			continue
+		}
+		group := bson.D{}
+		fields := make([]string, 0, len(keys))
+		for _, key := range keys {
+			group = append(group, bson.E{Key: key.Key, Value: "$" + key.Key})
+			fields = append(fields, key.Key)
+		}
+		cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: group},
+				{Key: "ids", Value: bson.D{{Key: "$push", Value: "$_id"}}},
+				{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			}}},
+			{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+			{{Key: "$limit", Value: 5}},
+		})
+		if err != nil {
+			break
+		}
+		var duplicates []bson.M
+		if err := cursor.All(ctx, &duplicates); err != nil || len(duplicates) == 0 {
+			continue
+		}
+		examples := make([]string, 0, len(duplicates))
+		for _, duplicate := range duplicates {
+			examples = append(examples, fmt.Sprintf("%v (documentos %v)", duplicate["_id"], duplicate["ids"]))
+		}
+		return fmt.Errorf("no se puede crear el índice único (%s) de %s porque hay documentos repetidos, por ejemplo: %s; "+
+			"elimina o une los repetidos y vuelve a iniciar el servidor: %w",
+			strings.Join(fields, ", "), collection.Name(), strings.Join(examples, "; "), cause)
+	}
+	return fmt.Errorf("error al crear los índices de %s: %w", collection.Name(), cause)
+}
@@ -12,8 +12,8 @@ import (
 var MongoDB *mongo.Database
 
 // ConnectMongoDB establece la conexión a MongoDB
-func ConnectMongoDB() {
-	client, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
+func ConnectMongoDB(uri, database string) {
+	client, err := mongo.NewClient(options.Client().ApplyURI(uri))
 	if err != nil {
 		log.Fatal("Error al crear cliente de MongoDB:", err)
 	}
@@ -26,6 +26,6 @@ func ConnectMongoDB() {
 		log.Fatal("Error al conectar a MongoDB:", err)
 	}
 
-	MongoDB = client.Database("arqsoft2")
+	MongoDB = client.Database(database)
 	log.Println("Conexión a MongoDB exitosa")
 }
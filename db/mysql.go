@@ -10,8 +10,7 @@ import (
 var DB *sql.DB
 
 // ConnectDB establece la conexión a la base de datos MySQL
-func ConnectDB() {
-	dsn := "root:Pirata02@tcp(127.0.0.1:3306)/arqsoft2"
+func ConnectDB(dsn string) {
 	var err error
 
 	DB, err = sql.Open("mysql", dsn)
@@ -1,11 +1,13 @@
-import React, { useEffect, useState } from 'react';
+import React, { useEffect, useState, useContext } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import API_URL from '../config';
+import AuthContext from '../context/AuthContext';
 import './CourseDetail.css';
 
 const CourseDetail = () => {
   const { id } = useParams();
   const [course, setCourse] = useState(null);
+  const { isAuthenticated, authFetch } = useContext(AuthContext);
   const navigate = useNavigate();
 
   useEffect(() => {
@@ -27,26 +29,28 @@ const CourseDetail = () => {
 
   const handleEnrollment = async () => {
     try {
-      const token = localStorage.getItem('token');
-      if (!token) {
+      if (!isAuthenticated) {
         alert('Debes iniciar sesión para inscribirte en un curso');
         return;
       }
 
-      const response = await fetch(`${API_URL}/courses/enroll`, {
+      const response = await authFetch(`${API_URL}/courses/enroll`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
-          Authorization: `Bearer ${token}`,
         },
         body: JSON.stringify({ course_id: id }),
       });
 
       if (response.ok) {
-        alert('¡Inscripción exitosa!');
+        const data = await response.json();
+        // Con el curso lleno la inscripción queda pendiente en la lista de espera
+        alert(data.status === 'pending' ? data.message : '¡Inscripción exitosa!');
         navigate('/mis-cursos');
       } else {
-        alert('Error al inscribirse en el curso');
+        // Los errores llegan como application/problem+json con el mensaje en detail
+        const problem = await response.json().catch(() => null);
+        alert((problem && problem.detail) || 'Error al inscribirse en el curso');
       }
     } catch (error) {
       console.error('Error al inscribirse:', error);
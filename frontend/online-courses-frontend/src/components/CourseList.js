@@ -12,7 +12,7 @@ const CourseList = () => {
       try {
         const response = await fetch(`${API_URL}/courses`);
         const data = await response.json();
-        setCourses(data);
+        setCourses(data.data || []); // La respuesta es una página: { data, next_cursor, total }
       } catch (error) {
         console.error('Error al obtener los cursos:', error);
       }
@@ -4,7 +4,7 @@ import API_URL from '../config';
 import AuthContext from '../context/AuthContext';
 
 const CreateCourse = () => {
-  const { userRole } = useContext(AuthContext);
+  const { userRole, authFetch } = useContext(AuthContext);
   console.log("Rol del usuario en CreateCourse:", userRole);
   const [title, setTitle] = useState('');
   const [description, setDescription] = useState('');
@@ -15,18 +15,16 @@ const CreateCourse = () => {
   const handleSubmit = async (e) => {
     e.preventDefault();
 
-    if (userRole !== 'admin') {
+    if (userRole !== 'admin' && userRole !== 'instructor') {
       alert('No tienes permiso para crear un curso');
       return;
     }
 
     try {
-      const token = localStorage.getItem('token');
-      const response = await fetch(`${API_URL}/courses`, {
+      const response = await authFetch(`${API_URL}/courses`, {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
-          Authorization: `Bearer ${token}`,
         },
         body: JSON.stringify({
           title,
@@ -14,6 +14,7 @@ function Login() {
     fetch('http://localhost:8080/users/login', {
       method: 'POST',
       headers: { 'Content-Type': 'application/json' },
+      credentials: 'include', // Para guardar la cookie HttpOnly con el token de refresco
       body: JSON.stringify({ email, password }),
     })
       .then((response) => response.json())
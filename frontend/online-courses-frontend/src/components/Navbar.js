@@ -18,7 +18,7 @@ function Navbar() {
       <ul>
         <li><Link to="/">Home</Link></li>
         {isAuthenticated && <li><Link to="/mis-cursos">Mis Cursos</Link></li>}
-        {isAuthenticated && userRole === 'admin' && <li><Link to="/crear-curso">Crear Curso</Link></li>}
+        {isAuthenticated && (userRole === 'admin' || userRole === 'instructor') && <li><Link to="/crear-curso">Crear Curso</Link></li>}
         {!isAuthenticated ? (
           <>
             <li><Link to="/login">Login</Link></li>
@@ -7,7 +7,6 @@ function Register() {
   const [name, setName] = useState('');
   const [email, setEmail] = useState('');
   const [password, setPassword] = useState('');
-  const [role, setRole] = useState('user'); // Establece "user" como valor por defecto
   const [message, setMessage] = useState('');
   const navigate = useNavigate();
 
@@ -18,7 +17,7 @@ function Register() {
         headers: {
           'Content-Type': 'application/json',
         },
-        body: JSON.stringify({ name, email, password, role }),
+        body: JSON.stringify({ name, email, password }),
       });
   
       if (!response.ok) {
@@ -57,10 +56,6 @@ function Register() {
             value={password}
             onChange={(e) => setPassword(e.target.value)}
         />
-        <select value={role} onChange={(e) => setRole(e.target.value)}>
-            <option value="user">Usuario</option>
-            <option value="admin">Administrador</option>
-        </select>
         <button type="button" onClick={handleRegister}>
             Registrarse
         </button>
@@ -18,7 +18,7 @@ function Search() {
       }
 
       const data = await response.json();
-      setResults(data.response.docs); // Ajusta esto según la estructura de tu respuesta de Solr
+      setResults(data.results); // { results, total, page, page_size, total_pages, facets }
     } catch (error) {
       console.error('Error al buscar cursos:', error);
       alert('Hubo un problema al realizar la búsqueda');
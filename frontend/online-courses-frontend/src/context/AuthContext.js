@@ -1,40 +1,108 @@
 // src/context/AuthContext.js
-import React, { createContext, useState, useEffect } from 'react';
+import React, { createContext, useState, useEffect, useCallback, useRef } from 'react';
+import API_URL from '../config';
 
 const AuthContext = createContext();
 
+// El token de refresco viaja solo en la cookie HttpOnly refresh_token, que el navegador envía a
+// /users/refresh y /users/logout; nunca se guarda en localStorage
 export const AuthProvider = ({ children }) => {
   const [isAuthenticated, setIsAuthenticated] = useState(false);
   const [userRole, setUserRole] = useState(null);
+  // refreshing comparte el refresco en curso: dos refrescos con la misma cookie revocarían la sesión
+  const refreshing = useRef(null);
 
-  useEffect(() => {
-    const token = localStorage.getItem('token');
-    if (token) {
-      setIsAuthenticated(true);
-      const payload = JSON.parse(atob(token.split('.')[1])); // Decodificar el token JWT
-      console.log("Rol del usuario:", payload.role); // Agrega este console.log
-      setUserRole(payload.role); // Obtener y guardar el rol del usuario
-    }
-  }, []);
-
-  const login = (token) => {
+  const applyToken = (token) => {
     localStorage.setItem('token', token);
     setIsAuthenticated(true);
     const payload = JSON.parse(atob(token.split('.')[1])); // Decodificar el token JWT
     setUserRole(payload.role);
   };
 
-  const logout = () => {
+  const clearSession = () => {
     localStorage.removeItem('token');
     setIsAuthenticated(false);
     setUserRole(null);
   };
 
+  useEffect(() => {
+    // Las versiones anteriores guardaban el token de refresco en localStorage
+    localStorage.removeItem('refreshToken');
+    const token = localStorage.getItem('token');
+    if (token) {
+      applyToken(token);
+    }
+  }, []);
+
+  const login = (token) => {
+    applyToken(token);
+  };
+
+  // refreshToken pide un token de acceso nuevo con la cookie de refresco; devuelve null si la
+  // sesión ya no es válida
+  const refreshToken = useCallback(() => {
+    if (!refreshing.current) {
+      refreshing.current = fetch(`${API_URL}/users/refresh`, {
+        method: 'POST',
+        credentials: 'include',
+      })
+        .then((response) => (response.ok ? response.json() : null))
+        .then((data) => {
+          if (data && data.token) {
+            applyToken(data.token);
+            return data.token;
+          }
+          clearSession();
+          return null;
+        })
+        .catch((error) => {
+          console.error('Error al refrescar la sesión:', error);
+          return null;
+        })
+        .finally(() => {
+          refreshing.current = null;
+        });
+    }
+    return refreshing.current;
+  }, []);
+
+  // authFetch hace la solicitud con el token de acceso; si el servidor responde 401 refresca el
+  // token una vez y la repite
+  const authFetch = useCallback(
+    async (url, options = {}) => {
+      const send = (token) =>
+        fetch(url, {
+          ...options,
+          headers: { ...options.headers, Authorization: `Bearer ${token}` },
+        });
+
+      const response = await send(localStorage.getItem('token'));
+      if (response.status !== 401) {
+        return response;
+      }
+      const token = await refreshToken();
+      return token ? send(token) : response;
+    },
+    [refreshToken]
+  );
+
+  const logout = async () => {
+    if (localStorage.getItem('token')) {
+      // Revocar la sesión en el servidor con la cookie de refresco; el estado local se limpia igual
+      try {
+        await authFetch(`${API_URL}/users/logout`, { method: 'POST', credentials: 'include' });
+      } catch (error) {
+        console.error('Error al cerrar sesión:', error);
+      }
+    }
+    clearSession();
+  };
+
   return (
-    <AuthContext.Provider value={{ isAuthenticated, userRole, login, logout }}>
+    <AuthContext.Provider value={{ isAuthenticated, userRole, login, logout, authFetch }}>
       {children}
     </AuthContext.Provider>
   );
 };
 
-export default AuthContext;
\ No newline at end of file
+export default AuthContext;
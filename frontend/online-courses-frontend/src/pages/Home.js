@@ -1,49 +1,66 @@
 // src/pages/Home.js
 import React, { useState, useEffect } from 'react';
 import { Link } from 'react-router-dom'; // Importa Link de react-router-dom
+import API_URL from '../config';
 import './Home.css';
 
 function Home() {
   const [query, setQuery] = useState('');
   const [courses, setCourses] = useState([]);
+  const [nextCursor, setNextCursor] = useState('');
   const [loading, setLoading] = useState(false);
+  // Filtros, orden y facetas de la búsqueda; search es null mientras se muestra el listado
+  const [level, setLevel] = useState('');
+  const [availableOnly, setAvailableOnly] = useState(false);
+  const [sort, setSort] = useState('relevance');
+  const [search, setSearch] = useState(null);
 
-  useEffect(() => {
-    const fetchCourses = async () => {
-      setLoading(true);
-      try {
-        const response = await fetch('http://localhost:8080/courses');
-        if (!response.ok) {
-          throw new Error('Error al obtener los cursos');
-        }
-        const data = await response.json();
-        setCourses(data);
-      } catch (error) {
-        console.error('Error al obtener los cursos:', error);
-        alert('Hubo un problema al obtener los cursos');
-      } finally {
-        setLoading(false);
+  // fetchCourses pide una página del listado; con after agrega la página siguiente a la actual
+  const fetchCourses = async (after = '') => {
+    setLoading(true);
+    try {
+      const url = after ? `${API_URL}/courses?after=${encodeURIComponent(after)}` : `${API_URL}/courses`;
+      const response = await fetch(url);
+      if (!response.ok) {
+        throw new Error('Error al obtener los cursos');
       }
-    };
+      const data = await response.json(); // La respuesta es una página: { data, next_cursor, total }
+      const page = data.data || [];
+      setCourses((current) => (after ? [...current, ...page] : page));
+      setNextCursor(data.next_cursor || '');
+    } catch (error) {
+      console.error('Error al obtener los cursos:', error);
+      alert('Hubo un problema al obtener los cursos');
+    } finally {
+      setLoading(false);
+    }
+  };
 
+  useEffect(() => {
     fetchCourses();
   }, []);
 
-  const handleSearch = async (e) => {
-    e.preventDefault();
+  // runSearch consulta /search con el texto, los filtros y el orden dados; la respuesta es
+  // { results, total, page, page_size, total_pages, facets }
+  const runSearch = async ({ text = query, levelFilter = level, available = availableOnly, order = sort, page = 1 } = {}) => {
     setLoading(true);
-
     try {
-      const response = query.trim()
-        ? await fetch(`http://localhost:8080/search?q=${encodeURIComponent(query)}`)
-        : await fetch('http://localhost:8080/courses');
-
+      const params = new URLSearchParams({ q: text, sort: order, page: String(page) });
+      if (levelFilter) {
+        params.set('level', levelFilter);
+      }
+      if (available) {
+        params.set('availability', 'true');
+      }
+      const response = await fetch(`${API_URL}/search?${params}`);
       if (!response.ok) {
         throw new Error('Error al realizar la búsqueda');
       }
 
       const data = await response.json();
-      setCourses(query.trim() ? data.response.docs : data);
+      setCourses(data.results || []);
+      setSearch(data);
+      setNextCursor('');
     } catch (error) {
       console.error('Error al buscar cursos:', error);
       alert('Hubo un problema al realizar la búsqueda');
@@ -52,6 +69,23 @@ function Home() {
     }
   };
 
+  const handleSearch = (e) => {
+    e.preventDefault();
+    if (!query.trim() && !level && !availableOnly) {
+      setSearch(null);
+      fetchCourses();
+      return;
+    }
+    runSearch();
+  };
+
+  // Elegir una faceta de nivel filtra por ese nivel; elegirla de nuevo quita el filtro
+  const toggleLevel = (value) => {
+    const next = level === value ? '' : value;
+    setLevel(next);
+    runSearch({ levelFilter: next });
+  };
+
   return (
     <div className="home">
       <h2>Bienvenido a la Plataforma de Cursos</h2>
@@ -62,8 +96,34 @@ function Home() {
           value={query}
           onChange={(e) => setQuery(e.target.value)}
         />
+        <select value={sort} onChange={(e) => setSort(e.target.value)}>
+          <option value="relevance">Más relevantes</option>
+          <option value="rating">Mejor calificados</option>
+        </select>
+        <label>
+          <input
+            type="checkbox"
+            checked={availableOnly}
+            onChange={(e) => setAvailableOnly(e.target.checked)}
+          />
+          Solo disponibles
+        </label>
         <button type="submit">Buscar</button>
       </form>
+      {search && search.facets && search.facets.level && (
+        <div className="facets">
+          {search.facets.level.map((facet) => (
+            <button
+              key={facet.value}
+              type="button"
+              className={facet.value === level ? 'facet active' : 'facet'}
+              onClick={() => toggleLevel(facet.value)}
+            >
+              {facet.value} ({facet.count})
+            </button>
+          ))}
+        </div>
+      )}
       {loading && <p>Cargando...</p>}
       <div className="courses">
         {courses.length > 0 ? (
@@ -83,6 +143,24 @@ function Home() {
           <p>No se encontraron cursos.</p>
         )}
       </div>
+      {search && search.total_pages > 1 && (
+        <div className="pages">
+          <button disabled={search.page <= 1 || loading} onClick={() => runSearch({ page: search.page - 1 })}>
+            Anterior
+          </button>
+          <span>
+            Página {search.page} de {search.total_pages}
+          </span>
+          <button disabled={search.page >= search.total_pages || loading} onClick={() => runSearch({ page: search.page + 1 })}>
+            Siguiente
+          </button>
+        </div>
+      )}
+      {nextCursor && !loading && (
+        <button className="load-more" onClick={() => fetchCourses(nextCursor)}>
+          Cargar más
+        </button>
+      )}
     </div>
   );
 }
@@ -5,7 +5,7 @@ import AuthContext from '../context/AuthContext';
 
 const MyCourses = () => {
   const [courses, setCourses] = useState([]);
-  const { isAuthenticated } = useContext(AuthContext);
+  const { isAuthenticated, authFetch } = useContext(AuthContext);
   const navigate = useNavigate();
 
   useEffect(() => {
@@ -16,13 +16,9 @@ const MyCourses = () => {
 
     const fetchMyCourses = async () => {
       try {
-        const token = localStorage.getItem('token');
-        console.log("Token enviado en la solicitud:", token);
-
-        const response = await fetch(`${API_URL}/enrollments`, {
+        const response = await authFetch(`${API_URL}/enrollments`, {
           headers: {
             'Content-Type': 'application/json',
-            Authorization: `Bearer ${token}`,
           },
         });
 
@@ -38,16 +34,12 @@ const MyCourses = () => {
     };
 
     fetchMyCourses();
-  }, [isAuthenticated, navigate]);
+  }, [isAuthenticated, navigate, authFetch]);
 
   const handleUnenroll = async (courseId) => {
     try {
-      const token = localStorage.getItem('token');
-      const response = await fetch(`${API_URL}/courses/unenroll?course_id=${courseId}`, {
+      const response = await authFetch(`${API_URL}/courses/unenroll?course_id=${courseId}`, {
         method: 'DELETE',
-        headers: {
-          Authorization: `Bearer ${token}`,
-        },
       });
 
       if (response.ok) {
@@ -0,0 +1,139 @@
+// Package i18n traduce los mensajes de la API. Los textos están en catálogos por idioma indexados
+// por el ID del mensaje; el español es el idioma por defecto.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Idiomas soportados
+const (
+	Spanish = "es"
+	English = "en"
+	// Default es el idioma cuando la solicitud no pide uno soportado
+	Default = Spanish
+)
+
+// Supported son los idiomas con catálogo, en el orden de preferencia del servidor
+var Supported = []string{Spanish, English}
+
+// localeFiles son los catálogos, uno por idioma con nombre <idioma>.json
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	catalogs = mustLoadCatalogs()
+	matcher  = language.NewMatcher([]language.Tag{language.Spanish, language.English})
+)
+
+// mustLoadCatalogs lee los catálogos embebidos y verifica que todos tengan los mismos mensajes
+// que el del idioma por defecto
+func mustLoadCatalogs() map[string]map[string]string {
+	loaded := make(map[string]map[string]string, len(Supported))
+	for _, lang := range Supported {
+		data, err := localeFiles.ReadFile("locales/" + lang + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: falta el catálogo %s: %v", lang, err))
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: catálogo %s inválido: %v", lang, err))
+		}
+		loaded[lang] = messages
+	}
+
+	for _, lang := range Supported {
+		for id := range loaded[Default] {
+			if _, ok := loaded[lang][id]; !ok {
+				panic(fmt.Sprintf("i18n: al catálogo %s le falta el mensaje %s", lang, id))
+			}
+		}
+		for id := range loaded[lang] {
+			if _, ok := loaded[Default][id]; !ok {
+				panic(fmt.Sprintf("i18n: el catálogo %s tiene el mensaje desconocido %s", lang, id))
+			}
+		}
+	}
+	return loaded
+}
+
+// Supports indica si hay catálogo para el idioma
+func Supports(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// T devuelve el mensaje id en el idioma dado, con args aplicados como en fmt.Sprintf. Si el id
+// no está en el catálogo se devuelve tal cual, lo que permite pasar textos que no se traducen.
+func T(lang, id string, args ...any) string {
+	text, ok := catalogs[lang][id]
+	if !ok {
+		text, ok = catalogs[Default][id]
+	}
+	if !ok {
+		return id
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// Match elige el idioma soportado que mejor coincide con un encabezado Accept-Language
+func Match(acceptLanguage string) string {
+	if strings.TrimSpace(acceptLanguage) == "" {
+		return Default
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return Supported[index]
+}
+
+type contextKey struct{}
+
+// WithLang devuelve un contexto con el idioma de la solicitud
+func WithLang(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, contextKey{}, lang)
+}
+
+// Lang devuelve el idioma de la solicitud, o Default si no se eligió ninguno
+func Lang(ctx context.Context) string {
+	if lang, ok := ctx.Value(contextKey{}).(string); ok {
+		return lang
+	}
+	return Default
+}
+
+// Tr devuelve el mensaje id en el idioma de la solicitud
+func Tr(ctx context.Context, id string, args ...any) string {
+	return T(Lang(ctx), id, args...)
+}
+
+// Use fija el idioma de la respuesta y devuelve la solicitud con ese idioma en su contexto
+func Use(w http.ResponseWriter, r *http.Request, lang string) *http.Request {
+	w.Header().Set("Content-Language", lang)
+	return r.WithContext(WithLang(r.Context(), lang))
+}
+
+// Middleware elige el idioma de cada solicitud según Accept-Language. auth.Authenticate lo
+// reemplaza por la preferencia guardada del usuario, si tiene una.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, Use(w, r, Match(r.Header.Get("Accept-Language"))))
+	})
+}
@@ -1,20 +1,33 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"net/http"
 
+	"github.com/hugodiazo/arq-soft-2/api/apierror"
+	"github.com/hugodiazo/arq-soft-2/api/certificates"
 	"github.com/hugodiazo/arq-soft-2/api/courses"
 	"github.com/hugodiazo/arq-soft-2/api/middleware"
+	"github.com/hugodiazo/arq-soft-2/api/quizzes"
+	"github.com/hugodiazo/arq-soft-2/api/reviews"
 	"github.com/hugodiazo/arq-soft-2/api/search"
+	"github.com/hugodiazo/arq-soft-2/api/taxonomy"
 	"github.com/hugodiazo/arq-soft-2/api/users"
+	"github.com/hugodiazo/arq-soft-2/auth"
+	"github.com/hugodiazo/arq-soft-2/config"
 	"github.com/hugodiazo/arq-soft-2/db"
+	"github.com/hugodiazo/arq-soft-2/i18n"
+	"github.com/hugodiazo/arq-soft-2/queue"
 )
 
-// Middleware para habilitar CORS
-func enableCors(next http.Handler) http.Handler {
+// Middleware para habilitar CORS para el origen configurado; admite credenciales para que el
+// frontend envíe la cookie HttpOnly del token de refresco
+func enableCors(origin string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		if r.Method == "OPTIONS" {
@@ -26,46 +39,206 @@ func enableCors(next http.Handler) http.Handler {
 }
 
 func main() {
+	configPath := flag.String("config", "", "archivo de configuración YAML o JSON (también CONFIG_FILE)")
+	flag.Parse()
+
+	// Cargar la configuración desde el archivo y las variables de entorno
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Subcomandos de mantenimiento; sin argumentos se inicia el servidor
+	switch cmd := flag.Arg(0); cmd {
+	case "", "serve":
+	case "reindex":
+		runReindex(cfg)
+		return
+	case "reconcile":
+		runReconcile(cfg, flag.Args()[1:])
+		return
+	case "migrate":
+		runMigrate(cfg, flag.Args()[1:])
+		return
+	default:
+		log.Fatalf("Comando desconocido: %s (usar serve, migrate, reindex o reconcile)", cmd)
+	}
+
 	// Conexión a la base de datos
-	db.ConnectDB()
-	db.ConnectMongoDB()
+	db.ConnectDB(cfg.MySQLDSN)
+	db.ConnectMongoDB(cfg.MongoURI, cfg.MongoDatabase)
+	if cfg.MigrateOnStart {
+		if err := migrate(context.Background()); err != nil {
+			log.Fatal("Error al migrar las bases de datos:", err)
+		}
+	}
+
+	// Conexión al broker de eventos; sin RabbitMQ se usa una cola en memoria
+	var broker queue.Broker
+	rabbit, err := queue.ConnectRabbitMQ(cfg.RabbitMQURL)
+	if err != nil {
+		log.Println("RabbitMQ no disponible, usando cola en memoria:", err)
+		broker = queue.NewMemoryBroker()
+	} else {
+		broker = rabbit
+	}
+	defer broker.Close()
+
+	// Repositorios y handlers con sus dependencias
+	userRepo := users.NewMySQLUserRepository(db.DB)
+	courseRepo := courses.NewMongoCourseRepository(db.MongoDB)
+	enrollmentRepo := courses.NewMongoEnrollmentRepository(db.MongoDB)
+	quizRepo := quizzes.NewMongoQuizRepository(db.MongoDB)
+	attemptRepo := quizzes.NewMongoAttemptRepository(db.MongoDB)
+	certificateRepo := certificates.NewMongoRepository(db.MongoDB)
+	reviewRepo := reviews.NewMongoRepository(db.MongoDB)
+	categoryRepo := taxonomy.NewMongoCategoryRepository(db.MongoDB)
+	tagRepo := taxonomy.NewMongoTagRepository(db.MongoDB)
+	signer, err := certificates.NewSigner(cfg.CertificateSeed(), cfg.PreviousCertificateKeys()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	issuer := certificates.NewIssuer(certificateRepo, courseRepo, userRepo, signer)
+
+	searchEngine, err := search.NewEngine(cfg.SearchEngine, cfg.SolrURL, cfg.SolrConfigSet)
+	if err != nil {
+		log.Fatal("Error al crear el motor de búsqueda:", err)
+	}
 
-	// Llamar a la función para indexar todos los cursos en Solr
-	courses.IndexAllCoursesInSolr()
+	tokenStore := auth.NewMySQLTokenStore(db.DB)
+	authService := auth.NewService(auth.Options{
+		Key:        []byte(cfg.JWTKey),
+		Issuer:     cfg.JWTIssuer,
+		Audience:   cfg.JWTAudience,
+		AccessTTL:  cfg.AccessTokenDuration(),
+		RefreshTTL: cfg.RefreshTokenDuration(),
+	}, tokenStore)
+	authenticate := authService.Authenticate
+
+	// protect exige autenticación y todos los permisos indicados para la ruta
+	protect := func(next http.HandlerFunc, perms ...auth.Permission) http.HandlerFunc {
+		return authenticate(middleware.RequirePermission(auth.DefaultPolicy, perms...)(next))
+	}
+
+	userHandler := users.NewHandler(userRepo, authService)
+	courseHandler := courses.NewHandler(courseRepo, enrollmentRepo, userRepo, broker, auth.DefaultPolicy, quizzes.NewCompletionGate(quizRepo), issuer)
+	quizHandler := quizzes.NewHandler(quizRepo, attemptRepo, courseRepo, enrollmentRepo, auth.DefaultPolicy)
+	certificateHandler := certificates.NewHandler(issuer, certificateRepo, enrollmentRepo, signer, auth.DefaultPolicy, cfg.PublicURL)
+	reviewHandler := reviews.NewHandler(reviewRepo, courseRepo, enrollmentRepo, userRepo, broker, auth.DefaultPolicy)
+	taxonomyHandler := taxonomy.NewHandler(categoryRepo, tagRepo, courseRepo, broker, auth.DefaultPolicy)
+	searchHandler := search.NewHandler(searchEngine)
+
+	// Indexador que consume los eventos de cursos
+	go func() {
+		if err := courses.NewIndexWorker(broker, searchEngine).Run(context.Background()); err != nil {
+			log.Println("El indexador de cursos se detuvo:", err)
+		}
+	}()
+
+	// La reindexación completa se lanza desde POST /admin/reindex o el subcomando reindex;
+	// el motor en memoria no persiste, así que se carga al iniciar
+	reindexer := courses.NewReindexer(courseRepo, searchEngine)
+	if cfg.SearchEngine == search.EngineMemory {
+		go reindexer.Run(context.Background())
+	}
+
+	// Reconciliación periódica entre MongoDB y el índice
+	reconciler := courses.NewReconciler(courseRepo, searchEngine)
+	if interval := cfg.ReconcileIntervalDuration(); interval > 0 {
+		go reconciler.Schedule(context.Background(), interval, cfg.ReconcileRepair)
+	}
 
 	// Crear un nuevo mux
 	mux := http.NewServeMux()
 
-	// Rutas del backend
-	mux.HandleFunc("/users", users.GetAllUsers)           // GET /users
-	mux.HandleFunc("/users/login", users.Login)           // POST /users/login
-	mux.HandleFunc("/users/register", users.RegisterUser) // POST /users/register
-	mux.HandleFunc("/users/update", users.UpdateUser)     // PUT /users
+	// Rutas del backend; las protegidas declaran los permisos que exigen
+	mux.HandleFunc("/users", protect(userHandler.GetAllUsers, auth.PermUserRead)) // GET /users
+	mux.HandleFunc("/users/login", userHandler.Login)                             // POST /users/login
+	mux.HandleFunc("/users/refresh", userHandler.Refresh)                         // POST /users/refresh
+	mux.HandleFunc("/users/logout", authenticate(userHandler.Logout))             // POST /users/logout
+	mux.HandleFunc("/users/register", userHandler.RegisterUser)                   // POST /users/register
+	mux.HandleFunc("GET /users/me", authenticate(userHandler.GetMe))
+	mux.HandleFunc("PUT /users/me", authenticate(userHandler.UpdateMe))
+	mux.HandleFunc("PUT /users/{id}/role", protect(userHandler.ChangeRole, auth.PermUserWrite))
+	mux.HandleFunc("GET /users/{id}/role-changes", protect(userHandler.ListRoleChanges, auth.PermUserRead))
 
 	// Manejo de rutas para cursos
 	mux.HandleFunc("/courses", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			courses.GetCourses(w, r)
+			courseHandler.GetCourses(w, r)
 		case http.MethodPost:
-			middleware.CheckRole("admin", courses.CreateCourse)(w, r) // Solo los administradores pueden crear cursos
+			protect(courseHandler.CreateCourse, auth.PermCourseCreate)(w, r)
 		default:
-			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+			apierror.Write(w, r, apierror.ErrMethodNotAllowed)
 		}
 	})
-	mux.HandleFunc("/courses/", courses.GetCourseByID)        // GET /courses/{id}
-	mux.HandleFunc("/courses/update/", courses.UpdateCourse)  // PUT /courses/update/{id}
-	mux.HandleFunc("/courses/enroll", courses.EnrollUser)     // POST /courses/enroll
-	mux.HandleFunc("/enrollments", courses.GetEnrollments)    // GET /enrollments
-	mux.HandleFunc("/search", search.SearchCourses)           // GET /search?q=<query>
-	mux.HandleFunc("/courses/unenroll", courses.UnenrollUser) // DELETE /courses/Unenroll
+	mux.HandleFunc("/courses/", courseHandler.GetCourseByID) // GET /courses/{id}
+	mux.HandleFunc("PUT /courses/update/{id}", protect(courseHandler.UpdateCourse, auth.PermCourseWriteOwn))
+	// Las rutas con método evitan el conflicto con DELETE /courses/{id}
+	mux.HandleFunc("DELETE /courses/{id}", protect(courseHandler.DeleteCourse, auth.PermCourseDeleteOwn)) // ?mode=soft|hard
+	mux.HandleFunc("GET /courses/{id}/modules", protect(courseHandler.GetModules, auth.PermEnrollmentSelf))
+	mux.HandleFunc("POST /courses/{id}/modules", protect(courseHandler.CreateModule, auth.PermCourseWriteOwn))
+	mux.HandleFunc("PUT /courses/{id}/modules/{module_id}", protect(courseHandler.UpdateModule, auth.PermCourseWriteOwn))
+	mux.HandleFunc("DELETE /courses/{id}/modules/{module_id}", protect(courseHandler.DeleteModule, auth.PermCourseWriteOwn))
+	mux.HandleFunc("POST /courses/{id}/modules/{module_id}/lessons", protect(courseHandler.CreateLesson, auth.PermCourseWriteOwn))
+	mux.HandleFunc("PUT /courses/{id}/modules/{module_id}/lessons/{lesson_id}", protect(courseHandler.UpdateLesson, auth.PermCourseWriteOwn))
+	mux.HandleFunc("DELETE /courses/{id}/modules/{module_id}/lessons/{lesson_id}", protect(courseHandler.DeleteLesson, auth.PermCourseWriteOwn))
+	mux.HandleFunc("POST /courses/enroll", protect(courseHandler.EnrollUser, auth.PermEnrollmentSelf))
+	mux.HandleFunc("/enrollments", protect(courseHandler.GetEnrollments, auth.PermEnrollmentSelf)) // GET /enrollments?status=
+	mux.HandleFunc("PUT /enrollments/{course_id}/progress", protect(courseHandler.UpdateProgress, auth.PermEnrollmentManage))
+	mux.HandleFunc("POST /enrollments/{course_id}/complete", protect(courseHandler.CompleteEnrollment, auth.PermEnrollmentSelf))
+	mux.HandleFunc("POST /enrollments/{course_id}/lessons/{lesson_id}/complete", protect(courseHandler.CompleteLesson, auth.PermEnrollmentSelf))
+
+	mux.HandleFunc("/search", searchHandler.SearchCourses) // GET /search?q=<query>
+	mux.HandleFunc("DELETE /courses/unenroll", protect(courseHandler.UnenrollUser, auth.PermEnrollmentSelf))
+
+	// Evaluaciones; las rutas de edición verifican además que el curso sea del instructor
+	mux.HandleFunc("GET /courses/{id}/quizzes", protect(quizHandler.ListQuizzes, auth.PermEnrollmentSelf))
+	mux.HandleFunc("POST /courses/{id}/quizzes", protect(quizHandler.CreateQuiz, auth.PermCourseWriteOwn))
+	mux.HandleFunc("GET /quizzes/{id}", protect(quizHandler.GetQuiz, auth.PermEnrollmentSelf))
+	mux.HandleFunc("PUT /quizzes/{id}", protect(quizHandler.UpdateQuiz, auth.PermCourseWriteOwn))
+	mux.HandleFunc("DELETE /quizzes/{id}", protect(quizHandler.DeleteQuiz, auth.PermCourseWriteOwn))
+	mux.HandleFunc("GET /quizzes/{id}/attempts", protect(quizHandler.ListAttempts, auth.PermEnrollmentSelf))
+	mux.HandleFunc("POST /quizzes/{id}/attempts", protect(quizHandler.StartAttempt, auth.PermEnrollmentSelf))
+	mux.HandleFunc("POST /quizzes/{id}/attempts/{attempt_id}/submit", protect(quizHandler.SubmitAttempt, auth.PermEnrollmentSelf))
+
+	// Certificados; la verificación es pública
+	mux.HandleFunc("GET /enrollments/{course_id}/certificate", protect(certificateHandler.GetEnrollmentCertificate, auth.PermEnrollmentSelf))
+	mux.HandleFunc("GET /certificates/{id}", protect(certificateHandler.GetCertificate, auth.PermEnrollmentSelf))
+	mux.HandleFunc("GET /certificates/{id}/verify", certificateHandler.VerifyCertificate)
+
+	// Reseñas; el listado de un curso es público y el autor solo puede editar la suya
+	mux.HandleFunc("GET /courses/{id}/reviews", reviewHandler.ListCourseReviews)
+	mux.HandleFunc("POST /courses/{id}/reviews", protect(reviewHandler.CreateReview, auth.PermEnrollmentSelf))
+	mux.HandleFunc("PUT /reviews/{id}", protect(reviewHandler.UpdateReview, auth.PermEnrollmentSelf))
+	mux.HandleFunc("DELETE /reviews/{id}", protect(reviewHandler.DeleteReview, auth.PermEnrollmentSelf))
+	mux.HandleFunc("GET /reviews", protect(reviewHandler.ListReviews, auth.PermReviewModerate))
+	mux.HandleFunc("PUT /reviews/{id}/moderation", protect(reviewHandler.ModerateReview, auth.PermReviewModerate))
+
+	// Categorías y etiquetas; las administra taxonomy:manage y cada instructor clasifica sus cursos
+	mux.HandleFunc("GET /categories", taxonomyHandler.GetCategories)
+	mux.HandleFunc("POST /categories", protect(taxonomyHandler.CreateCategory, auth.PermTaxonomyManage))
+	mux.HandleFunc("PUT /categories/{id}", protect(taxonomyHandler.UpdateCategory, auth.PermTaxonomyManage))
+	mux.HandleFunc("DELETE /categories/{id}", protect(taxonomyHandler.DeleteCategory, auth.PermTaxonomyManage))
+	mux.HandleFunc("GET /tags", taxonomyHandler.GetTags)
+	mux.HandleFunc("POST /tags", protect(taxonomyHandler.CreateTag, auth.PermTaxonomyManage))
+	mux.HandleFunc("PUT /tags/{id}", protect(taxonomyHandler.UpdateTag, auth.PermTaxonomyManage))
+	mux.HandleFunc("DELETE /tags/{id}", protect(taxonomyHandler.DeleteTag, auth.PermTaxonomyManage))
+	mux.HandleFunc("POST /tags/{id}/merge", protect(taxonomyHandler.MergeTag, auth.PermTaxonomyManage))
+	mux.HandleFunc("POST /courses/{id}/classification", protect(taxonomyHandler.SetCourseClassification, auth.PermCourseWriteOwn))
+
+	// Administración de la búsqueda
+	mux.HandleFunc("POST /admin/reindex", protect(reindexer.StartReindex, auth.PermSearchReindex))
+	mux.HandleFunc("GET /admin/reindex", protect(reindexer.GetReindexStatus, auth.PermSearchReindex))
+	mux.HandleFunc("GET /admin/reconcile", protect(reconciler.GetLastReconcile, auth.PermSearchReconcile))
 
 	// Usar el middleware para habilitar CORS
-	handler := enableCors(mux)
+	handler := enableCors(cfg.CORSOrigin, i18n.Middleware(mux))
 
 	// Iniciar el servidor
-	log.Println("Servidor iniciado en http://localhost:8080")
-	if err := http.ListenAndServe(":8080", handler); err != nil {
+	log.Println("Servidor iniciado en", cfg.ListenAddr)
+	if err := http.ListenAndServe(cfg.ListenAddr, handler); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,43 @@
+package queue
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClosed se devuelve cuando se usa un broker que ya fue cerrado
+var ErrClosed = errors.New("broker cerrado")
+
+// Message representa un mensaje recibido desde una cola
+type Message struct {
+	Body []byte
+
+	ack  func() error
+	nack func(requeue bool) error
+}
+
+// Ack confirma que el mensaje fue procesado
+func (m Message) Ack() error {
+	if m.ack == nil {
+		return nil
+	}
+	return m.ack()
+}
+
+// Nack rechaza el mensaje; si requeue es true vuelve a la cola
+func (m Message) Nack(requeue bool) error {
+	if m.nack == nil {
+		return nil
+	}
+	return m.nack(requeue)
+}
+
+// Broker abstrae el sistema de mensajería usado para publicar y consumir eventos
+type Broker interface {
+	// Publish envía el cuerpo a la cola indicada
+	Publish(ctx context.Context, queue string, body []byte) error
+	// Consume devuelve un canal con los mensajes de la cola; se cierra al cancelar ctx
+	Consume(ctx context.Context, queue string) (<-chan Message, error)
+	// Close libera las conexiones del broker
+	Close() error
+}
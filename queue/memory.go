@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryQueueSize es la capacidad de cada cola en memoria
+const memoryQueueSize = 1024
+
+// MemoryBroker es un broker en memoria para desarrollo y pruebas
+type MemoryBroker struct {
+	mu     sync.Mutex
+	queues map[string]chan []byte
+	closed bool
+}
+
+// NewMemoryBroker crea un broker en memoria vacío
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{queues: make(map[string]chan []byte)}
+}
+
+func (b *MemoryBroker) queue(name string) (chan []byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+	q, ok := b.queues[name]
+	if !ok {
+		q = make(chan []byte, memoryQueueSize)
+		b.queues[name] = q
+	}
+	return q, nil
+}
+
+// Publish encola una copia del cuerpo en la cola indicada
+func (b *MemoryBroker) Publish(ctx context.Context, queue string, body []byte) error {
+	q, err := b.queue(queue)
+	if err != nil {
+		return err
+	}
+
+	msg := make([]byte, len(body))
+	copy(msg, body)
+
+	select {
+	case q <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Consume entrega los mensajes de la cola hasta que se cancele ctx
+func (b *MemoryBroker) Consume(ctx context.Context, queue string) (<-chan Message, error) {
+	q, err := b.queue(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Message)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case body := <-q:
+				msg := Message{
+					Body: body,
+					nack: func(requeue bool) error {
+						if requeue {
+							return b.Publish(context.Background(), queue, body)
+						}
+						return nil
+					},
+				}
+				select {
+				case out <- msg:
+				case <-ctx.Done():
+					// Devolver el mensaje a la cola para no perderlo
+					select {
+					case q <- body:
+					default:
+					}
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Pending devuelve la cantidad de mensajes pendientes en una cola
+func (b *MemoryBroker) Pending(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.queues[queue])
+}
+
+// Close marca el broker como cerrado
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
@@ -0,0 +1,189 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// RabbitMQ implementa Broker sobre una conexión AMQP. Si el canal de publicación o la conexión se
+// cierran, el siguiente Publish los vuelve a abrir.
+type RabbitMQ struct {
+	url string
+
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+	declared map[string]bool
+	closed   bool
+}
+
+// ConnectRabbitMQ establece la conexión con RabbitMQ
+func ConnectRabbitMQ(url string) (*RabbitMQ, error) {
+	r := &RabbitMQ{url: url, declared: make(map[string]bool)}
+	if _, err := r.channel(); err != nil {
+		return nil, err
+	}
+	log.Println("Conexión a RabbitMQ exitosa")
+	return r, nil
+}
+
+// connection devuelve la conexión abierta, reconectando si se cerró; se llama con mu tomado
+func (r *RabbitMQ) connection() (*amqp.Connection, error) {
+	if r.closed {
+		return nil, ErrClosed
+	}
+	if r.conn != nil && !r.conn.IsClosed() {
+		return r.conn, nil
+	}
+	conn, err := amqp.Dial(r.url)
+	if err != nil {
+		return nil, fmt.Errorf("error al conectar a RabbitMQ: %w", err)
+	}
+	r.conn = conn
+	return conn, nil
+}
+
+// channel devuelve el canal de publicación, abriéndolo si no hay uno; se llama con mu tomado
+func (r *RabbitMQ) channel() (*amqp.Channel, error) {
+	if r.ch != nil {
+		return r.ch, nil
+	}
+	conn, err := r.connection()
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir canal de RabbitMQ: %w", err)
+	}
+
+	// Al cerrarse el canal, por un error del servidor o porque cayó la conexión, se descarta para
+	// que el siguiente Publish abra otro
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		if err := <-closed; err != nil {
+			log.Println("Canal de publicación de RabbitMQ cerrado:", err)
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.ch == ch {
+			r.ch = nil
+			r.declared = make(map[string]bool)
+		}
+	}()
+	r.ch = ch
+	return ch, nil
+}
+
+// declareQueue declara la cola como durable
+func declareQueue(ch *amqp.Channel, name string) error {
+	_, err := ch.QueueDeclare(name, true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("error al declarar la cola %s: %w", name, err)
+	}
+	return nil
+}
+
+// Publish publica un mensaje persistente en la cola indicada
+func (r *RabbitMQ) Publish(ctx context.Context, queue string, body []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ch, err := r.channel()
+	if err != nil {
+		return err
+	}
+	if !r.declared[queue] {
+		if err := declareQueue(ch, queue); err != nil {
+			return err
+		}
+		r.declared[queue] = true
+	}
+
+	return ch.Publish("", queue, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Body:         body,
+	})
+}
+
+// Consume abre un canal propio y entrega los mensajes de la cola sin ack automático
+func (r *RabbitMQ) Consume(ctx context.Context, queue string) (<-chan Message, error) {
+	r.mu.Lock()
+	conn, err := r.connection()
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir canal de RabbitMQ: %w", err)
+	}
+	if err := declareQueue(ch, queue); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	// Procesar de a un mensaje para respetar el orden de los eventos
+	if err := ch.Qos(1, 0, false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("error al configurar QoS: %w", err)
+	}
+
+	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("error al consumir la cola %s: %w", queue, err)
+	}
+
+	out := make(chan Message)
+	go func() {
+		defer close(out)
+		defer ch.Close()
+		for {
+			select {
+			case d, ok := <-deliveries:
+				if !ok {
+					return
+				}
+				msg := Message{
+					Body: d.Body,
+					ack:  func() error { return d.Ack(false) },
+					nack: func(requeue bool) error { return d.Nack(false, requeue) },
+				}
+				select {
+				case out <- msg:
+				case <-ctx.Done():
+					d.Nack(false, true)
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Close cierra el canal y la conexión con RabbitMQ
+func (r *RabbitMQ) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	if r.ch != nil {
+		r.ch.Close()
+	}
+	if r.conn == nil || r.conn.IsClosed() {
+		return nil
+	}
+	return r.conn.Close()
+}